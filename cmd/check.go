@@ -5,26 +5,210 @@ Copyright © 2025 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
 	"github.com/spf13/cobra"
+	"gitlab.com/kobot/kobot/pkg/checks"
+	"gitlab.com/kobot/kobot/pkg/common"
+	"gitlab.com/kobot/kobot/pkg/logging"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+var (
+	outputFormat string
+	htmlOutput   bool
+	failOn       string
+	withEvents   bool
+	// excludeNamespaces are names or patterns of namespaces never to scan.
+	excludeNamespaces []string
+	namespaceSelector string
+	concurrency       int
+	// ignoreFile is the waiver file; empty reads .kobotignore when present.
+	ignoreFile string
+	waivers    *checks.Waivers
+	// failThreshold is the parsed --fail-on value; empty means findings never fail the run.
+	failThreshold checks.Severity
 )
 
 // checkCmd represents the check command
 var checkCmd = &cobra.Command{
 	Use:   "check",
 	Short: "Check the health of the entire cluster or a specific resource in the cluster.",
-	Long: `Check the health of the entire cluster or a specific resource in the cluster (helmReleases, deployments, custom CRDs, etc).`,
+	Long: `Check the health of the entire cluster or a specific resource in the cluster (helmReleases, deployments, custom CRDs, etc).
+
+Exit codes:
+  0  healthy: every check ran and no finding reached the --fail-on severity
+  1  usage or unexpected error
+  2  at least one finding at or above the --fail-on severity
+  3  partial scan: a check or namespace could not be scanned because of API errors
+     (checks of components that are not installed, e.g. Flux or Istio, are skipped instead)
+  4  cannot connect to the cluster
+
+When findings and scan errors both occur, exit code 2 takes precedence.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyConfig(cmd); err != nil {
+			return err
+		}
+
+		format, err := checks.ParseOutput(outputFormat)
+		if err != nil {
+			return err
+		}
+		outputFormat = format
+
+		failThreshold = ""
+		if failOn != "none" {
+			if failThreshold, err = checks.ParseSeverity(failOn); err != nil {
+				return fmt.Errorf("invalid --fail-on value: %w", err)
+			}
+		}
+		if err := namespaceSelection().Validate(); err != nil {
+			return err
+		}
+		if _, err := labels.Parse(labelSelector); err != nil {
+			return fmt.Errorf("invalid --selector value: %w", err)
+		}
+		if _, err := fields.ParseSelector(fieldSelector); err != nil {
+			return fmt.Errorf("invalid --field-selector value: %w", err)
+		}
+		if concurrency < 1 {
+			return fmt.Errorf("invalid --concurrency value %d: must be at least 1", concurrency)
+		}
+
+		// an explicit waiver file must exist, the one in the working directory is optional
+		if ignoreFile != "" {
+			waivers, err = checks.LoadWaivers(ignoreFile, true)
+		} else {
+			waivers, err = checks.LoadWaivers(checks.WaiverFileName, false)
+		}
+		if err != nil {
+			return err
+		}
+
+		// machine-readable output must not be mixed with the decorative logging
+		logging.SetQuiet(outputFormat != checks.OutputConsole)
+
+		common.UseConnectionOptions(connOptions)
+		return nil
+	},
 }
 
-func init() {
-	rootCmd.AddCommand(checkCmd)
+// runChecks connects to the cluster with the clients the selected checks need,
+// runs them in one pass and writes the report in the selected output format.
+// The returned error carries the process exit code (see exit.go).
+func runChecks(selected []checks.Check) error {
+	ctx := context.Background()
+	started := time.Now()
+
+	// visual gap between the commmand in the first log message
+	if outputFormat == checks.OutputConsole {
+		fmt.Println()
+	}
+
+	// the typed clientset is always needed to resolve namespaces
+	env := &checks.Env{
+		Deep:             podDeepCheck || podLogs,
+		FluxGrace:        time.Duration(fluxGracePeriod) * time.Second,
+		CertExpiryWindow: time.Duration(certExpiryDays) * 24 * time.Hour,
+		Events:           withEvents,
+		Concurrency:      concurrency,
+		LabelSelector:    labelSelector,
+		FieldSelector:    fieldSelector,
+	}
+	if podLogs {
+		env.Logs = checks.LogOptions{Lines: podLogLines, MaxBytes: podLogBytes}
+	}
+	clientset := common.EnsureClusterConnection()
+	if clientset == nil {
+		return newExitError(ExitConnectionFailed, "cannot connect to the cluster")
+	}
+	env.Clientset = clientset
 
-	// Here you will define your flags and configuration settings.
+	needed := checks.RequiredClients(selected)
+	for _, client := range waivers.RequiredClients() {
+		needed[client] = true
+	}
+	if needed[checks.DynamicClient] {
+		if env.Dynamic = common.EnsureDynamicClusterConnection(); env.Dynamic == nil {
+			return newExitError(ExitConnectionFailed, "cannot connect to the cluster")
+		}
+	}
 
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// checkCmd.PersistentFlags().String("foo", "", "A help for foo")
+	if checks.NeedsNamespaces(selected) {
+		namespaces, err := checks.ResolveNamespaces(ctx, env.Clientset, namespaceSelection())
+		if err != nil {
+			logging.Error("%v", err)
+			return newExitError(ExitPartialScan, "%v", err)
+		}
+		env.Namespaces = namespaces
+	}
+
+	results := checks.RunChecks(ctx, env, selected)
+	results = waivers.Apply(ctx, env, results)
+
+	if outputFormat == checks.OutputConsole {
+		checks.PrintReport(results)
+	} else {
+		meta := checks.ReportMetadata{
+			KobotVersion: CliVersion,
+			StartedAt:    started,
+			FinishedAt:   time.Now(),
+			Namespaces:   env.Namespaces,
+		}
+		if conn, err := common.Connection(); err == nil {
+			meta.Context = conn.Context
+			meta.Server = conn.Config.Host
+		}
+
+		report := checks.BuildReport(results, meta)
+		if err := checks.WriteReport(os.Stdout, report, outputFormat); err != nil {
+			logging.Error("Failed to write %s report: %v", outputFormat, err)
+		}
+	}
+
+	// Generate HTML report if requested
+	if htmlOutput {
+		if err := checks.WriteHTMLReport(results, "kobot-report.html"); err != nil {
+			logging.Error("Failed to write HTML report: %v", err)
+		} else {
+			logging.Success("HTML report saved as kobot-report.html\n")
+		}
+	}
+
+	return exitStatus(results)
+}
+
+// namespaceSelection collects the namespace flags of the running command.
+func namespaceSelection() checks.NamespaceSelection {
+	return checks.NamespaceSelection{Include: namespace, Exclude: excludeNamespaces, Selector: namespaceSelector}
+}
+
+// exitStatus maps the results of a run to the documented exit codes.
+func exitStatus(results []*checks.Result) error {
+	if failThreshold != "" {
+		if failing := checks.AtOrAbove(results, failThreshold); len(failing) > 0 {
+			return newExitError(ExitFindings, "%d finding(s) at or above severity %s", len(failing), failThreshold)
+		}
+	}
+	if errs := checks.ScanErrors(results); errs > 0 {
+		return newExitError(ExitPartialScan, "%d scan error(s), results are partial", errs)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
 
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// checkCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	checkCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", checks.OutputConsole, "Output format: console, json or yaml (json/yaml suppress all decorative logging)")
+	checkCmd.PersistentFlags().BoolVar(&htmlOutput, "html", false, "Generate an HTML report (kobot-report.html)")
+	checkCmd.PersistentFlags().BoolVar(&withEvents, "events", true, "Attach recent Warning events of each failing object and its owners to its findings")
+	checkCmd.PersistentFlags().StringVar(&failOn, "fail-on", string(checks.SeverityCritical), "Exit with code 2 when a finding reaches this severity: info, warning, critical or none")
+	checkCmd.PersistentFlags().StringSliceVar(&excludeNamespaces, "exclude-namespace", []string{}, "Namespaces never to check: names, globs (kube-*) or regular expressions between slashes (/^test-[0-9]+$/)")
+	checkCmd.PersistentFlags().StringVar(&namespaceSelector, "namespace-selector", "", "Only check namespaces matching this label selector (e.g. team=payments)")
+	checkCmd.PersistentFlags().IntVar(&concurrency, "concurrency", 1, "Number of checks to run at the same time")
+	checkCmd.PersistentFlags().StringVar(&ignoreFile, "ignore-file", "", "Waiver file of accepted findings (default: "+checks.WaiverFileName+" when present)")
 }
@@ -4,54 +4,118 @@ Copyright © 2025 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
-	"gitlab.com/kobot/kobot/pkg/common"
+	"fmt"
+	"strings"
+
 	"github.com/spf13/cobra"
 	"gitlab.com/kobot/kobot/pkg/checks"
+	"gitlab.com/kobot/kobot/pkg/logging"
 )
 
 var (
 	namespace       []string
-	htmlOutput      bool
 	helmRelease     bool
 	fluxGracePeriod int
-	podDeepCheck bool
+	podDeepCheck    bool
+	podLogs         bool
+	podLogLines     int64
+	podLogBytes     int
+	certExpiryDays  int
+	ruleFiles       []string
+	enabledChecks   []string
+	disabledChecks  []string
+	extraResources  []string
+	labelSelector   string
+	fieldSelector   string
 )
 
+// defaultHelmReleaseNamespace is scanned by --helmrelease-only when no namespace is selected.
+const defaultHelmReleaseNamespace = "bigbang"
+
 var clusterCmd = &cobra.Command{
 	Use:   "cluster",
 	Short: "Check overall cluster health across all namespaces",
-	Run: func(cmd *cobra.Command, args []string) {
+	Long: `Runs every registered health check against the cluster in a single pass.
+
+Use --helmrelease-only to limit the scan to Flux HelmReleases (in the bigbang
+namespace unless -n or --namespace-selector is given) and --deep to
+perform a container and condition level pod analysis. --logs additionally
+embeds the log tail of crashed containers (it implies --deep).`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		selected, err := selectChecks(enabledChecks, disabledChecks)
+		if err != nil {
+			return err
+		}
 
-		// if the user want to run helmrelease checks
+		// if the user want to run helmrelease checks only
 		if helmRelease {
-			dynamicClient := common.EnsureDynamicClusterConnection()
-			if dynamicClient == nil {
-				return
+			hr, _ := checks.Lookup("helmreleases")
+			selected = []checks.Check{hr}
+
+			// the HelmRelease-only scan keeps its historical default namespace
+			if len(namespace) == 0 && namespaceSelector == "" {
+				logging.Warn("No namespaces specified — defaulting to '%s'.", defaultHelmReleaseNamespace)
+				namespace = []string{defaultHelmReleaseNamespace}
 			}
-			checks.RunHelmReleaseCheck(dynamicClient, namespace, htmlOutput, fluxGracePeriod)
-			return
 		}
 
-		// if the user wants to run a deep pod health check
-		if podDeepCheck {
-			clientset := common.EnsureClusterConnection()
-			if clientset == nil {
-				return
+		for _, name := range extraResources {
+			gvr, err := checks.ParseResource(name)
+			if err != nil {
+				return err
 			}
-			checks.RunPodDeepCheck(clientset, namespace, htmlOutput)
-			return
+			selected = append(selected, checks.NewResourceCheck(gvr))
 		}
 
-		// default behavior of running a low level pod health check
-		clientset := common.EnsureClusterConnection()
-		if clientset == nil {
-			return
+		rules, err := loadRuleFiles(ruleFiles)
+		if err != nil {
+			return err
 		}
+		selected = append(selected, rules...)
+
+		// from here on errors are scan outcomes, not usage mistakes
+		cmd.SilenceUsage = true
 
-		checks.RunPodCheck(clientset, namespace, htmlOutput)
+		return runChecks(selected)
 	},
 }
 
+// selectChecks returns the registered checks to run: all of them or only the
+// enabled ones, minus the disabled ones. Unknown names are an error.
+func selectChecks(enabled, disabled []string) ([]checks.Check, error) {
+	for _, name := range append(append([]string{}, enabled...), disabled...) {
+		if _, ok := checks.Lookup(name); !ok {
+			var known []string
+			for _, c := range checks.Registered() {
+				known = append(known, c.Name())
+			}
+			return nil, fmt.Errorf("unknown check %q (available: %s)", name, strings.Join(known, ", "))
+		}
+	}
+
+	skip := make(map[string]bool, len(disabled))
+	for _, name := range disabled {
+		skip[name] = true
+	}
+
+	candidates := checks.Registered()
+	if len(enabled) > 0 {
+		candidates = nil
+		for _, name := range enabled {
+			c, _ := checks.Lookup(name)
+			candidates = append(candidates, c)
+		}
+	}
+
+	var selected []checks.Check
+	for _, c := range candidates {
+		if !skip[c.Name()] {
+			selected = append(selected, c)
+		}
+	}
+	return selected, nil
+}
+
 func init() {
 	checkCmd.AddCommand(clusterCmd)
 	clusterCmd.Flags().StringSliceVarP(
@@ -59,10 +123,19 @@ func init() {
 		"namespace",
 		"n",
 		[]string{},
-		"Comma-separated list of namespaces to check (default: all)",
+		"Comma-separated list of namespaces, globs or /regex/ patterns to check (default: all)",
 	)
-	clusterCmd.Flags().BoolVar(&htmlOutput, "html", false, "Generate an HTML report (kobot-report.html)")
-	clusterCmd.Flags().BoolVar(&helmRelease, "helmrelease-only", false, "Run only HelmRelease checks")
-	clusterCmd.Flags().IntVar(&fluxGracePeriod, "flux-grace", 5, "Time (in seconds) to wait for Flux-managed resources to become Ready (default: 5s)")
+	clusterCmd.Flags().BoolVar(&helmRelease, "helmrelease-only", false, "Run only HelmRelease checks (default namespace: "+defaultHelmReleaseNamespace+")")
+	clusterCmd.Flags().IntVar(&fluxGracePeriod, "flux-grace", 5, "Maximum time (in seconds) to wait for not-ready HelmReleases to reconcile; they are re-fetched until Ready or the deadline passes")
 	clusterCmd.Flags().BoolVar(&podDeepCheck, "deep", false, "Performs a deeper pod health analysis when running the check cluster command")
+	clusterCmd.Flags().BoolVar(&podLogs, "logs", false, "Embed the log tail of crash-looping and failed containers in the report (implies --deep)")
+	clusterCmd.Flags().Int64Var(&podLogLines, "log-lines", 50, "Number of log lines to tail per crashed container with --logs")
+	clusterCmd.Flags().IntVar(&podLogBytes, "log-bytes", 8192, "Maximum bytes of log kept per crashed container with --logs")
+	clusterCmd.Flags().StringSliceVar(&enabledChecks, "checks", []string{}, "Run only these checks (default: all registered checks)")
+	clusterCmd.Flags().StringSliceVar(&disabledChecks, "skip-checks", []string{}, "Checks to leave out of the run")
+	clusterCmd.Flags().StringSliceVar(&extraResources, "resource", []string{}, "Custom resources (group/version/resource) to check with the generic condition rules")
+	clusterCmd.Flags().StringSliceVar(&ruleFiles, "rules", []string{}, "Rules files with custom CEL checks to run alongside the built-in checks")
+	clusterCmd.Flags().StringVarP(&labelSelector, "selector", "l", "", "Only check pods and HelmReleases matching this label selector")
+	clusterCmd.Flags().StringVar(&fieldSelector, "field-selector", "", "Only check pods matching this field selector (e.g. spec.nodeName=node-1)")
+	clusterCmd.Flags().IntVar(&certExpiryDays, "cert-expiry", 30, "Report TLS certificates expiring within this many days")
 }
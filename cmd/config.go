@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gitlab.com/kobot/kobot/pkg/config"
+)
+
+// configFile is the project config file given with --config.
+var configFile string
+
+// applyConfig defaults every flag the user did not set from its KOBOT_* environment
+// variable or, failing that, from the config files. The resulting precedence is
+// flags > environment > project file > user file.
+func applyConfig(cmd *cobra.Command) error {
+	userPath := ""
+	if home, err := os.UserHomeDir(); err == nil {
+		userPath = filepath.Join(home, config.FileName)
+	}
+
+	// an explicit project file must exist, the one in the working directory is optional
+	projectPath, required := configFile, true
+	if projectPath == "" {
+		projectPath = os.Getenv(config.EnvName("config"))
+	}
+	if projectPath == "" {
+		projectPath, required = config.FileName, false
+	}
+
+	cfg, err := config.Load(userPath, projectPath, required)
+	if err != nil {
+		return err
+	}
+	values := cfg.FlagValues()
+
+	var setErr error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if setErr != nil || f.Changed || f.Name == "config" || f.Name == "help" {
+			return
+		}
+
+		source := config.EnvName(f.Name)
+		value, ok := os.LookupEnv(source)
+		if !ok {
+			source = "config file"
+			if value, ok = values[f.Name]; !ok {
+				return
+			}
+		}
+		if err := f.Value.Set(value); err != nil {
+			setErr = fmt.Errorf("invalid %s value %q from %s: %w", f.Name, value, source, err)
+		}
+	})
+	return setErr
+}
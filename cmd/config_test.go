@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestApplyConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		user      string            // ~/.kobot.yaml
+		project   string            // ./.kobot.yaml
+		explicit  string            // file given with --config
+		configArg string            // --config value, relative to the temp dir
+		env       map[string]string // KOBOT_* variables
+		flags     map[string]string // flags set on the command line
+		want      map[string]string
+		wantErr   string
+	}{
+		{
+			name: "defaults without any source",
+			want: map[string]string{"output": "console", "fail-on": "critical", "namespace": "[]"},
+		},
+		{
+			name: "user file",
+			user: "output: json\nnamespaces: [a, b]\n",
+			want: map[string]string{"output": "json", "namespace": "[a,b]", "fail-on": "critical"},
+		},
+		{
+			name:    "project file wins over user file",
+			user:    "output: json\nfailOn: info\n",
+			project: "output: yaml\n",
+			want:    map[string]string{"output": "yaml", "fail-on": "info"},
+		},
+		{
+			name:    "environment wins over project file",
+			project: "output: yaml\n",
+			env:     map[string]string{"KOBOT_OUTPUT": "json", "KOBOT_FAIL_ON": "warning"},
+			want:    map[string]string{"output": "json", "fail-on": "warning"},
+		},
+		{
+			name:    "flag wins over environment",
+			project: "output: yaml\n",
+			env:     map[string]string{"KOBOT_OUTPUT": "json"},
+			flags:   map[string]string{"output": "console"},
+			want:    map[string]string{"output": "console"},
+		},
+		{
+			name:      "explicit --config replaces the working directory file",
+			project:   "output: yaml\n",
+			explicit:  "failOn: none\n",
+			configArg: "team.yaml",
+			want:      map[string]string{"output": "console", "fail-on": "none"},
+		},
+		{
+			name:     "KOBOT_CONFIG selects the project file",
+			project:  "output: yaml\n",
+			explicit: "output: json\n",
+			env:      map[string]string{"KOBOT_CONFIG": "team.yaml"},
+			want:     map[string]string{"output": "json"},
+		},
+		{
+			name:      "missing explicit file",
+			configArg: "missing.yaml",
+			wantErr:   "unable to read config file",
+		},
+		{
+			name:    "unknown key",
+			project: "outptu: json\n",
+			wantErr: "unknown field",
+		},
+		{
+			name:    "invalid value names its source",
+			env:     map[string]string{"KOBOT_CONCURRENCY": "many"},
+			wantErr: "from KOBOT_CONCURRENCY",
+		},
+		{
+			name:    "nested params",
+			project: "params:\n  pods:\n    deep: true\n    logLines: 20\n  certificates:\n    expiryDays: 7\n",
+			want:    map[string]string{"deep": "true", "log-lines": "20", "cert-expiry": "7"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home, work := t.TempDir(), t.TempDir()
+			t.Setenv("HOME", home)
+			t.Chdir(work)
+			for _, name := range []string{"KOBOT_OUTPUT", "KOBOT_FAIL_ON", "KOBOT_CONFIG", "KOBOT_CONCURRENCY", "KOBOT_NAMESPACE"} {
+				t.Setenv(name, "")
+				os.Unsetenv(name)
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			write := func(path, content string) {
+				if content == "" {
+					return
+				}
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			write(filepath.Join(home, ".kobot.yaml"), tt.user)
+			write(filepath.Join(work, ".kobot.yaml"), tt.project)
+			write(filepath.Join(work, "team.yaml"), tt.explicit)
+
+			configFile = tt.configArg
+			defer func() { configFile = "" }()
+
+			cmd := &cobra.Command{Use: "test"}
+			cmd.Flags().String("config", "", "")
+			cmd.Flags().StringP("output", "o", "console", "")
+			cmd.Flags().String("fail-on", "critical", "")
+			cmd.Flags().StringSliceP("namespace", "n", []string{}, "")
+			cmd.Flags().Int("concurrency", 1, "")
+			cmd.Flags().Bool("deep", false, "")
+			cmd.Flags().Int64("log-lines", 50, "")
+			cmd.Flags().Int("cert-expiry", 30, "")
+			for k, v := range tt.flags {
+				if err := cmd.Flags().Set(k, v); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := applyConfig(cmd)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("applyConfig() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyConfig() error = %v", err)
+			}
+			for flag, want := range tt.want {
+				if got := cmd.Flags().Lookup(flag).Value.String(); got != want {
+					t.Errorf("--%s = %q, want %q", flag, got, want)
+				}
+			}
+		})
+	}
+}
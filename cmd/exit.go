@@ -0,0 +1,32 @@
+package cmd
+
+import "fmt"
+
+// Process exit codes returned by kobot. They are part of the CLI contract
+// (pipelines gate on them) so never renumber an existing code.
+const (
+	// ExitHealthy means every check ran and nothing reached the --fail-on threshold.
+	ExitHealthy = 0
+	// ExitError covers usage errors and anything unexpected.
+	ExitError = 1
+	// ExitFindings means at least one finding is at or above the --fail-on severity.
+	ExitFindings = 2
+	// ExitPartialScan means some checks or namespaces could not be scanned because of API errors,
+	// i.e. a check could not read what it should have read. Checks skipped because the
+	// component they inspect is not installed (no Flux, no Istio) never cause it.
+	ExitPartialScan = 3
+	// ExitConnectionFailed means kobot could not reach the cluster at all.
+	ExitConnectionFailed = 4
+)
+
+// exitError carries a specific process exit code back up to Execute.
+type exitError struct {
+	code int
+	msg  string
+}
+
+func (e *exitError) Error() string { return e.msg }
+
+func newExitError(code int, format string, args ...interface{}) *exitError {
+	return &exitError{code: code, msg: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"gitlab.com/kobot/kobot/pkg/checks"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   int
+		wantStderr string
+	}{
+		{name: "success", err: nil, wantCode: ExitHealthy},
+		{name: "findings", err: newExitError(ExitFindings, "%d finding(s) at or above severity %s", 3, checks.SeverityWarning), wantCode: ExitFindings, wantStderr: "3 finding(s) at or above severity warning\n"},
+		{name: "partial scan", err: newExitError(ExitPartialScan, "2 scan error(s), results are partial"), wantCode: ExitPartialScan, wantStderr: "2 scan error(s), results are partial\n"},
+		{name: "no connection", err: newExitError(ExitConnectionFailed, "cannot connect to the cluster"), wantCode: ExitConnectionFailed, wantStderr: "cannot connect to the cluster\n"},
+		{name: "wrapped exit error", err: fmt.Errorf("check: %w", newExitError(ExitFindings, "1 finding(s)")), wantCode: ExitFindings, wantStderr: "1 finding(s)\n"},
+		{name: "usage error", err: errors.New(`invalid --fail-on value: unknown severity "loud"`), wantCode: ExitError, wantStderr: "Error: invalid --fail-on value: unknown severity \"loud\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			if got := exitCode(tt.err, &stderr); got != tt.wantCode {
+				t.Errorf("exitCode() = %d, want %d", got, tt.wantCode)
+			}
+			if stderr.String() != tt.wantStderr {
+				t.Errorf("stderr = %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestExitStatus(t *testing.T) {
+	finding := func(sev checks.Severity) checks.Finding { return checks.Finding{Severity: sev, Reason: "Test"} }
+	warning := &checks.Result{Resource: "pods", Findings: []checks.Finding{finding(checks.SeverityWarning)}}
+	critical := &checks.Result{Resource: "pods", Findings: []checks.Finding{finding(checks.SeverityCritical)}}
+	scanError := &checks.Result{Resource: "pods", Namespaces: []checks.NamespaceResult{{Name: "shop", Error: "forbidden"}}}
+	skipped := &checks.Result{Resource: "Istio", Skipped: "istiod is not installed"}
+
+	tests := []struct {
+		name      string
+		threshold checks.Severity
+		results   []*checks.Result
+		want      int
+	}{
+		{name: "healthy", threshold: checks.SeverityCritical, results: []*checks.Result{{Resource: "pods"}}, want: ExitHealthy},
+		{name: "below the threshold", threshold: checks.SeverityCritical, results: []*checks.Result{warning}, want: ExitHealthy},
+		{name: "at the threshold", threshold: checks.SeverityWarning, results: []*checks.Result{warning}, want: ExitFindings},
+		{name: "above the threshold", threshold: checks.SeverityWarning, results: []*checks.Result{critical}, want: ExitFindings},
+		{name: "findings never fail", results: []*checks.Result{critical}, want: ExitHealthy},
+		{name: "scan error", threshold: checks.SeverityCritical, results: []*checks.Result{scanError}, want: ExitPartialScan},
+		{name: "findings win over scan errors", threshold: checks.SeverityCritical, results: []*checks.Result{scanError, critical}, want: ExitFindings},
+		{name: "skipped check is not a scan error", threshold: checks.SeverityCritical, results: []*checks.Result{skipped}, want: ExitHealthy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(previous checks.Severity) { failThreshold = previous }(failThreshold)
+			failThreshold = tt.threshold
+
+			var stderr bytes.Buffer
+			if got := exitCode(exitStatus(tt.results), &stderr); got != tt.want {
+				t.Errorf("exit code = %d, want %d (stderr %q)", got, tt.want, stderr.String())
+			}
+		})
+	}
+}
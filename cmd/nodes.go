@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"gitlab.com/kobot/kobot/pkg/checks"
+)
+
+var nodesCmd = &cobra.Command{
+	Use:   "nodes",
+	Short: "Check node readiness, pressure conditions, taints and capacity",
+	Long: `Reports NotReady nodes, Memory/Disk/PID pressure, cordoned nodes, unexpected
+NoSchedule/NoExecute taints, kubelet version skew against the API server and
+nodes whose allocatable capacity is almost fully requested.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		nodes, _ := checks.Lookup("nodes")
+		return runChecks([]checks.Check{nodes})
+	},
+}
+
+func init() {
+	checkCmd.AddCommand(nodesCmd)
+}
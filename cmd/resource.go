@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/kobot/kobot/pkg/checks"
+	"sigs.k8s.io/yaml"
+)
+
+var resourceFile string
+
+// resourceList is the file format of --file: the resources to check, one group/version/resource each.
+type resourceList struct {
+	Resources []string `json:"resources"`
+}
+
+var resourceCmd = &cobra.Command{
+	Use:   "resource <group/version/resource>...",
+	Short: "Check any custom resource with generic condition-based rules",
+	Long: `Evaluates every object of the given resources the way kstatus does: Stalled,
+Ready, Available and Reconciling conditions, status.observedGeneration against
+metadata.generation, spec.suspend and objects stuck terminating on their finalizers.
+Use it for operators' custom resources kobot has no dedicated check for.
+
+Resources are given as group/version/resource (version/resource for the core group),
+on the command line or in a YAML file passed with --file:
+
+  resources:
+    - postgresql.cnpg.io/v1/clusters
+    - kafka.strimzi.io/v1beta2/kafkas`,
+	Example: `  kobot check resource postgresql.cnpg.io/v1/clusters -n databases
+  kobot check resource --file resources.yaml`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		names := args
+		if resourceFile != "" {
+			fromFile, err := loadResourceList(resourceFile)
+			if err != nil {
+				return err
+			}
+			names = append(names, fromFile...)
+		}
+		if len(names) == 0 {
+			return fmt.Errorf("no resources given: pass group/version/resource arguments or --file")
+		}
+
+		var selected []checks.Check
+		for _, name := range names {
+			gvr, err := checks.ParseResource(name)
+			if err != nil {
+				return err
+			}
+			selected = append(selected, checks.NewResourceCheck(gvr))
+		}
+
+		cmd.SilenceUsage = true
+		return runChecks(selected)
+	},
+}
+
+// loadResourceList reads the resources listed in a --file YAML document.
+func loadResourceList(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read resource file: %w", err)
+	}
+	var list resourceList
+	if err := yaml.UnmarshalStrict(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid resource file %s: %w", path, err)
+	}
+	return list.Resources, nil
+}
+
+func init() {
+	checkCmd.AddCommand(resourceCmd)
+	resourceCmd.Flags().StringSliceVarP(&namespace, "namespace", "n", []string{}, "Comma-separated list of namespaces, globs or /regex/ patterns to check (default: all)")
+	resourceCmd.Flags().StringVarP(&resourceFile, "file", "f", "", "YAML file listing the resources to check")
+}
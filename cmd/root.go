@@ -5,15 +5,25 @@ Copyright © 2025 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"os"
+
 	"github.com/spf13/cobra"
+	"gitlab.com/kobot/kobot/pkg/cluster"
 )
 
+// connOptions holds the kubectl-style flags selecting the cluster and identity to use.
+var connOptions cluster.Options
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "kobot",
 	Short: "Kobot is a Kubernetes cluster health tool.",
 	Long: `kobot is a Kubernetes cluster health analyzer for operators and release engineers.`,
+	// errors are printed by Execute so exit codes and messages stay consistent
+	SilenceErrors: true,
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	// Run: func(cmd *cobra.Command, args []string) { },
@@ -22,19 +32,35 @@ var rootCmd = &cobra.Command{
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	err := rootCmd.Execute()
-	if err != nil {
-		os.Exit(1)
-	}
+	os.Exit(exitCode(rootCmd.Execute(), os.Stderr))
 }
 
-func init() {
+// exitCode prints the error a command returned to w and maps it to the process exit code.
+func exitCode(err error, w io.Writer) int {
+	if err == nil {
+		return ExitHealthy
+	}
 
-	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.kobot.yaml)")
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		if exitErr.msg != "" {
+			fmt.Fprintln(w, exitErr.msg)
+		}
+		return exitErr.code
+	}
 
-	// Cobra also supports local flags, which will only run
-	// when this action is called directly.
-	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	fmt.Fprintln(w, "Error:", err)
+	return ExitError
 }
 
+func init() {
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Project config file (default: ./.kobot.yaml, read on top of ~/.kobot.yaml; also $KOBOT_CONFIG)")
 
+	// same names and semantics as kubectl so existing muscle memory works
+	rootCmd.PersistentFlags().StringVar(&connOptions.Kubeconfig, "kubeconfig", "", "Path to the kubeconfig file to use (default: $KUBECONFIG or ~/.kube/config, in-cluster config when neither exists)")
+	rootCmd.PersistentFlags().StringVar(&connOptions.Context, "context", "", "The name of the kubeconfig context to use")
+	rootCmd.PersistentFlags().StringVar(&connOptions.Cluster, "cluster", "", "The name of the kubeconfig cluster to use")
+	rootCmd.PersistentFlags().StringVar(&connOptions.User, "user", "", "The name of the kubeconfig user to use")
+	rootCmd.PersistentFlags().StringVar(&connOptions.Impersonate, "as", "", "Username to impersonate for the operation")
+	rootCmd.PersistentFlags().StringSliceVar(&connOptions.ImpersonateGroups, "as-group", []string{}, "Group to impersonate for the operation, can be repeated")
+}
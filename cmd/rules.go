@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/kobot/kobot/pkg/checks"
+)
+
+var rulesCmd = &cobra.Command{
+	Use:   "rules <rules.yaml>...",
+	Short: "Run custom rules written as CEL expressions",
+	Long: `Runs the site-specific rules defined in one or more rules files. Each rule names a
+target resource, an optional label selector and a CEL expression that must hold for
+every object; objects for which it is false are reported with the rule's severity
+and message. The target is available as 'object', related resources listed in the
+same namespace as 'related.<name>':
+
+  rules:
+    - id: prod-namespace-has-pdb
+      description: Every namespace labeled tier=prod must have a PodDisruptionBudget
+      resource: v1/namespaces
+      selector: tier=prod
+      related:
+        pdbs: policy/v1/poddisruptionbudgets
+      expression: size(related.pdbs) > 0
+      severity: warning
+      messageExpression: "'namespace ' + object.metadata.name + ' has no PodDisruptionBudget'"
+    - id: helmrelease-pinned-chart
+      resource: helm.toolkit.fluxcd.io/v2/helmreleases
+      expression: has(object.spec.chart.spec.version) && !object.spec.chart.spec.version.contains('*')
+      message: HelmRelease does not pin a chart version
+
+Namespaced targets are listed in the selected namespaces (-n, --exclude-namespace,
+--namespace-selector) and Namespace targets are limited to them as well. Other
+cluster-scoped targets (e.g. nodes) are always evaluated in full.
+
+Rules can also run alongside the built-in checks with 'kobot check cluster --rules'.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		selected, err := loadRuleFiles(args)
+		if err != nil {
+			return err
+		}
+
+		cmd.SilenceUsage = true
+		return runChecks(selected)
+	},
+}
+
+// loadRuleFiles compiles the rules of every given file into checks. Rule IDs
+// name the findings and are matched by waivers, so they must be unique across files.
+func loadRuleFiles(paths []string) ([]checks.Check, error) {
+	var out []checks.Check
+	definedIn := make(map[string]string) // rule ID -> file
+	for _, path := range paths {
+		rules, err := checks.LoadRules(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range rules {
+			if other, ok := definedIn[rule.Name()]; ok {
+				return nil, fmt.Errorf("%s: rule %q is already defined in %s", path, rule.Name(), other)
+			}
+			definedIn[rule.Name()] = path
+		}
+		out = append(out, rules...)
+	}
+	return out, nil
+}
+
+func init() {
+	checkCmd.AddCommand(rulesCmd)
+	rulesCmd.Flags().StringSliceVarP(&namespace, "namespace", "n", []string{}, "Comma-separated list of namespaces, globs or /regex/ patterns to check (default: all)")
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadRuleFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, id string) string {
+		path := filepath.Join(dir, name)
+		content := "rules:\n  - id: " + id + "\n    resource: v1/pods\n    expression: 'true'\n"
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	a, b, c := write("a.yaml", "team-a"), write("b.yaml", "team-b"), write("c.yaml", "team-a")
+
+	rules, err := loadRuleFiles([]string{a, b})
+	if err != nil || len(rules) != 2 {
+		t.Fatalf("loadRuleFiles(a, b) = %d rules, %v, want 2 rules", len(rules), err)
+	}
+
+	_, err = loadRuleFiles([]string{a, b, c})
+	if err == nil || !strings.Contains(err.Error(), `rule "team-a" is already defined in `+a) {
+		t.Errorf("loadRuleFiles(a, b, c) error = %v, want duplicate team-a", err)
+	}
+}
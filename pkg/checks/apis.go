@@ -0,0 +1,122 @@
+package checks
+
+import (
+	"context"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// apiKind describes a custom resource API a check reads through the dynamic
+// client. Versions are tried in order so clusters running older releases of
+// the owning project (Flux, Gateway API, cert-manager, ...) are still covered.
+type apiKind struct {
+	Kind     string
+	Group    string
+	Resource string
+	Versions []string
+}
+
+// apiLister lists custom resources, remembering which API version of each kind the cluster serves.
+type apiLister struct {
+	dynamic dynamic.Interface
+	// versions maps group/resource to its served version; "" means the kind is not installed.
+	versions map[string]string
+}
+
+func newAPILister(dynamicClient dynamic.Interface) *apiLister {
+	return &apiLister{dynamic: dynamicClient, versions: make(map[string]string)}
+}
+
+// gvr returns the resolved GroupVersionResource for a kind, if it was found.
+func (l *apiLister) gvr(kind apiKind) (schema.GroupVersionResource, bool) {
+	v := l.versions[kind.Group+"/"+kind.Resource]
+	return schema.GroupVersionResource{Group: kind.Group, Version: v, Resource: kind.Resource}, v != ""
+}
+
+// list returns the objects of a kind in a namespace, or of a cluster-scoped kind
+// when ns is empty. Kinds the cluster does not serve return nothing.
+func (l *apiLister) list(ctx context.Context, kind apiKind, ns string) ([]unstructured.Unstructured, error) {
+	key := kind.Group + "/" + kind.Resource
+	if v, resolved := l.versions[key]; resolved {
+		if v == "" {
+			return nil, nil
+		}
+		gvr, _ := l.gvr(kind)
+		list, err := l.dynamic.Resource(gvr).Namespace(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	}
+
+	for _, v := range kind.Versions {
+		gvr := schema.GroupVersionResource{Group: kind.Group, Version: v, Resource: kind.Resource}
+		list, err := l.dynamic.Resource(gvr).Namespace(ns).List(ctx, metav1.ListOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		l.versions[key] = v
+		return list.Items, nil
+	}
+
+	l.versions[key] = ""
+	return nil, nil
+}
+
+// installed returns how many of the listed kinds the cluster serves.
+func (l *apiLister) installed() int {
+	n := 0
+	for _, v := range l.versions {
+		if v != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// customResourceRef returns the ResourceRef for an object listed through the dynamic client.
+func customResourceRef(obj unstructured.Unstructured) ResourceRef {
+	gv, _ := schema.ParseGroupVersion(obj.GetAPIVersion())
+	return ResourceRef{
+		Group:     gv.Group,
+		Version:   gv.Version,
+		Kind:      obj.GetKind(),
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+	}
+}
+
+// condition is a status condition read from an unstructured object.
+type condition struct {
+	status, reason, message string
+	// since is the lastTransitionTime, zero when it is missing or malformed.
+	since time.Time
+}
+
+// findCondition looks up a condition by type in an unstructured conditions list.
+func findCondition(conditions []interface{}, condType string) (condition, bool) {
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if t, _, _ := unstructured.NestedString(cond, "type"); t != condType {
+			continue
+		}
+		status, _, _ := unstructured.NestedString(cond, "status")
+		reason, _, _ := unstructured.NestedString(cond, "reason")
+		message, _, _ := unstructured.NestedString(cond, "message")
+		transition, _, _ := unstructured.NestedString(cond, "lastTransitionTime")
+		since, _ := time.Parse(time.RFC3339, transition)
+		return condition{status: status, reason: reason, message: message, since: since}, true
+	}
+	return condition{}, false
+}
@@ -0,0 +1,306 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gitlab.com/kobot/kobot/pkg/logging"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func init() {
+	Register(certificateCheck{})
+}
+
+// cert-manager kinds, read through the dynamic client since they are CRDs.
+var (
+	certificateKind        = apiKind{Kind: "Certificate", Group: "cert-manager.io", Resource: "certificates", Versions: []string{"v1"}}
+	certificateRequestKind = apiKind{Kind: "CertificateRequest", Group: "cert-manager.io", Resource: "certificaterequests", Versions: []string{"v1"}}
+	issuerKind             = apiKind{Kind: "Issuer", Group: "cert-manager.io", Resource: "issuers", Versions: []string{"v1"}}
+	clusterIssuerKind      = apiKind{Kind: "ClusterIssuer", Group: "cert-manager.io", Resource: "clusterissuers", Versions: []string{"v1"}}
+)
+
+// certificateNameAnnotation is set by cert-manager on the Secrets it issues.
+const certificateNameAnnotation = "cert-manager.io/certificate-name"
+
+// certificateRequestPendingGrace is how long a CertificateRequest may wait for its issuer.
+const certificateRequestPendingGrace = 10 * time.Minute
+
+// certificateCheck reports TLS Secrets holding expired, soon to expire or broken
+// certificates, and the readiness of cert-manager Certificates, CertificateRequests
+// and (Cluster)Issuers. cert-manager is optional; without it only Secrets are scanned.
+type certificateCheck struct{}
+
+func (certificateCheck) Name() string { return "certificates" }
+
+func (certificateCheck) Description() string {
+	return "TLS Secret expiry and key pairs, cert-manager Certificate, CertificateRequest and Issuer readiness"
+}
+
+func (certificateCheck) RequiredClients() []Client { return []Client{KubeClient, DynamicClient} }
+
+func (certificateCheck) Run(ctx context.Context, env *Env) (*Result, error) {
+	logging.Info("Scanning TLS certificates across %d namespace(s).", len(env.Namespaces))
+	logging.Info("Certificate expiry window set to %s.", env.CertExpiryWindow)
+	logging.Starting("Operator-initiated certificate expiry check")
+
+	result := &Result{Resource: "certificates"}
+	lister := newAPILister(env.Dynamic)
+	now := time.Now()
+
+	// --- cluster-scoped ClusterIssuers
+	scope := NamespaceResult{Name: ClusterScope}
+	issuers := make(map[string]ResourceRef) // not-ready issuers by Kind/namespace/name
+
+	clusterCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	clusterIssuers, err := lister.list(clusterCtx, clusterIssuerKind, "")
+	cancel()
+	if err != nil {
+		scope.Error = fmt.Sprintf("unable to list ClusterIssuers: %v", err)
+	}
+	scope.Checked = len(clusterIssuers)
+	for _, issuer := range clusterIssuers {
+		result.Findings = append(result.Findings, inspectIssuer(issuer, issuers)...)
+	}
+
+	// --- namespaced Secrets, Issuers, Certificates and CertificateRequests
+	var certificates, requests []unstructured.Unstructured
+	for _, ns := range env.Namespaces {
+		logging.Running("Scan job on namespace: %s", ns)
+		nsResult := NamespaceResult{Name: ns}
+		var errs []string
+
+		nsCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		secrets, err := env.Clientset.CoreV1().Secrets(ns).List(nsCtx, metav1.ListOptions{
+			FieldSelector: "type=" + string(v1.SecretTypeTLS),
+		})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("unable to list TLS Secrets: %v", err))
+		} else {
+			nsResult.Checked += len(secrets.Items)
+			for _, secret := range secrets.Items {
+				result.Findings = append(result.Findings, inspectTLSSecret(secret, env.CertExpiryWindow, now)...)
+			}
+		}
+
+		for _, kind := range []apiKind{issuerKind, certificateKind, certificateRequestKind} {
+			items, err := lister.list(nsCtx, kind, ns)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("unable to list %ss: %v", kind.Kind, err))
+				continue
+			}
+			nsResult.Checked += len(items)
+
+			switch kind.Kind {
+			case "Issuer":
+				for _, issuer := range items {
+					result.Findings = append(result.Findings, inspectIssuer(issuer, issuers)...)
+				}
+			case "Certificate":
+				certificates = append(certificates, items...)
+			case "CertificateRequest":
+				requests = append(requests, items...)
+			}
+		}
+		cancel()
+
+		nsResult.Error = strings.Join(errs, "; ")
+		result.Namespaces = append(result.Namespaces, nsResult)
+	}
+
+	// Certificates are inspected once every Issuer is known, so a broken issuer is reported as their cause
+	ready := make(map[string]bool, len(certificates))
+	for _, cert := range certificates {
+		cond, _ := findCondition(conditionsOf(cert), "Ready")
+		ready[objectKey(cert)] = cond.status == "True"
+		result.Findings = append(result.Findings, inspectCertificate(cert, issuers, now)...)
+	}
+	for _, req := range requests {
+		result.Findings = append(result.Findings, inspectCertificateRequest(req, ready, now)...)
+	}
+
+	result.Namespaces = append(result.Namespaces, scope)
+	return result, nil
+}
+
+// conditionsOf returns the status.conditions of an unstructured object.
+func conditionsOf(obj unstructured.Unstructured) []interface{} {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	return conditions
+}
+
+// inspectTLSSecret checks that a kubernetes.io/tls Secret holds a parseable certificate
+// matching its key that is neither expired nor expiring within the window.
+func inspectTLSSecret(secret v1.Secret, window time.Duration, now time.Time) []Finding {
+	b := &findingBuilder{check: "certificates", ref: ResourceRef{Version: "v1", Kind: "Secret", Namespace: secret.Namespace, Name: secret.Name}}
+
+	// Secrets issued by cert-manager are renewed by it; their Certificate is the object to fix
+	certificate := secret.Annotations[certificateNameAnnotation]
+	if certificate != "" {
+		b.owner = &ResourceRef{Group: certificateKind.Group, Version: "v1", Kind: certificateKind.Kind, Namespace: secret.Namespace, Name: certificate}
+	}
+
+	cert, err := tlsCertificate(&secret)
+	if cert == nil {
+		b.add(SeverityCritical, "TLSSecretInvalid", "Secret does not hold a valid certificate",
+			"Replace the Secret with a PEM encoded certificate and key.", err.Error())
+		return b.findings
+	}
+	switch {
+	case errors.Is(err, errTLSKeyMissing):
+		b.add(SeverityCritical, "TLSKeyMissing", "Secret has no private key",
+			"Add the PEM encoded private key the certificate was issued for as tls.key.", err.Error())
+	case err != nil:
+		b.add(SeverityCritical, "KeyPairMismatch", "Private key does not match the certificate",
+			"Replace tls.key with the key the certificate was issued for, or reissue the certificate.", err.Error())
+	}
+
+	evidence := []string{
+		"subject " + cert.Subject.String(),
+		"issuer " + cert.Issuer.String(),
+		"valid until " + cert.NotAfter.Format(time.RFC3339),
+	}
+	if len(cert.DNSNames) > 0 {
+		evidence = append(evidence, "DNS names "+strings.Join(cert.DNSNames, ", "))
+	}
+
+	renew := "Renew the certificate and update the Secret."
+	if certificate != "" {
+		renew = fmt.Sprintf("cert-manager should have renewed it; check 'kubectl describe certificate %s -n %s'.", certificate, secret.Namespace)
+	}
+
+	left := cert.NotAfter.Sub(now)
+	switch {
+	case left <= 0:
+		b.add(SeverityCritical, "CertificateExpired", fmt.Sprintf("Certificate expired %s ago", humanDays(-left)), renew, evidence...)
+	case left <= window:
+		b.add(SeverityWarning, "CertificateExpiringSoon", fmt.Sprintf("Certificate expires in %s", humanDays(left)), renew, evidence...)
+	case now.Before(cert.NotBefore):
+		b.add(SeverityWarning, "CertificateNotYetValid", fmt.Sprintf("Certificate is not valid before %s", cert.NotBefore.Format(time.RFC3339)),
+			"Check the clock of the issuing system; clients reject the certificate until then.", evidence...)
+	}
+
+	return b.findings
+}
+
+// humanDays renders a duration in days, or hours when it is shorter than two days.
+func humanDays(d time.Duration) string {
+	if d < 48*time.Hour {
+		return fmt.Sprintf("%dh", int(math.Ceil(d.Hours())))
+	}
+	return fmt.Sprintf("%d days", int(d.Hours()/24))
+}
+
+// inspectIssuer reports Issuers and ClusterIssuers that are not Ready and records them in notReady.
+func inspectIssuer(issuer unstructured.Unstructured, notReady map[string]ResourceRef) []Finding {
+	ref := customResourceRef(issuer)
+	b := &findingBuilder{check: "certificates", ref: ref}
+
+	cond, found := findCondition(conditionsOf(issuer), "Ready")
+	if found && cond.status == "True" {
+		return nil
+	}
+
+	notReady[ref.Kind+"/"+ref.Namespace+"/"+ref.Name] = ref
+	reason := cond.reason
+	if !found {
+		reason = "ReadyConditionMissing"
+	}
+	b.add(SeverityCritical, "IssuerNotReady", fmt.Sprintf("%s is not Ready (Reason: %s)", ref.Kind, reason),
+		fmt.Sprintf("Check the issuer configuration (ACME account, CA secret, Vault auth) with 'kubectl describe %s %s'.", strings.ToLower(ref.Kind), ref.Name),
+		cond.message)
+	return b.findings
+}
+
+// inspectCertificate reports Certificates that are not Ready or failing to renew.
+// Certificates of a broken issuer are attributed to it.
+func inspectCertificate(cert unstructured.Unstructured, notReadyIssuers map[string]ResourceRef, now time.Time) []Finding {
+	b := &findingBuilder{check: "certificates", ref: customResourceRef(cert)}
+	describe := fmt.Sprintf("kubectl describe certificate %s -n %s", cert.GetName(), cert.GetNamespace())
+
+	issuerKindName, _, _ := unstructured.NestedString(cert.Object, "spec", "issuerRef", "kind")
+	issuerName, _, _ := unstructured.NestedString(cert.Object, "spec", "issuerRef", "name")
+	issuerKey := "Issuer/" + cert.GetNamespace() + "/" + issuerName
+	if issuerKindName == "ClusterIssuer" {
+		issuerKey = "ClusterIssuer//" + issuerName
+	}
+
+	cond, found := findCondition(conditionsOf(cert), "Ready")
+	if !found || cond.status != "True" {
+		reason := cond.reason
+		if !found {
+			reason = "ReadyConditionMissing"
+		}
+		b.add(SeverityCritical, "CertificateNotReady", fmt.Sprintf("Certificate is not Ready (Reason: %s)", reason),
+			fmt.Sprintf("Check the latest CertificateRequest and the issuer with '%s'.", describe),
+			cond.message)
+	}
+
+	if attempts, found, _ := unstructured.NestedInt64(cert.Object, "status", "failedIssuanceAttempts"); found && attempts > 0 {
+		lastFailure, _, _ := unstructured.NestedString(cert.Object, "status", "lastFailureTime")
+		b.add(SeverityWarning, "IssuanceFailing", fmt.Sprintf("%d issuance attempt(s) failed", attempts),
+			fmt.Sprintf("cert-manager retries with backoff; fix the cause shown by '%s'.", describe),
+			"last failure "+lastFailure)
+	}
+
+	if renewal, found, _ := unstructured.NestedString(cert.Object, "status", "renewalTime"); found {
+		if at, err := time.Parse(time.RFC3339, renewal); err == nil && now.Sub(at) > time.Hour {
+			b.add(SeverityWarning, "RenewalOverdue", fmt.Sprintf("Renewal was due %s ago", humanDays(now.Sub(at))),
+				"Check that the cert-manager controller is running and can reach the issuer.",
+				"renewalTime "+renewal)
+		}
+	}
+
+	if issuer, ok := notReadyIssuers[issuerKey]; ok {
+		for i := range b.findings {
+			b.findings[i].CausedBy = []ResourceRef{issuer}
+			b.findings[i].Evidence = append(b.findings[i].Evidence, fmt.Sprintf("issuer %s is not Ready", issuer.String()))
+		}
+	}
+	return b.findings
+}
+
+// inspectCertificateRequest reports denied, failed and long pending CertificateRequests.
+// Old requests of a Certificate that is Ready again are history and skipped.
+func inspectCertificateRequest(req unstructured.Unstructured, readyCertificates map[string]bool, now time.Time) []Finding {
+	b := &findingBuilder{check: "certificates", ref: customResourceRef(req)}
+
+	for _, o := range req.GetOwnerReferences() {
+		if o.Kind != certificateKind.Kind {
+			continue
+		}
+		if readyCertificates[req.GetNamespace()+"/"+o.Name] {
+			return nil
+		}
+		b.owner = &ResourceRef{Group: certificateKind.Group, Version: "v1", Kind: o.Kind, Namespace: req.GetNamespace(), Name: o.Name}
+	}
+	describe := fmt.Sprintf("kubectl describe certificaterequest %s -n %s", req.GetName(), req.GetNamespace())
+	conditions := conditionsOf(req)
+
+	if cond, ok := findCondition(conditions, "Denied"); ok && cond.status == "True" {
+		b.add(SeverityCritical, "CertificateRequestDenied", fmt.Sprintf("CertificateRequest was denied (%s)", cond.reason),
+			"Check the approver policy that denied the request.", cond.message)
+		return b.findings
+	}
+
+	cond, found := findCondition(conditions, "Ready")
+	switch {
+	case found && cond.status == "True":
+		// issued; the Certificate itself is inspected separately
+	case cond.reason == "Failed":
+		b.add(SeverityCritical, "CertificateRequestFailed", "CertificateRequest failed",
+			fmt.Sprintf("Fix the issuer error shown by '%s'.", describe), cond.message)
+	case now.Sub(req.GetCreationTimestamp().Time) > certificateRequestPendingGrace:
+		b.add(SeverityWarning, "CertificateRequestPending",
+			fmt.Sprintf("CertificateRequest has been pending for %s", now.Sub(req.GetCreationTimestamp().Time).Round(time.Minute)),
+			fmt.Sprintf("Check that the request is approved and the issuer is reachable with '%s'.", describe),
+			conditionEvidence(cond.reason, cond.message))
+	}
+	return b.findings
+}
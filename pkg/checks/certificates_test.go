@@ -0,0 +1,93 @@
+package checks
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTLSCertificate(t *testing.T) {
+	now := time.Now()
+	certPEM, keyPEM := testKeyPair(t, now.AddDate(0, 3, 0), "shop.example.com")
+	_, otherKey := testKeyPair(t, now.AddDate(0, 3, 0), "shop.example.com")
+
+	tests := []struct {
+		name     string
+		cert     []byte
+		key      []byte
+		wantCert bool
+		wantErr  error
+	}{
+		{name: "matching pair", cert: certPEM, key: keyPEM, wantCert: true},
+		{name: "missing key", cert: certPEM, wantCert: true, wantErr: errTLSKeyMissing},
+		{name: "other key", cert: certPEM, key: otherKey, wantCert: true, wantErr: errTLSKeyMismatch},
+		{name: "missing certificate", key: keyPEM},
+		{name: "not PEM", cert: []byte("not a certificate"), key: keyPEM},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert, err := tlsCertificate(testTLSSecret("shop", "web-tls", tt.cert, tt.key))
+			if (cert != nil) != tt.wantCert {
+				t.Errorf("tlsCertificate() certificate = %v, want one: %v", cert, tt.wantCert)
+			}
+			switch {
+			case !tt.wantCert:
+				if err == nil {
+					t.Error("tlsCertificate() without a certificate returned no error")
+				}
+			case tt.wantErr == nil && err != nil, tt.wantErr != nil && !errors.Is(err, tt.wantErr):
+				t.Errorf("tlsCertificate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestInspectTLSSecret(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	window := 30 * 24 * time.Hour
+	pair := func(notAfter time.Time) ([]byte, []byte) { return testKeyPair(t, notAfter, "shop.example.com") }
+
+	valid, validKey := pair(now.AddDate(0, 6, 0))
+	_, otherKey := pair(now.AddDate(0, 6, 0))
+	expired, expiredKey := pair(now.AddDate(0, 0, -3))
+	expiring, expiringKey := pair(now.AddDate(0, 0, 10))
+	future, futureKey := pair(now.AddDate(2, 0, 0)) // valid from a year from now
+
+	tests := []struct {
+		name string
+		cert []byte
+		key  []byte
+		want []string
+	}{
+		{name: "valid", cert: valid, key: validKey},
+		{name: "expired", cert: expired, key: expiredKey, want: []string{"critical/CertificateExpired"}},
+		{name: "expiring within the window", cert: expiring, key: expiringKey, want: []string{"warning/CertificateExpiringSoon"}},
+		{name: "not yet valid", cert: future, key: futureKey, want: []string{"warning/CertificateNotYetValid"}},
+		{name: "missing tls.key", cert: valid, want: []string{"critical/TLSKeyMissing"}},
+		{name: "mismatched tls.key", cert: valid, key: otherKey, want: []string{"critical/KeyPairMismatch"}},
+		{name: "expired without a key", cert: expired, want: []string{"critical/TLSKeyMissing", "critical/CertificateExpired"}},
+		{name: "missing tls.crt", key: validKey, want: []string{"critical/TLSSecretInvalid"}},
+		{name: "garbage tls.crt", cert: []byte("-----BEGIN CERTIFICATE-----\nbm90IGEgY2VydA==\n-----END CERTIFICATE-----\n"), key: validKey, want: []string{"critical/TLSSecretInvalid"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := testTLSSecret("shop", "web-tls", tt.cert, tt.key)
+			if got := findingReasons(inspectTLSSecret(*secret, window, now)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("inspectTLSSecret() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInspectTLSSecretOwner(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	cert, key := testKeyPair(t, now.AddDate(0, 0, -1), "shop.example.com")
+	secret := testTLSSecret("shop", "web-tls", cert, key)
+	secret.Annotations = map[string]string{certificateNameAnnotation: "web"}
+
+	findings := inspectTLSSecret(*secret, 0, now)
+	if len(findings) != 1 || findings[0].Owner == nil || findings[0].Owner.Kind != "Certificate" || findings[0].Owner.Name != "web" {
+		t.Errorf("inspectTLSSecret() = %+v, want the expiry attributed to Certificate web", findings)
+	}
+}
@@ -0,0 +1,56 @@
+package checks
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// Key errors tlsCertificate returns along with the parsed certificate.
+var (
+	errTLSKeyMissing  = errors.New("secret has no " + v1.TLSPrivateKeyKey)
+	errTLSKeyMismatch = errors.New(v1.TLSPrivateKeyKey + " does not match the certificate")
+)
+
+// tlsCertificate parses the certificate chain of a TLS Secret and verifies that
+// tls.key matches it. It returns the leaf certificate.
+func tlsCertificate(secret *v1.Secret) (*x509.Certificate, error) {
+	certPEM, key := secret.Data[v1.TLSCertKey], secret.Data[v1.TLSPrivateKeyKey]
+	if len(certPEM) == 0 {
+		return nil, fmt.Errorf("secret has no %s", v1.TLSCertKey)
+	}
+
+	var leaf *x509.Certificate
+	for rest := certPEM; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s does not hold a valid certificate: %w", v1.TLSCertKey, err)
+		}
+		if leaf == nil {
+			leaf = cert
+		}
+	}
+	if leaf == nil {
+		return nil, fmt.Errorf("%s holds no PEM encoded certificate", v1.TLSCertKey)
+	}
+
+	if len(key) == 0 {
+		return leaf, errTLSKeyMissing
+	}
+	if _, err := tls.X509KeyPair(certPEM, key); err != nil {
+		return leaf, fmt.Errorf("%w: %w", errTLSKeyMismatch, err)
+	}
+	return leaf, nil
+}
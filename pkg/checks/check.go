@@ -0,0 +1,299 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Client identifies a Kubernetes client a check needs in order to run.
+type Client string
+
+const (
+	// KubeClient is the typed clientset used for core resources (pods, nodes, apps/v1, etc).
+	KubeClient Client = "kubernetes"
+	// DynamicClient is used for custom resources like HelmReleases and Kustomizations.
+	DynamicClient Client = "dynamic"
+)
+
+// Check is a single health check kobot can run against a cluster.
+// New checks register themselves with Register from an init function,
+// so adding one never requires touching the cobra commands.
+type Check interface {
+	// Name is the stable identifier of the check (e.g. "pods").
+	Name() string
+	// Description is a one line summary shown to operators.
+	Description() string
+	// RequiredClients lists the clients the check expects to find in the Env.
+	RequiredClients() []Client
+	// Run executes the check and returns what it found.
+	Run(ctx context.Context, env *Env) (*Result, error)
+}
+
+// ClusterScopedCheck is optionally implemented by checks that only look at
+// cluster-scoped objects (e.g. nodes) and therefore ignore Env.Namespaces.
+type ClusterScopedCheck interface {
+	ClusterScoped() bool
+}
+
+// NeedsNamespaces reports whether any of the checks scans namespaced objects,
+// i.e. whether the namespace list has to be resolved before running them.
+func NeedsNamespaces(list []Check) bool {
+	for _, c := range list {
+		if scoped, ok := c.(ClusterScopedCheck); !ok || !scoped.ClusterScoped() {
+			return true
+		}
+	}
+	return false
+}
+
+// Env is everything a check receives when it runs: the cluster clients
+// and the operator-selected settings for this scan.
+type Env struct {
+	Clientset kubernetes.Interface
+	Dynamic   dynamic.Interface
+
+	// Namespaces is the resolved list of namespaces to scan. It is never empty
+	// when a check runs; see ResolveNamespaces.
+	Namespaces []string
+	// LabelSelector limits the pods and HelmReleases the pod, deep pod and
+	// HelmRelease checks look at (-l, --selector).
+	LabelSelector string
+	// FieldSelector limits the pods of the pod and deep pod checks (--field-selector).
+	// It is never sent to HelmReleases: custom resources only support metadata fields.
+	FieldSelector string
+	// Deep enables the deeper (container and condition level) pod analysis.
+	Deep bool
+	// FluxGrace is how long to wait for Flux-managed resources to become Ready.
+	FluxGrace time.Duration
+	// CertExpiryWindow is how far ahead certificates are reported as expiring soon.
+	CertExpiryWindow time.Duration
+	// Events attaches recent Warning events of failing objects to their findings.
+	Events bool
+	// Logs configures the log excerpts of crashed containers in the deep pod scan.
+	Logs LogOptions
+	// Concurrency is how many checks RunChecks runs at the same time; below 1 means one.
+	Concurrency int
+}
+
+// podListOptions returns the ListOptions carrying the operator's pod selectors.
+func (e *Env) podListOptions() metav1.ListOptions {
+	return metav1.ListOptions{LabelSelector: e.LabelSelector, FieldSelector: e.FieldSelector}
+}
+
+// has reports whether the Env carries the given client.
+func (e *Env) has(c Client) bool {
+	switch c {
+	case KubeClient:
+		return e.Clientset != nil
+	case DynamicClient:
+		return e.Dynamic != nil
+	}
+	return false
+}
+
+// ClusterScope is the NamespaceResult name used by checks of cluster-scoped
+// objects such as nodes, whose findings carry no namespace either.
+const ClusterScope = ""
+
+// NamespaceResult summarizes what a check looked at in one namespace.
+type NamespaceResult struct {
+	Name    string
+	Checked int
+	// Error is set when the namespace could not be (fully) scanned.
+	Error string
+}
+
+// Result is the outcome of a single check run.
+type Result struct {
+	Check       string
+	Description string
+	// Resource is the plural noun of the objects the check counts (e.g. "pods").
+	Resource   string
+	Namespaces []NamespaceResult
+	Findings   []Finding
+	// Waived are the findings accepted by a waiver; they never fail the run.
+	Waived []Finding
+	// Error is set when the check could not run at all.
+	Error string
+	// Skipped is set when the check does not apply to the cluster, e.g. because
+	// the component it inspects is not installed. It is not a scan error.
+	Skipped string
+}
+
+// skipped returns the Result of a check that does not apply to the cluster.
+func skipped(resource, reason string) *Result {
+	return &Result{Resource: resource, Skipped: reason}
+}
+
+// Failed returns the number of distinct objects in a namespace with at least
+// one warning or critical finding. Informational findings never fail an object.
+func (r *Result) Failed(namespace string) int {
+	seen := make(map[string]bool)
+	for _, f := range r.Findings {
+		if f.Resource.Namespace == namespace && f.Severity.AtLeast(SeverityWarning) {
+			seen[f.Resource.key()] = true
+		}
+	}
+	return len(seen)
+}
+
+// MaxSeverity returns the highest severity among the findings of a namespace,
+// or an empty Severity when there are none.
+func (r *Result) MaxSeverity(namespace string) Severity {
+	return maxSeverity(namespaceFindings(r, namespace))
+}
+
+// Checked returns the total number of objects the check looked at.
+func (r *Result) Checked() int {
+	total := 0
+	for _, ns := range r.Namespaces {
+		total += ns.Checked
+	}
+	return total
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   []Check
+)
+
+// Register adds a check to the registry. It panics if a check with the same
+// name was already registered, since that is always a programming error.
+func Register(c Check) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for _, existing := range registry {
+		if existing.Name() == c.Name() {
+			panic(fmt.Sprintf("checks: check %q registered twice", c.Name()))
+		}
+	}
+	registry = append(registry, c)
+}
+
+// Registered returns every registered check in registration order.
+func Registered() []Check {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	out := make([]Check, len(registry))
+	copy(out, registry)
+	return out
+}
+
+// Lookup returns the registered check with the given name.
+func Lookup(name string) (Check, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	for _, c := range registry {
+		if c.Name() == name {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// RequiredClients returns the union of clients needed by the given checks.
+func RequiredClients(list []Check) map[Client]bool {
+	need := make(map[Client]bool)
+	for _, c := range list {
+		for _, client := range c.RequiredClients() {
+			need[client] = true
+		}
+	}
+	return need
+}
+
+// RunChecks runs the checks, up to env.Concurrency at a time, and collects their
+// results in the order the checks were given. A check that fails outright still
+// produces a Result carrying the error, so one broken check never hides the
+// output of the others.
+func RunChecks(ctx context.Context, env *Env, list []Check) []*Result {
+	results := make([]*Result, len(list))
+
+	workers := env.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+
+	for i, c := range list {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = runCheck(ctx, env, c)
+		}()
+	}
+
+	wg.Wait()
+	return results
+}
+
+// runCheck runs a single check and attaches the events of its findings.
+func runCheck(ctx context.Context, env *Env, c Check) *Result {
+	result := &Result{Check: c.Name(), Description: c.Description()}
+
+	missing := ""
+	for _, client := range c.RequiredClients() {
+		if !env.has(client) {
+			missing = string(client)
+			break
+		}
+	}
+
+	if missing != "" {
+		result.Error = fmt.Sprintf("required %s client is not available", missing)
+	} else if r, err := c.Run(ctx, env); err != nil {
+		result.Error = err.Error()
+	} else if r != nil {
+		r.Check = c.Name()
+		r.Description = c.Description()
+		result = r
+	}
+
+	if env.Events && env.Clientset != nil {
+		correlateEvents(ctx, env, result.Findings)
+	}
+	return result
+}
+
+// ScanErrors counts the checks that could not run and the namespaces that
+// could not be scanned. Anything above zero means the results are partial.
+// Skipped checks never count: a component that is not installed is not a failure.
+func ScanErrors(results []*Result) int {
+	errs := 0
+	for _, r := range results {
+		if r.Error != "" {
+			errs++
+		}
+		for _, ns := range r.Namespaces {
+			if ns.Error != "" {
+				errs++
+			}
+		}
+	}
+	return errs
+}
+
+// AtOrAbove returns the findings whose severity reaches the given threshold.
+func AtOrAbove(results []*Result, threshold Severity) []Finding {
+	var out []Finding
+	for _, r := range results {
+		for _, f := range r.Findings {
+			if f.Severity.AtLeast(threshold) {
+				out = append(out, f)
+			}
+		}
+	}
+	return out
+}
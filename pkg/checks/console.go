@@ -0,0 +1,317 @@
+package checks
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"gitlab.com/kobot/kobot/pkg/logging"
+)
+
+// PrintReport writes the human-friendly console report for a set of check results.
+func PrintReport(results []*Result) {
+	for _, r := range results {
+		printResult(r)
+	}
+	printSummary(results)
+}
+
+func printResult(r *Result) {
+	fmt.Println()
+	fmt.Println(strings.Repeat("=", 55))
+	logging.Title("            Kobot %s Health Report\n", r.Check)
+	fmt.Println(strings.Repeat("=", 55))
+	fmt.Println()
+
+	if r.Error != "" {
+		fmt.Printf("%s %s: %s\n", color.RedString("ERROR:"), r.Check, r.Error)
+		return
+	}
+	if r.Skipped != "" {
+		fmt.Printf("   %s %s\n", color.HiBlackString("SKIPPED:"), r.Skipped)
+		return
+	}
+
+	for _, ns := range r.Namespaces {
+		if ns.Error != "" {
+			fmt.Printf("   %s %s (%s)\n", color.RedString("ERROR:"), scopeLabel(ns.Name), ns.Error)
+			continue
+		}
+
+		// nothing to report for namespaces without any of the checked objects
+		if ns.Checked == 0 && r.Failed(ns.Name) == 0 {
+			continue
+		}
+
+		failed := r.Failed(ns.Name)
+		switch r.MaxSeverity(ns.Name) {
+		case SeverityCritical:
+			fmt.Printf("   %s %s (%d of %d %s unhealthy)\n", color.RedString("FAIL:"), scopeLabel(ns.Name), failed, ns.Checked, r.Resource)
+		case SeverityWarning:
+			fmt.Printf("   %s %s (%d of %d %s need attention)\n", color.YellowString("WARN:"), scopeLabel(ns.Name), failed, ns.Checked, r.Resource)
+		default:
+			fmt.Printf("   %s %s (%d %s healthy)\n", color.GreenString("PASS:"), scopeLabel(ns.Name), ns.Checked, r.Resource)
+		}
+		printFindingTree(namespaceFindings(r, ns.Name))
+	}
+	printWaived(r.Waived)
+}
+
+// printWaived lists the findings accepted by a waiver apart from the failing ones.
+func printWaived(findings []Finding) {
+	if len(findings) == 0 {
+		return
+	}
+	fmt.Printf("   %s %d finding(s) accepted by the waiver file\n", color.HiBlackString("WAIVED:"), len(findings))
+	for _, f := range findings {
+		until := ""
+		if f.Waiver.Expires != "" {
+			until = " until " + f.Waiver.Expires
+		}
+		fmt.Printf("        - %s %s %s\n", qualifiedRef(f.Resource), f.Reason,
+			color.HiBlackString("(waived%s: %s)", until, f.Waiver.Reason))
+	}
+}
+
+// scopeLabel is how a NamespaceResult name is shown to operators.
+func scopeLabel(namespace string) string {
+	if namespace == ClusterScope {
+		return "(cluster-scoped)"
+	}
+	return namespace
+}
+
+// namespaceFindings returns the findings of a result that belong to one namespace.
+func namespaceFindings(r *Result, namespace string) []Finding {
+	var out []Finding
+	for _, f := range r.Findings {
+		if f.Resource.Namespace == namespace {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// maxListedMembers caps how many members of a group are listed by name.
+const maxListedMembers = 5
+
+// printFindingTree prints findings as a small tree, grouping the objects of one
+// workload (e.g. the pods of a Deployment) under it.
+func printFindingTree(findings []Finding) {
+	groups := groupFindings(findings)
+
+	for i, g := range groups {
+		prefix, indent := "└──", "    "
+		if i < len(groups)-1 {
+			prefix, indent = "├──", "│   "
+		}
+
+		switch {
+		case !g.grouped():
+			// symptoms of a failure elsewhere collapse into one line pointing at the root cause
+			if causes := causedBy(g.Findings); causes != nil {
+				fmt.Printf("        %s %s %s\n", prefix, color.YellowString(g.Owner.String()),
+					color.HiBlackString("(blocked by %s)", refList(causes)))
+				continue
+			}
+			fmt.Printf("        %s %s\n", prefix, color.YellowString(g.Owner.String()))
+			for _, f := range g.Findings {
+				printFinding(indent, f)
+			}
+		case len(g.Members) == 1:
+			fmt.Printf("        %s %s %s\n", prefix, color.YellowString(g.Owner.String()), color.HiBlackString("→ "+g.Members[0].String()))
+			for _, f := range g.Findings {
+				printFinding(indent, f)
+			}
+		default:
+			fmt.Printf("        %s %s %s\n", prefix, color.YellowString(g.Owner.String()),
+				color.HiBlackString("(%d %s: %s)", len(g.Members), g.memberNoun(), memberList(g.Members)))
+			printFinding(indent, g.Representative)
+
+			var also []string
+			for _, reason := range g.otherReasons() {
+				also = append(also, fmt.Sprintf("%s (%d)", reason, g.Reasons[reason]))
+			}
+			fmt.Printf("        %s      %s\n", indent, color.HiBlackString("seen on %d of %d %s, e.g. %s",
+				g.Reasons[g.Representative.Reason], len(g.Members), g.memberNoun(), g.Representative.Resource.String()))
+			if len(also) > 0 {
+				fmt.Printf("        %s      %s %s\n", indent, color.HiBlackString("also"), strings.Join(also, ", "))
+			}
+		}
+	}
+}
+
+// printFinding prints one finding with its evidence, events, logs and suggested action.
+func printFinding(indent string, f Finding) {
+	fmt.Printf("        %s  ↳ %s %s\n", indent, severityTag(f.Severity), f.Message)
+	for _, e := range f.Evidence {
+		fmt.Printf("        %s      %s\n", indent, color.HiBlackString(e))
+	}
+	for _, e := range f.Events {
+		fmt.Printf("        %s      %s %s\n", indent, color.MagentaString("event"), color.HiBlackString(e.String()))
+	}
+	if f.Logs != nil {
+		printLogExcerpt(indent, f.Logs)
+	}
+	if len(f.Dependents) > 0 {
+		fmt.Printf("        %s      %s %s\n", indent, color.RedString("blocks"), refList(f.Dependents))
+	}
+	if f.Action != "" {
+		fmt.Printf("        %s      %s %s\n", indent, color.CyanString("→"), f.Action)
+	}
+}
+
+// memberList names the first members of a group and counts the rest. Kinds are
+// only spelled out when the members are of different kinds.
+func memberList(members []ResourceRef) string {
+	mixed := false
+	for _, m := range members {
+		mixed = mixed || m.Kind != members[0].Kind
+	}
+
+	var names []string
+	for i, m := range members {
+		if i == maxListedMembers {
+			names = append(names, fmt.Sprintf("+%d more", len(members)-maxListedMembers))
+			break
+		}
+		if mixed {
+			names = append(names, m.String())
+		} else {
+			names = append(names, m.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// printLogExcerpt prints a container log tail, highlighting the lines with fatal patterns.
+func printLogExcerpt(indent string, e *LogExcerpt) {
+	fmt.Printf("        %s      %s %s\n", indent, color.MagentaString("logs"), color.HiBlackString(logExcerptTitle(e)))
+	for _, l := range e.Lines {
+		text := color.HiBlackString(l.Text)
+		if l.Match != "" {
+			text = color.RedString(l.Text)
+		}
+		fmt.Printf("        %s        │ %s\n", indent, text)
+	}
+}
+
+// logExcerptTitle describes where a log excerpt comes from.
+func logExcerptTitle(e *LogExcerpt) string {
+	title := fmt.Sprintf("container %s", e.Container)
+	if e.Previous {
+		title += " (previous instance)"
+	}
+	if e.Truncated {
+		title += ", truncated"
+	}
+	if matches := e.Matches(); len(matches) > 0 {
+		title += ", shows: " + strings.Join(matches, ", ")
+	}
+	return title
+}
+
+// causedBy returns the root causes shared by all findings of one object, or nil
+// when any of them is a problem of the object itself.
+func causedBy(findings []Finding) []ResourceRef {
+	for _, f := range findings {
+		if len(f.CausedBy) == 0 {
+			return nil
+		}
+	}
+	return findings[0].CausedBy
+}
+
+// refList renders references as a comma separated list of Kind/namespace/name.
+func refList(refs []ResourceRef) string {
+	names := make([]string, 0, len(refs))
+	for _, r := range refs {
+		names = append(names, qualifiedRef(r))
+	}
+	return strings.Join(names, ", ")
+}
+
+// qualifiedRef renders a reference as Kind/namespace/name, or Kind/name when cluster-scoped.
+func qualifiedRef(r ResourceRef) string {
+	if r.Namespace == "" {
+		return r.String()
+	}
+	return r.Kind + "/" + r.Namespace + "/" + r.Name
+}
+
+// severityTag renders a short colored label for a severity.
+func severityTag(s Severity) string {
+	switch s {
+	case SeverityCritical:
+		return color.RedString("[critical]")
+	case SeverityWarning:
+		return color.YellowString("[warning]")
+	default:
+		return color.CyanString("[info]")
+	}
+}
+
+func printSummary(results []*Result) {
+	var failedChecks, failedNamespaces int
+	scanErrors := ScanErrors(results)
+	failingMap := make(map[string]int) // ns -> unhealthy object count
+
+	fmt.Println()
+	fmt.Println(strings.Repeat("=", 55))
+	logging.Title("            Kobot Cluster Health Summary\n")
+	fmt.Println(strings.Repeat("=", 55))
+	fmt.Println()
+
+	for _, r := range results {
+		if r.Error != "" {
+			fmt.Printf("%-14s %s\n", r.Check+":", color.RedString("not run (%s)", r.Error))
+			continue
+		}
+		if r.Skipped != "" {
+			fmt.Printf("%-14s %s\n", r.Check+":", color.HiBlackString("skipped (%s)", r.Skipped))
+			continue
+		}
+		counts := CountBySeverity(r.Findings)
+		waived := ""
+		if len(r.Waived) > 0 {
+			waived = fmt.Sprintf(", %d waived", len(r.Waived))
+		}
+		fmt.Printf("%-14s %d %s checked, %d critical, %d warning, %d info%s\n", r.Check+":", r.Checked(), r.Resource,
+			counts[SeverityCritical], counts[SeverityWarning], counts[SeverityInfo], waived)
+
+		if counts[SeverityCritical]+counts[SeverityWarning] > 0 {
+			failedChecks++
+		}
+		for _, ns := range r.Namespaces {
+			if failed := r.Failed(ns.Name); failed > 0 {
+				if _, seen := failingMap[ns.Name]; !seen {
+					failedNamespaces++
+				}
+				failingMap[ns.Name] += failed
+			}
+		}
+	}
+	fmt.Println()
+
+	if scanErrors > 0 {
+		logging.Warn("%d scan error(s) occurred — the results below are incomplete.\n", scanErrors)
+	}
+
+	if failedChecks > 0 {
+		logging.Error("%d namespace(s) failed the health check.\n", failedNamespaces)
+		fmt.Println("Failing namespaces:")
+		var names []string
+		for ns := range failingMap {
+			names = append(names, ns)
+		}
+		sort.Strings(names)
+		for _, ns := range names {
+			fmt.Printf("  - %s (%d unhealthy objects)\n", scopeLabel(ns), failingMap[ns])
+		}
+		fmt.Println()
+		logging.Action("Operators should investigate the failing namespaces and rerun kobot once reconciled.\n")
+	} else if scanErrors == 0 {
+		logging.Success("All checks passed. The cluster reported healthy.\n")
+	}
+}
@@ -0,0 +1,212 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// helmReleaseDependencies returns the namespace/name keys a HelmRelease lists in spec.dependsOn.
+// A dependency without a namespace lives in the release's own namespace.
+func helmReleaseDependencies(hr unstructured.Unstructured) []string {
+	deps, _, _ := unstructured.NestedSlice(hr.Object, "spec", "dependsOn")
+
+	var keys []string
+	for _, d := range deps {
+		dep, ok := d.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(dep, "name")
+		ns, _, _ := unstructured.NestedString(dep, "namespace")
+		if name == "" {
+			continue
+		}
+		if ns == "" {
+			ns = hr.GetNamespace()
+		}
+		keys = append(keys, ns+"/"+name)
+	}
+	return keys
+}
+
+// dependencyNotReadyReason is the Ready reason of a release Flux holds back
+// because one of its dependsOn releases is not Ready.
+const dependencyNotReadyReason = "DependencyNotReady"
+
+// dependencyGraph is the spec.dependsOn graph of the scanned HelmReleases.
+type dependencyGraph struct {
+	releases map[string]unstructured.Unstructured // namespace/name -> release
+	// failing marks releases whose Ready condition is not True. Like Flux's own
+	// dependsOn gate it ignores spec.suspend: a suspended but Ready release does not block.
+	failing map[string]bool
+	// blocked marks failing releases that only wait for a dependency (DependencyNotReady).
+	blocked map[string]bool
+	// fetch looks up a dependency that was not part of the scan (e.g. another namespace).
+	fetch func(key string) (unstructured.Unstructured, bool)
+	// missing marks keys fetch could not find or read, so each is only fetched once.
+	missing map[string]bool
+}
+
+func newDependencyGraph(releases []unstructured.Unstructured, fetch func(key string) (unstructured.Unstructured, bool)) *dependencyGraph {
+	g := &dependencyGraph{
+		releases: make(map[string]unstructured.Unstructured),
+		failing:  make(map[string]bool),
+		blocked:  make(map[string]bool),
+		fetch:    fetch,
+		missing:  make(map[string]bool),
+	}
+	for _, hr := range releases {
+		g.add(hr)
+	}
+	return g
+}
+
+func (g *dependencyGraph) add(hr unstructured.Unstructured) {
+	key := objectKey(hr)
+	g.releases[key] = hr
+
+	conditions, _, _ := unstructured.NestedSlice(hr.Object, "status", "conditions")
+	ready, found := findCondition(conditions, "Ready")
+	g.failing[key] = !found || ready.status != "True"
+	g.blocked[key] = g.failing[key] && ready.reason == dependencyNotReadyReason
+}
+
+// lookup returns a release by key, fetching it from the API when it was not scanned.
+// Both found and missing releases are remembered, so every key is fetched at most once.
+func (g *dependencyGraph) lookup(key string) (unstructured.Unstructured, bool) {
+	if hr, ok := g.releases[key]; ok {
+		return hr, true
+	}
+	if g.fetch == nil || g.missing[key] {
+		return unstructured.Unstructured{}, false
+	}
+	hr, ok := g.fetch(key)
+	if !ok {
+		g.missing[key] = true
+		return hr, false
+	}
+	g.add(hr)
+	return hr, true
+}
+
+// failingDependencies returns the dependencies of key that exist and are not Ready.
+func (g *dependencyGraph) failingDependencies(key string) []string {
+	hr, ok := g.lookup(key)
+	if !ok {
+		return nil
+	}
+	var out []string
+	for _, dep := range helmReleaseDependencies(hr) {
+		if _, ok := g.lookup(dep); ok && g.failing[dep] {
+			out = append(out, dep)
+		}
+	}
+	return out
+}
+
+// rootCauses returns the failing releases at the bottom of the dependency chain of
+// key: the failing dependencies that fail for a reason of their own, reached through
+// releases that are only blocked by their dependencies. A release whose chain only
+// loops back (a dependsOn cycle) is its own root.
+func (g *dependencyGraph) rootCauses(key string) []string {
+	var roots []string
+	visited := map[string]bool{key: true}
+	queue := g.failingDependencies(key)
+
+	for len(queue) > 0 {
+		dep := queue[0]
+		queue = queue[1:]
+		if visited[dep] {
+			continue
+		}
+		visited[dep] = true
+
+		var next []string
+		if g.blocked[dep] {
+			next = g.failingDependencies(dep)
+		}
+		if len(next) == 0 {
+			roots = append(roots, dep)
+		}
+		queue = append(queue, next...)
+	}
+
+	if len(roots) == 0 {
+		return []string{key}
+	}
+	sort.Strings(roots)
+	return roots
+}
+
+// attributeRootCauses links HelmRelease findings along the dependsOn graph: findings
+// of releases Flux holds back with DependencyNotReady get CausedBy set, and each root
+// failing release lists the releases it blocks in Dependents. Findings with any other
+// reason (suspended, install failures) are problems of the release itself and stay as they are.
+func attributeRootCauses(findings []Finding, g *dependencyGraph) {
+	index := make(map[string]int) // namespace/name -> index of the release's finding
+	for i, f := range findings {
+		index[f.Resource.Namespace+"/"+f.Resource.Name] = i
+	}
+
+	dependents := make(map[string][]ResourceRef)
+	for i, f := range findings {
+		if f.Reason != dependencyNotReadyReason {
+			continue
+		}
+		key := f.Resource.Namespace + "/" + f.Resource.Name
+		roots := g.rootCauses(key)
+		if len(roots) == 1 && roots[0] == key {
+			continue
+		}
+
+		for _, root := range roots {
+			ref := helmReleaseRef(root)
+			findings[i].CausedBy = append(findings[i].CausedBy, ref)
+			dependents[root] = append(dependents[root], f.Resource)
+		}
+		findings[i].Evidence = append(findings[i].Evidence, fmt.Sprintf("dependsOn chain blocked by %s", strings.Join(roots, ", ")))
+	}
+
+	for root, deps := range dependents {
+		i, ok := index[root]
+		if !ok {
+			continue
+		}
+		findings[i].Dependents = deps
+	}
+}
+
+// helmReleaseRef returns the ResourceRef for a namespace/name HelmRelease key.
+func helmReleaseRef(key string) ResourceRef {
+	ns, name, _ := strings.Cut(key, "/")
+	return ResourceRef{
+		Group:     helmReleaseGVR.Group,
+		Version:   helmReleaseGVR.Version,
+		Kind:      "HelmRelease",
+		Namespace: ns,
+		Name:      name,
+	}
+}
+
+// helmReleaseFetcher returns a lookup function for dependencies outside the scanned namespaces.
+func helmReleaseFetcher(ctx context.Context, dynamicClient dynamic.Interface) func(key string) (unstructured.Unstructured, bool) {
+	return func(key string) (unstructured.Unstructured, bool) {
+		ns, name, _ := strings.Cut(key, "/")
+		getCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+
+		// a dependency that cannot be read is treated like a missing one
+		hr, err := dynamicClient.Resource(helmReleaseGVR).Namespace(ns).Get(getCtx, name, metav1.GetOptions{})
+		if err != nil {
+			return unstructured.Unstructured{}, false
+		}
+		return *hr, true
+	}
+}
@@ -0,0 +1,197 @@
+package checks
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// testHelmRelease builds a HelmRelease with a Ready condition and dependsOn entries
+// given as "name" or "namespace/name".
+func testHelmRelease(key, readyStatus, readyReason string, suspended bool, dependsOn ...string) unstructured.Unstructured {
+	ref := helmReleaseRef(key)
+	var deps []interface{}
+	for _, d := range dependsOn {
+		dep := map[string]interface{}{"name": d}
+		if r := helmReleaseRef(d); r.Name != "" {
+			dep = map[string]interface{}{"namespace": r.Namespace, "name": r.Name}
+		}
+		deps = append(deps, dep)
+	}
+	obj := map[string]interface{}{
+		"apiVersion": "helm.toolkit.fluxcd.io/v2",
+		"kind":       "HelmRelease",
+		"metadata":   map[string]interface{}{"namespace": ref.Namespace, "name": ref.Name},
+		"spec":       map[string]interface{}{"suspend": suspended, "dependsOn": deps},
+		"status": map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": readyStatus, "reason": readyReason},
+			},
+		},
+	}
+	return unstructured.Unstructured{Object: obj}
+}
+
+func TestHelmReleaseDependencies(t *testing.T) {
+	hr := testHelmRelease("apps/web", "True", "", false, "db", "infra/ingress")
+	got := helmReleaseDependencies(hr)
+	want := []string{"apps/db", "infra/ingress"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("helmReleaseDependencies() = %v, want %v", got, want)
+	}
+}
+
+func TestDependencyGraphRootCauses(t *testing.T) {
+	tests := []struct {
+		name     string
+		releases []unstructured.Unstructured
+		fetched  []unstructured.Unstructured
+		key      string
+		want     []string
+	}{
+		{
+			name: "direct failing dependency",
+			releases: []unstructured.Unstructured{
+				testHelmRelease("ns/app", "False", dependencyNotReadyReason, false, "db"),
+				testHelmRelease("ns/db", "False", "InstallFailed", false),
+			},
+			key:  "ns/app",
+			want: []string{"ns/db"},
+		},
+		{
+			name: "chain through a blocked release",
+			releases: []unstructured.Unstructured{
+				testHelmRelease("ns/app", "False", dependencyNotReadyReason, false, "api"),
+				testHelmRelease("ns/api", "False", dependencyNotReadyReason, false, "db"),
+				testHelmRelease("ns/db", "False", "UpgradeFailed", false),
+			},
+			key:  "ns/app",
+			want: []string{"ns/db"},
+		},
+		{
+			name: "chain stops at a release failing on its own",
+			releases: []unstructured.Unstructured{
+				testHelmRelease("ns/app", "False", dependencyNotReadyReason, false, "api"),
+				testHelmRelease("ns/api", "False", "InstallFailed", false, "db"),
+				testHelmRelease("ns/db", "False", "UpgradeFailed", false),
+			},
+			key:  "ns/app",
+			want: []string{"ns/api"},
+		},
+		{
+			name: "suspended but Ready dependency does not block",
+			releases: []unstructured.Unstructured{
+				testHelmRelease("ns/app", "False", dependencyNotReadyReason, false, "db"),
+				testHelmRelease("ns/db", "True", "UpgradeSucceeded", true),
+			},
+			key:  "ns/app",
+			want: []string{"ns/app"},
+		},
+		{
+			name: "several roots are sorted",
+			releases: []unstructured.Unstructured{
+				testHelmRelease("ns/app", "False", dependencyNotReadyReason, false, "redis", "db"),
+				testHelmRelease("ns/db", "False", "InstallFailed", false),
+				testHelmRelease("ns/redis", "False", "InstallFailed", false),
+			},
+			key:  "ns/app",
+			want: []string{"ns/db", "ns/redis"},
+		},
+		{
+			name: "cycle is its own root",
+			releases: []unstructured.Unstructured{
+				testHelmRelease("ns/a", "False", dependencyNotReadyReason, false, "b"),
+				testHelmRelease("ns/b", "False", dependencyNotReadyReason, false, "a"),
+			},
+			key:  "ns/a",
+			want: []string{"ns/a"},
+		},
+		{
+			name: "dependency in another namespace is fetched",
+			releases: []unstructured.Unstructured{
+				testHelmRelease("apps/web", "False", dependencyNotReadyReason, false, "infra/ingress"),
+			},
+			fetched: []unstructured.Unstructured{
+				testHelmRelease("infra/ingress", "False", "InstallFailed", false),
+			},
+			key:  "apps/web",
+			want: []string{"infra/ingress"},
+		},
+		{
+			name: "missing dependency is ignored",
+			releases: []unstructured.Unstructured{
+				testHelmRelease("ns/app", "False", dependencyNotReadyReason, false, "gone"),
+			},
+			key:  "ns/app",
+			want: []string{"ns/app"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetch := func(key string) (unstructured.Unstructured, bool) {
+				for _, hr := range tt.fetched {
+					if objectKey(hr) == key {
+						return hr, true
+					}
+				}
+				return unstructured.Unstructured{}, false
+			}
+			g := newDependencyGraph(tt.releases, fetch)
+			if got := g.rootCauses(tt.key); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rootCauses(%s) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDependencyGraphFetchesOnce(t *testing.T) {
+	releases := []unstructured.Unstructured{
+		testHelmRelease("ns/app", "False", dependencyNotReadyReason, false, "infra/ingress", "gone"),
+		testHelmRelease("ns/worker", "False", dependencyNotReadyReason, false, "infra/ingress", "gone"),
+	}
+	fetched := map[string]int{}
+	fetch := func(key string) (unstructured.Unstructured, bool) {
+		fetched[key]++
+		if key == "infra/ingress" {
+			return testHelmRelease(key, "False", "InstallFailed", false), true
+		}
+		return unstructured.Unstructured{}, false
+	}
+
+	g := newDependencyGraph(releases, fetch)
+	for _, key := range []string{"ns/app", "ns/worker", "ns/app"} {
+		if got := g.rootCauses(key); !reflect.DeepEqual(got, []string{"infra/ingress"}) {
+			t.Errorf("rootCauses(%s) = %v, want infra/ingress", key, got)
+		}
+	}
+	if want := map[string]int{"infra/ingress": 1, "ns/gone": 1}; !reflect.DeepEqual(fetched, want) {
+		t.Errorf("fetches = %v, want every key fetched once, %v", fetched, want)
+	}
+}
+
+func TestAttributeRootCauses(t *testing.T) {
+	releases := []unstructured.Unstructured{
+		testHelmRelease("ns/app", "False", dependencyNotReadyReason, false, "db"),
+		testHelmRelease("ns/worker", "False", "InstallFailed", false, "db"),
+		testHelmRelease("ns/db", "False", "UpgradeFailed", false),
+	}
+	var findings []Finding
+	for _, hr := range releases {
+		findings = append(findings, helmReleaseFinding(hr, fluxReadiness(hr), 0))
+	}
+
+	attributeRootCauses(findings, newDependencyGraph(releases, nil))
+
+	db := helmReleaseRef("ns/db")
+	if got := findings[0].CausedBy; !reflect.DeepEqual(got, []ResourceRef{db}) {
+		t.Errorf("DependencyNotReady finding CausedBy = %v, want %v", got, []ResourceRef{db})
+	}
+	if got := findings[1].CausedBy; got != nil {
+		t.Errorf("InstallFailed finding CausedBy = %v, want none", got)
+	}
+	if got := findings[2].Dependents; !reflect.DeepEqual(got, []ResourceRef{helmReleaseRef("ns/app")}) {
+		t.Errorf("root Dependents = %v, want only ns/app", got)
+	}
+}
@@ -0,0 +1,241 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"gitlab.com/kobot/kobot/pkg/logging"
+	v1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// maxEventsPerFinding caps how many events are attached to a single finding.
+const maxEventsPerFinding = 5
+
+// Event is a Kubernetes Event correlated with a finding, either about the
+// failing object itself or about one of its owners.
+type Event struct {
+	Type    string      `json:"type"`
+	Reason  string      `json:"reason"`
+	Message string      `json:"message"`
+	Object  ResourceRef `json:"object"`
+	// Count is how often the event was observed; repeated events are folded into one.
+	Count    int32     `json:"count"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// String renders the event the way it is shown next to a finding.
+func (e Event) String() string {
+	s := fmt.Sprintf("%s %s %s: %s", e.LastSeen.Format(time.RFC3339), e.Object.String(), e.Reason, e.Message)
+	if e.Count > 1 {
+		s += fmt.Sprintf(" (x%d)", e.Count)
+	}
+	return s
+}
+
+// eventIndex holds the Warning events of one scope keyed by the object they are about.
+type eventIndex map[string][]Event
+
+// correlateEvents attaches the most recent Warning events of each failing object
+// and its owners to the object's most severe finding. Events are read from both
+// the core/v1 and the events.k8s.io/v1 API, since emitters use either; copies of
+// the same event are folded together and the result is ordered oldest first.
+func correlateEvents(ctx context.Context, env *Env, findings []Finding) {
+	owners := newOwnerResolver(env.Clientset)
+	indexes := make(map[string]eventIndex) // namespace -> events
+
+	for _, i := range primaryFindings(findings) {
+		f := &findings[i]
+		ns := f.Resource.Namespace
+
+		index, ok := indexes[ns]
+		if !ok {
+			index = listWarningEvents(ctx, env, ns)
+			indexes[ns] = index
+		}
+		if len(index) == 0 {
+			continue
+		}
+
+		objects := append([]ResourceRef{f.Resource}, owners.chain(ctx, f.Resource)...)
+		var events []Event
+		for _, obj := range objects {
+			events = append(events, index[eventKey(obj.Kind, obj.Namespace, obj.Name)]...)
+		}
+		f.Events = relevantEvents(events)
+	}
+}
+
+// primaryFindings returns the index of the most severe warning-or-worse finding
+// of every object, so events are shown once per object instead of per finding.
+func primaryFindings(findings []Finding) []int {
+	best := make(map[string]int)
+	var order []string
+	for i, f := range findings {
+		if !f.Severity.AtLeast(SeverityWarning) {
+			continue
+		}
+		k := f.Resource.key()
+		j, ok := best[k]
+		if !ok {
+			order = append(order, k)
+		}
+		if !ok || f.Severity.rank() > findings[j].Severity.rank() {
+			best[k] = i
+		}
+	}
+
+	out := make([]int, 0, len(order))
+	for _, k := range order {
+		out = append(out, best[k])
+	}
+	return out
+}
+
+// relevantEvents folds repeated events, orders them by time and keeps the newest few.
+func relevantEvents(events []Event) []Event {
+	folded := make(map[string]*Event)
+	var order []string
+	for _, e := range events {
+		k := eventKey(e.Object.Kind, e.Object.Namespace, e.Object.Name) + "/" + e.Reason + "/" + e.Message
+		existing, ok := folded[k]
+		if !ok {
+			e := e
+			folded[k] = &e
+			order = append(order, k)
+			continue
+		}
+		existing.Count += e.Count
+		if e.LastSeen.After(existing.LastSeen) {
+			existing.LastSeen = e.LastSeen
+		}
+	}
+
+	out := make([]Event, 0, len(order))
+	for _, k := range order {
+		out = append(out, *folded[k])
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].LastSeen.Before(out[j].LastSeen) })
+	if len(out) > maxEventsPerFinding {
+		out = out[len(out)-maxEventsPerFinding:]
+	}
+	return out
+}
+
+// eventKey identifies the object an event is about.
+func eventKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// listWarningEvents reads the Warning events of a namespace (or of cluster-scoped
+// objects) from both event APIs. Failures only cost evidence, so they are logged
+// and otherwise ignored.
+func listWarningEvents(ctx context.Context, env *Env, namespace string) eventIndex {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	index := make(eventIndex)
+	seen := make(map[types.UID]bool)
+	add := func(uid types.UID, e Event) {
+		if seen[uid] {
+			return
+		}
+		seen[uid] = true
+		k := eventKey(e.Object.Kind, e.Object.Namespace, e.Object.Name)
+		index[k] = append(index[k], e)
+	}
+
+	coreOpts := metav1.ListOptions{FieldSelector: "type=" + v1.EventTypeWarning}
+	eventsOpts := metav1.ListOptions{FieldSelector: "type=" + v1.EventTypeWarning}
+	listNamespace := namespace
+	if namespace == ClusterScope {
+		// events about cluster-scoped objects live in the default namespace, but only
+		// their empty involvedObject.namespace tells them apart from namespaced ones
+		listNamespace = metav1.NamespaceAll
+		coreOpts.FieldSelector += ",involvedObject.namespace="
+		eventsOpts.FieldSelector += ",regarding.namespace="
+	}
+
+	if list, err := env.Clientset.EventsV1().Events(listNamespace).List(ctx, eventsOpts); err != nil {
+		logging.Warn("Unable to list events.k8s.io events in %s: %v", scopeLabel(namespace), err)
+	} else {
+		for _, e := range list.Items {
+			add(e.UID, fromEventsV1(e))
+		}
+	}
+
+	if list, err := env.Clientset.CoreV1().Events(listNamespace).List(ctx, coreOpts); err != nil {
+		logging.Warn("Unable to list core/v1 events in %s: %v", scopeLabel(namespace), err)
+	} else {
+		for _, e := range list.Items {
+			add(e.UID, fromCoreEvent(e))
+		}
+	}
+
+	return index
+}
+
+// fromCoreEvent converts a core/v1 Event.
+func fromCoreEvent(e v1.Event) Event {
+	out := Event{
+		Type:    e.Type,
+		Reason:  e.Reason,
+		Message: e.Message,
+		Object:  eventObject(e.InvolvedObject),
+		Count:   e.Count,
+	}
+
+	switch {
+	case e.Series != nil:
+		out.Count = e.Series.Count
+		out.LastSeen = e.Series.LastObservedTime.Time
+	case !e.LastTimestamp.IsZero():
+		out.LastSeen = e.LastTimestamp.Time
+	case !e.EventTime.IsZero():
+		out.LastSeen = e.EventTime.Time
+	default:
+		out.LastSeen = e.CreationTimestamp.Time
+	}
+	if out.Count == 0 {
+		out.Count = 1
+	}
+	return out
+}
+
+// fromEventsV1 converts an events.k8s.io/v1 Event.
+func fromEventsV1(e eventsv1.Event) Event {
+	out := Event{
+		Type:    e.Type,
+		Reason:  e.Reason,
+		Message: e.Note,
+		Object:  eventObject(e.Regarding),
+		Count:   e.DeprecatedCount,
+	}
+
+	switch {
+	case e.Series != nil:
+		out.Count = e.Series.Count
+		out.LastSeen = e.Series.LastObservedTime.Time
+	case !e.EventTime.IsZero():
+		out.LastSeen = e.EventTime.Time
+	case !e.DeprecatedLastTimestamp.IsZero():
+		out.LastSeen = e.DeprecatedLastTimestamp.Time
+	default:
+		out.LastSeen = e.CreationTimestamp.Time
+	}
+	if out.Count == 0 {
+		out.Count = 1
+	}
+	return out
+}
+
+// eventObject converts the object reference of an event into a ResourceRef.
+func eventObject(ref v1.ObjectReference) ResourceRef {
+	gv, _ := schema.ParseGroupVersion(ref.APIVersion)
+	return ResourceRef{Group: gv.Group, Version: gv.Version, Kind: ref.Kind, Namespace: ref.Namespace, Name: ref.Name}
+}
@@ -0,0 +1,114 @@
+package checks
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPrimaryFindings(t *testing.T) {
+	web, api := podRef("shop", "web"), podRef("shop", "api")
+	findings := []Finding{
+		{Severity: SeverityWarning, Resource: web},
+		{Severity: SeverityInfo, Resource: api},
+		{Severity: SeverityCritical, Resource: web},
+		{Severity: SeverityWarning, Resource: api},
+		{Severity: SeverityWarning, Resource: api},
+	}
+	if got, want := primaryFindings(findings), []int{2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("primaryFindings() = %v, want %v", got, want)
+	}
+}
+
+func TestRelevantEvents(t *testing.T) {
+	at := func(minute int) time.Time { return time.Date(2026, 3, 1, 10, minute, 0, 0, time.UTC) }
+	pod := podRef("shop", "web")
+	event := func(reason string, minute int) Event {
+		return Event{Type: v1.EventTypeWarning, Reason: reason, Message: reason + " happened", Object: pod, Count: 1, LastSeen: at(minute)}
+	}
+
+	got := relevantEvents([]Event{
+		event("BackOff", 5), event("Unhealthy", 1), event("BackOff", 9),
+		event("A", 2), event("B", 3), event("C", 4), event("D", 6),
+	})
+	var reasons []string
+	for _, e := range got {
+		reasons = append(reasons, e.Reason)
+	}
+	if want := []string{"A", "B", "C", "D", "BackOff"}; !reflect.DeepEqual(reasons, want) {
+		t.Fatalf("relevantEvents() = %v, want %v", reasons, want)
+	}
+	if backOff := got[4]; backOff.Count != 2 || !backOff.LastSeen.Equal(at(9)) {
+		t.Errorf("folded BackOff = count %d, last seen %s, want count 2 at %s", backOff.Count, backOff.LastSeen, at(9))
+	}
+}
+
+func TestFromEvents(t *testing.T) {
+	last := metav1.NewTime(time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC))
+	series := metav1.NewMicroTime(time.Date(2026, 3, 1, 11, 0, 0, 0, time.UTC))
+	object := v1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "shop", Name: "web"}
+	want := ResourceRef{Group: "apps", Version: "v1", Kind: "Deployment", Namespace: "shop", Name: "web"}
+
+	core := fromCoreEvent(v1.Event{InvolvedObject: object, Reason: "FailedCreate", Message: "quota", LastTimestamp: last})
+	if core.Object != want || core.Count != 1 || !core.LastSeen.Equal(last.Time) || core.Message != "quota" {
+		t.Errorf("fromCoreEvent() = %+v", core)
+	}
+	core = fromCoreEvent(v1.Event{InvolvedObject: object, Count: 3, LastTimestamp: last, Series: &v1.EventSeries{Count: 7, LastObservedTime: series}})
+	if core.Count != 7 || !core.LastSeen.Equal(series.Time) {
+		t.Errorf("fromCoreEvent() with series = count %d at %s, want 7 at %s", core.Count, core.LastSeen, series.Time)
+	}
+
+	ev := fromEventsV1(eventsv1.Event{Regarding: object, Reason: "FailedCreate", Note: "quota", EventTime: series})
+	if ev.Object != want || ev.Count != 1 || !ev.LastSeen.Equal(series.Time) || ev.Message != "quota" {
+		t.Errorf("fromEventsV1() = %+v", ev)
+	}
+}
+
+func TestCorrelateEvents(t *testing.T) {
+	yes := true
+	owner := func(apiVersion, kind, name string) []metav1.OwnerReference {
+		return []metav1.OwnerReference{{APIVersion: apiVersion, Kind: kind, Name: name, Controller: &yes}}
+	}
+	warning := func(name string, object v1.ObjectReference, reason string) *v1.Event {
+		return &v1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Namespace: "shop", Name: name, UID: types.UID(name)},
+			Type:           v1.EventTypeWarning,
+			InvolvedObject: object,
+			Reason:         reason,
+			Count:          1,
+			LastTimestamp:  metav1.NewTime(time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)),
+		}
+	}
+	cs := fake.NewSimpleClientset(
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web-5d9c7-abcde", OwnerReferences: owner("apps/v1", "ReplicaSet", "web-5d9c7")}},
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web-5d9c7", OwnerReferences: owner("apps/v1", "Deployment", "web")}},
+		warning("e1", v1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "shop", Name: "web-5d9c7-abcde"}, "BackOff"),
+		warning("e2", v1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "shop", Name: "web"}, "ProgressDeadlineExceeded"),
+		warning("e3", v1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "shop", Name: "other"}, "Unrelated"),
+	)
+
+	findings := []Finding{
+		{Severity: SeverityCritical, Resource: podRef("shop", "web-5d9c7-abcde")},
+		{Severity: SeverityInfo, Resource: podRef("shop", "other")},
+	}
+	correlateEvents(context.Background(), &Env{Clientset: cs}, findings)
+
+	var reasons []string
+	for _, e := range findings[0].Events {
+		reasons = append(reasons, e.Reason)
+	}
+	if want := []string{"BackOff", "ProgressDeadlineExceeded"}; !reflect.DeepEqual(reasons, want) {
+		t.Errorf("events of the pod finding = %v, want %v", reasons, want)
+	}
+	if findings[1].Events != nil {
+		t.Errorf("info finding got events %v, want none", findings[1].Events)
+	}
+}
@@ -0,0 +1,140 @@
+package checks
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Severity ranks how urgently a finding needs operator attention.
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// rank orders severities so they can be compared against a threshold.
+func (s Severity) rank() int {
+	switch s {
+	case SeverityInfo:
+		return 1
+	case SeverityWarning:
+		return 2
+	case SeverityCritical:
+		return 3
+	}
+	return 0
+}
+
+// AtLeast reports whether s is as severe as, or more severe than, other.
+func (s Severity) AtLeast(other Severity) bool {
+	return s.rank() >= other.rank()
+}
+
+// ParseSeverity converts user input (e.g. a flag value) into a Severity.
+func ParseSeverity(s string) (Severity, error) {
+	sev := Severity(strings.ToLower(strings.TrimSpace(s)))
+	if sev.rank() == 0 {
+		return "", fmt.Errorf("unknown severity %q (expected info, warning or critical)", s)
+	}
+	return sev, nil
+}
+
+// ResourceRef identifies the cluster object a finding is about.
+type ResourceRef struct {
+	Group     string `json:"group,omitempty"`
+	Version   string `json:"version"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// GVK returns the GroupVersionKind of the referenced object.
+func (r ResourceRef) GVK() schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: r.Group, Version: r.Version, Kind: r.Kind}
+}
+
+// String renders the reference the way kubectl does (e.g. "Pod/api-7d9f").
+func (r ResourceRef) String() string {
+	return r.Kind + "/" + r.Name
+}
+
+// key uniquely identifies the referenced object inside a report.
+func (r ResourceRef) key() string {
+	return r.Group + "/" + r.Kind + "/" + r.Namespace + "/" + r.Name
+}
+
+// Finding is a single problem a check discovered on a cluster object.
+// Every check returns findings and every reporter consumes them, so this is
+// the one place to extend when results need to carry more information.
+type Finding struct {
+	// CheckID is the name of the check that produced the finding (e.g. "pods").
+	CheckID  string   `json:"checkId"`
+	Severity Severity `json:"severity"`
+	// Reason is a short, machine-friendly CamelCase cause (e.g. "CrashLoopBackOff").
+	Reason   string      `json:"reason"`
+	Resource ResourceRef `json:"resource"`
+	// Owner is the top-level controller of the resource (e.g. the Deployment of a pod).
+	Owner   *ResourceRef `json:"owner,omitempty"`
+	Message string       `json:"message"`
+	// Evidence holds the raw facts (status messages, exit codes) backing the finding.
+	Evidence []string `json:"evidence,omitempty"`
+	// Action is the suggested next step for the operator.
+	Action string `json:"suggestedAction,omitempty"`
+	// CausedBy lists the root failing objects this finding is a symptom of.
+	// Reporters collapse such findings under their root cause.
+	CausedBy []ResourceRef `json:"causedBy,omitempty"`
+	// Dependents lists the objects that fail because of this one.
+	Dependents []ResourceRef `json:"dependents,omitempty"`
+	// Events are the recent Warning events of the object and its owners, oldest first.
+	Events []Event `json:"events,omitempty"`
+	// Logs is the log tail of the crashed container the finding is about (--logs).
+	Logs *LogExcerpt `json:"logs,omitempty"`
+	// Waiver is the waiver that accepted the finding, set on the Waived findings of a result.
+	Waiver *Waiver `json:"waiver,omitempty"`
+}
+
+// findingBuilder collects findings for a single object; it keeps the inspect
+// functions focused on the health rules rather than on struct literals.
+type findingBuilder struct {
+	check string
+	ref   ResourceRef
+	// owner, when set, is recorded as the top-level controller of ref.
+	owner    *ResourceRef
+	findings []Finding
+}
+
+func (b *findingBuilder) add(severity Severity, reason, message, action string, evidence ...string) {
+	f := Finding{
+		CheckID:  b.check,
+		Severity: severity,
+		Reason:   reason,
+		Resource: b.ref,
+		Owner:    b.owner,
+		Message:  message,
+		Action:   action,
+	}
+	for _, e := range evidence {
+		if e != "" {
+			f.Evidence = append(f.Evidence, e)
+		}
+	}
+	b.findings = append(b.findings, f)
+}
+
+// podRef returns the ResourceRef for a core/v1 Pod.
+func podRef(namespace, name string) ResourceRef {
+	return ResourceRef{Version: "v1", Kind: "Pod", Namespace: namespace, Name: name}
+}
+
+// CountBySeverity returns how many findings exist for each severity.
+func CountBySeverity(findings []Finding) map[Severity]int {
+	counts := make(map[Severity]int)
+	for _, f := range findings {
+		counts[f.Severity]++
+	}
+	return counts
+}
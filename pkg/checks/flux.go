@@ -0,0 +1,261 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gitlab.com/kobot/kobot/pkg/logging"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func init() {
+	Register(fluxCheck{})
+}
+
+// reconcileRequestedAnnotation is set by 'flux reconcile' to ask a controller for an immediate reconcile.
+const reconcileRequestedAnnotation = "reconcile.fluxcd.io/requestedAt"
+
+// fluxKinds are the Kustomization and source APIs; HelmReleases have their own check.
+var fluxKinds = []apiKind{
+	{Kind: "Kustomization", Group: "kustomize.toolkit.fluxcd.io", Resource: "kustomizations", Versions: []string{"v1"}},
+	{Kind: "GitRepository", Group: "source.toolkit.fluxcd.io", Resource: "gitrepositories", Versions: []string{"v1"}},
+	{Kind: "HelmRepository", Group: "source.toolkit.fluxcd.io", Resource: "helmrepositories", Versions: []string{"v1", "v1beta2"}},
+	{Kind: "OCIRepository", Group: "source.toolkit.fluxcd.io", Resource: "ocirepositories", Versions: []string{"v1", "v1beta2"}},
+}
+
+// fluxStatus is the readiness verdict for any Flux toolkit object.
+type fluxStatus struct {
+	Ready     bool
+	Suspended bool
+	// Pending is set when the object has no Ready condition and no observedGeneration:
+	// its controller has not processed it yet, which is expected right after it is applied.
+	Pending bool
+	Reason  string
+	Message string
+}
+
+// fluxReadiness checks the .status.conditions of a Flux object for Ready=True
+// and also ensures the resource is not suspended (.spec.suspend != true).
+// OCI HelmRepositories are always Ready: source-controller does not reconcile
+// them, so they never get a status.
+func fluxReadiness(obj unstructured.Unstructured) fluxStatus {
+	suspended, found, err := unstructured.NestedBool(obj.Object, "spec", "suspend")
+	if err == nil && found && suspended {
+		return fluxStatus{Suspended: true, Reason: "Suspended", Message: obj.GetKind() + " is suspended"}
+	}
+
+	if repoType, _, _ := unstructured.NestedString(obj.Object, "spec", "type"); obj.GetKind() == "HelmRepository" && repoType == "oci" {
+		return fluxStatus{Ready: true}
+	}
+
+	_, observed, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+
+	conditions, found, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if !found || err != nil {
+		return fluxStatus{Pending: !observed, Reason: "NoConditions", Message: "no conditions found"}
+	}
+
+	for _, c := range conditions {
+		if cond, ok := c.(map[string]interface{}); ok {
+			t, _, _ := unstructured.NestedString(cond, "type")
+			s, _, _ := unstructured.NestedString(cond, "status")
+			r, _, _ := unstructured.NestedString(cond, "reason")
+			m, _, _ := unstructured.NestedString(cond, "message")
+
+			if t == "Ready" {
+				return fluxStatus{Ready: s == "True", Reason: r, Message: m}
+			}
+		}
+	}
+	return fluxStatus{Pending: !observed, Reason: "ReadyConditionMissing", Message: "Ready condition missing"}
+}
+
+// fluxCheck reports Kustomizations and Flux sources (Git, Helm and OCI repositories)
+// that are not Ready, suspended, ignoring reconcile requests or not applying the
+// latest artifact revision. A failing source is often the real reason HelmReleases
+// and Kustomizations downstream of it look broken.
+type fluxCheck struct{}
+
+func (fluxCheck) Name() string { return "flux" }
+
+func (fluxCheck) Description() string {
+	return "Flux Kustomization, GitRepository, HelmRepository and OCIRepository readiness"
+}
+
+func (fluxCheck) RequiredClients() []Client { return []Client{DynamicClient} }
+
+func (fluxCheck) Run(ctx context.Context, env *Env) (*Result, error) {
+	logging.Info("Scanning Flux Kustomizations and sources.")
+	logging.Starting("Operator-initiated Flux source and Kustomization readiness check")
+
+	result := &Result{Resource: "Flux objects"}
+	lister := newAPILister(env.Dynamic)
+
+	var objects []unstructured.Unstructured
+	sources := make(map[string]unstructured.Unstructured) // Kind/namespace/name -> source
+
+	for _, ns := range env.Namespaces {
+		logging.Running("Scan job on namespace: %s", ns)
+		nsResult := NamespaceResult{Name: ns}
+		var errs []string
+
+		for _, kind := range fluxKinds {
+			nsCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+			items, err := lister.list(nsCtx, kind, ns)
+			cancel()
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("unable to list %ss: %v", kind.Kind, err))
+				continue
+			}
+
+			nsResult.Checked += len(items)
+			for _, obj := range items {
+				objects = append(objects, obj)
+				if kind.Kind != "Kustomization" {
+					sources[sourceKey(obj.GetKind(), obj.GetNamespace(), obj.GetName())] = obj
+				}
+			}
+		}
+
+		nsResult.Error = strings.Join(errs, "; ")
+		result.Namespaces = append(result.Namespaces, nsResult)
+	}
+
+	// list errors (e.g. Forbidden) leave the kinds unresolved too; those stay scan errors
+	if lister.installed() == 0 && ScanErrors([]*Result{result}) == 0 {
+		return skipped(result.Resource, "no Flux Kustomization or source APIs are installed"), nil
+	}
+
+	for _, obj := range objects {
+		b := &findingBuilder{check: "flux", ref: customResourceRef(obj)}
+		inspectFluxObject(b, obj, env.FluxGrace)
+
+		if obj.GetKind() == "Kustomization" {
+			source, ok := lister.source(ctx, obj, sources)
+			inspectKustomizationRevision(b, obj, source, ok)
+		}
+		result.Findings = append(result.Findings, b.findings...)
+	}
+
+	return result, nil
+}
+
+// inspectFluxObject applies the Ready/suspend, generation and reconcile request rules shared by every Flux kind.
+func inspectFluxObject(b *findingBuilder, obj unstructured.Unstructured, grace time.Duration) {
+	kind, name, ns := obj.GetKind(), obj.GetName(), obj.GetNamespace()
+	fluxCmd := strings.ToLower(kind)
+	if kind != "Kustomization" {
+		fluxCmd = "source " + strings.TrimSuffix(strings.ToLower(kind), "repository")
+	}
+
+	status := fluxReadiness(obj)
+	switch {
+	case status.Suspended:
+		b.add(SeverityWarning, "Suspended", status.Message,
+			fmt.Sprintf("Confirm the suspension is intended, otherwise run 'flux resume %s %s -n %s'.", fluxCmd, name, ns))
+		return
+	case status.Pending:
+		b.add(SeverityWarning, status.Reason, fmt.Sprintf("%s has not been reconciled by its controller yet", kind),
+			fmt.Sprintf("Rerun kobot once Flux has reconciled it; if it stays without a status, check that the Flux controllers are running and watching namespace %s.", ns),
+			status.Message)
+	case !status.Ready:
+		action := fmt.Sprintf("Review the status with 'flux get %s %s -n %s' and the controller logs.", fluxCmd, name, ns)
+		if kind != "Kustomization" {
+			action += " HelmReleases and Kustomizations using this source cannot reconcile until it is Ready."
+		}
+		b.add(SeverityCritical, status.Reason, fmt.Sprintf("%s is not Ready (Reason: %s)", kind, status.Reason), action, status.Message)
+	}
+
+	observed, found, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if found && observed < obj.GetGeneration() {
+		b.add(SeverityWarning, "GenerationNotObserved",
+			fmt.Sprintf("Controller has not reconciled the latest spec (generation %d, observed %d)", obj.GetGeneration(), observed),
+			"Check that the Flux controllers are running and not overloaded.")
+	}
+
+	// a manual 'flux reconcile' that the controller never picked up
+	requested := obj.GetAnnotations()[reconcileRequestedAnnotation]
+	handled, _, _ := unstructured.NestedString(obj.Object, "status", "lastHandledReconcileAt")
+	if requested != "" && requested != handled && requestIsStale(requested, grace) {
+		b.add(SeverityWarning, "ReconcileRequestNotHandled", "Requested reconcile has not been handled by the controller",
+			"Check that the Flux controllers are running and not overloaded.",
+			fmt.Sprintf("requestedAt=%s lastHandledReconcileAt=%s", requested, handled))
+	}
+}
+
+// requestIsStale reports whether a reconcile request timestamp is older than the grace period.
+// Timestamps that are not RFC3339 (the annotation is free-form) are always treated as stale.
+func requestIsStale(requested string, grace time.Duration) bool {
+	at, err := time.Parse(time.RFC3339Nano, requested)
+	if err != nil {
+		return true
+	}
+	return time.Since(at) > grace
+}
+
+// inspectKustomizationRevision compares what a Kustomization applied against what it
+// attempted and against the artifact its source currently serves.
+func inspectKustomizationRevision(b *findingBuilder, ks unstructured.Unstructured, source unstructured.Unstructured, sourceFound bool) {
+	applied, _, _ := unstructured.NestedString(ks.Object, "status", "lastAppliedRevision")
+	attempted, _, _ := unstructured.NestedString(ks.Object, "status", "lastAttemptedRevision")
+	flux := fmt.Sprintf("flux get kustomization %s -n %s", ks.GetName(), ks.GetNamespace())
+
+	if attempted != "" && attempted != applied {
+		b.add(SeverityCritical, "RevisionNotApplied", "Latest attempted revision failed to apply",
+			fmt.Sprintf("Check the apply error with '%s' and fix the manifests in that revision.", flux),
+			fmt.Sprintf("lastAttemptedRevision=%s lastAppliedRevision=%s", attempted, applied))
+		return
+	}
+
+	if !sourceFound {
+		return
+	}
+	artifact, found, _ := unstructured.NestedString(source.Object, "status", "artifact", "revision")
+	if found && artifact != "" && applied != "" && artifact != applied {
+		b.add(SeverityWarning, "SourceRevisionNotApplied",
+			fmt.Sprintf("Source %s/%s serves a revision the Kustomization has not applied yet", source.GetKind(), source.GetName()),
+			fmt.Sprintf("Run 'flux reconcile kustomization %s -n %s' and check '%s'.", ks.GetName(), ks.GetNamespace(), flux),
+			fmt.Sprintf("source artifact=%s lastAppliedRevision=%s", artifact, applied))
+	}
+}
+
+// sourceKey identifies a Flux source in the index built while scanning.
+func sourceKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// source returns the source a Kustomization points at, from the scan index or
+// fetched directly when it lives in a namespace that was not scanned.
+func (l *apiLister) source(ctx context.Context, ks unstructured.Unstructured, index map[string]unstructured.Unstructured) (unstructured.Unstructured, bool) {
+	kind, _, _ := unstructured.NestedString(ks.Object, "spec", "sourceRef", "kind")
+	name, _, _ := unstructured.NestedString(ks.Object, "spec", "sourceRef", "name")
+	ns, _, _ := unstructured.NestedString(ks.Object, "spec", "sourceRef", "namespace")
+	if ns == "" {
+		ns = ks.GetNamespace()
+	}
+
+	if obj, ok := index[sourceKey(kind, ns, name)]; ok {
+		return obj, true
+	}
+
+	for _, k := range fluxKinds {
+		if k.Kind != kind {
+			continue
+		}
+		gvr, ok := l.gvr(k)
+		if !ok {
+			return unstructured.Unstructured{}, false
+		}
+		getCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		obj, err := l.dynamic.Resource(gvr).Namespace(ns).Get(getCtx, name, metav1.GetOptions{})
+		if err != nil {
+			return unstructured.Unstructured{}, false
+		}
+		return *obj, true
+	}
+	return unstructured.Unstructured{}, false
+}
@@ -0,0 +1,165 @@
+package checks
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// testFluxObject builds a Flux object of kind in namespace flux-system with the given spec and status.
+func testFluxObject(kind, name string, spec, status map[string]interface{}) unstructured.Unstructured {
+	obj := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "source.toolkit.fluxcd.io/v1",
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"namespace": "flux-system", "name": name},
+	}}
+	if spec != nil {
+		obj.Object["spec"] = spec
+	}
+	if status != nil {
+		obj.Object["status"] = status
+	}
+	return obj
+}
+
+func TestFluxReadiness(t *testing.T) {
+	ready := func(status, reason string) map[string]interface{} {
+		return map[string]interface{}{
+			"observedGeneration": int64(1),
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Reconciling", "status": "False"},
+				map[string]interface{}{"type": "Ready", "status": status, "reason": reason, "message": "msg"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name string
+		obj  unstructured.Unstructured
+		want fluxStatus
+	}{
+		{name: "ready", obj: testFluxObject("GitRepository", "app", nil, ready("True", "Succeeded")), want: fluxStatus{Ready: true, Reason: "Succeeded", Message: "msg"}},
+		{name: "failing", obj: testFluxObject("GitRepository", "app", nil, ready("False", "GitOperationFailed")), want: fluxStatus{Reason: "GitOperationFailed", Message: "msg"}},
+		{
+			name: "suspended wins over Ready",
+			obj:  testFluxObject("GitRepository", "app", map[string]interface{}{"suspend": true}, ready("True", "Succeeded")),
+			want: fluxStatus{Suspended: true, Reason: "Suspended", Message: "GitRepository is suspended"},
+		},
+		{name: "not reconciled yet", obj: testFluxObject("GitRepository", "app", nil, nil), want: fluxStatus{Pending: true, Reason: "NoConditions", Message: "no conditions found"}},
+		{
+			name: "processed without conditions",
+			obj:  testFluxObject("GitRepository", "app", nil, map[string]interface{}{"observedGeneration": int64(2)}),
+			want: fluxStatus{Reason: "NoConditions", Message: "no conditions found"},
+		},
+		{
+			name: "Ready missing before the first reconcile",
+			obj:  testFluxObject("GitRepository", "app", nil, map[string]interface{}{"conditions": []interface{}{map[string]interface{}{"type": "Reconciling", "status": "True"}}}),
+			want: fluxStatus{Pending: true, Reason: "ReadyConditionMissing", Message: "Ready condition missing"},
+		},
+		{
+			name: "Ready missing after a reconcile",
+			obj: testFluxObject("GitRepository", "app", nil, map[string]interface{}{
+				"observedGeneration": int64(1),
+				"conditions":         []interface{}{map[string]interface{}{"type": "Reconciling", "status": "True"}},
+			}),
+			want: fluxStatus{Reason: "ReadyConditionMissing", Message: "Ready condition missing"},
+		},
+		{name: "OCI HelmRepository has no status", obj: testFluxObject("HelmRepository", "charts", map[string]interface{}{"type": "oci"}, nil), want: fluxStatus{Ready: true}},
+		{name: "default HelmRepository", obj: testFluxObject("HelmRepository", "charts", map[string]interface{}{"type": "default"}, nil), want: fluxStatus{Pending: true, Reason: "NoConditions", Message: "no conditions found"}},
+		{name: "OCIRepository is not skipped", obj: testFluxObject("OCIRepository", "manifests", map[string]interface{}{"type": "oci"}, nil), want: fluxStatus{Pending: true, Reason: "NoConditions", Message: "no conditions found"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fluxReadiness(tt.obj); got != tt.want {
+				t.Errorf("fluxReadiness() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInspectFluxObjectSeverity(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  unstructured.Unstructured
+		want []string
+	}{
+		{name: "OCI HelmRepository", obj: testFluxObject("HelmRepository", "charts", map[string]interface{}{"type": "oci"}, nil)},
+		{name: "not reconciled yet", obj: testFluxObject("GitRepository", "app", nil, nil), want: []string{"warning/NoConditions"}},
+		{name: "processed without conditions", obj: testFluxObject("GitRepository", "app", nil, map[string]interface{}{"observedGeneration": int64(1)}), want: []string{"critical/NoConditions"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &findingBuilder{check: "flux", ref: customResourceRef(tt.obj)}
+			inspectFluxObject(b, tt.obj, time.Minute)
+			if got := findingReasons(b.findings); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("inspectFluxObject() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestIsStale(t *testing.T) {
+	tests := []struct {
+		requested string
+		want      bool
+	}{
+		{requested: time.Now().Add(-10 * time.Second).Format(time.RFC3339Nano), want: false},
+		{requested: time.Now().Add(-10 * time.Minute).Format(time.RFC3339), want: true},
+		{requested: time.Now().Add(time.Minute).Format(time.RFC3339), want: false},
+		{requested: "2024-01-01", want: true},
+		{requested: "whenever", want: true},
+	}
+	for _, tt := range tests {
+		if got := requestIsStale(tt.requested, time.Minute); got != tt.want {
+			t.Errorf("requestIsStale(%q) = %v, want %v", tt.requested, got, tt.want)
+		}
+	}
+}
+
+func TestInspectKustomizationRevision(t *testing.T) {
+	kustomization := func(applied, attempted string) unstructured.Unstructured {
+		return testFluxObject("Kustomization", "apps", nil, map[string]interface{}{
+			"lastAppliedRevision":   applied,
+			"lastAttemptedRevision": attempted,
+		})
+	}
+	source := testFluxObject("GitRepository", "app", nil, map[string]interface{}{
+		"artifact": map[string]interface{}{"revision": "main@sha1:bbb"},
+	})
+
+	tests := []struct {
+		name        string
+		ks          unstructured.Unstructured
+		sourceFound bool
+		want        []string
+	}{
+		{name: "up to date", ks: kustomization("main@sha1:bbb", "main@sha1:bbb"), sourceFound: true},
+		{name: "attempted revision failed", ks: kustomization("main@sha1:aaa", "main@sha1:bbb"), sourceFound: true, want: []string{"critical/RevisionNotApplied"}},
+		{name: "source ahead", ks: kustomization("main@sha1:aaa", "main@sha1:aaa"), sourceFound: true, want: []string{"warning/SourceRevisionNotApplied"}},
+		{name: "source ahead but not found", ks: kustomization("main@sha1:aaa", "main@sha1:aaa")},
+		{name: "never applied", ks: kustomization("", ""), sourceFound: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &findingBuilder{check: "flux", ref: customResourceRef(tt.ks)}
+			inspectKustomizationRevision(b, tt.ks, source, tt.sourceFound)
+			if got := findingReasons(b.findings); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("inspectKustomizationRevision() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHelmReleaseFindingPending(t *testing.T) {
+	hr := testFluxObject("HelmRelease", "podinfo", nil, nil)
+	if f := helmReleaseFinding(hr, fluxReadiness(hr), 0); f.Severity != SeverityWarning || f.Reason != "NoConditions" {
+		t.Errorf("helmReleaseFinding() for an unprocessed release = %s/%s, want warning/NoConditions", f.Severity, f.Reason)
+	}
+	hr.Object["status"] = map[string]interface{}{"observedGeneration": int64(1)}
+	if f := helmReleaseFinding(hr, fluxReadiness(hr), 0); f.Severity != SeverityCritical {
+		t.Errorf("helmReleaseFinding() for a processed release without conditions = %s, want critical", f.Severity)
+	}
+}
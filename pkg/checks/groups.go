@@ -0,0 +1,142 @@
+package checks
+
+import (
+	"context"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// FindingGroup is the primary unit of a report: a top-level workload (or a lone
+// object without a controller) with the failing objects that belong to it. It
+// turns thirty identical pod failures into one line about their Deployment.
+type FindingGroup struct {
+	// Owner is the top-level controller, or the object itself when it has none.
+	Owner    ResourceRef `json:"owner"`
+	Severity Severity    `json:"severity"`
+	// Members are the objects with findings, e.g. the failing pods of a Deployment.
+	Members []ResourceRef `json:"members"`
+	// Reasons counts how many members share each finding reason.
+	Reasons map[string]int `json:"reasons"`
+	// Representative is the most severe, most common finding of the group.
+	Representative Finding `json:"representative"`
+	// Findings are all findings of the group's members.
+	Findings []Finding `json:"-"`
+}
+
+// grouped reports whether the group stands for a controller rather than for a single object.
+func (g FindingGroup) grouped() bool {
+	return len(g.Members) != 1 || g.Members[0].key() != g.Owner.key()
+}
+
+// groupFindings groups findings by the owner of their object, keeping the order in
+// which the groups first appear.
+func groupFindings(findings []Finding) []FindingGroup {
+	var order []string
+	groups := make(map[string]*FindingGroup)
+	members := make(map[string]map[string]bool) // group -> member keys
+	reasons := make(map[string]map[string]map[string]bool)
+
+	for _, f := range findings {
+		owner := f.Resource
+		if f.Owner != nil {
+			owner = *f.Owner
+		}
+		k := owner.key()
+
+		g, ok := groups[k]
+		if !ok {
+			g = &FindingGroup{Owner: owner, Reasons: make(map[string]int)}
+			groups[k] = g
+			members[k] = make(map[string]bool)
+			reasons[k] = make(map[string]map[string]bool)
+			order = append(order, k)
+		}
+		g.Findings = append(g.Findings, f)
+		if f.Severity.rank() > g.Severity.rank() {
+			g.Severity = f.Severity
+		}
+
+		mk := f.Resource.key()
+		if !members[k][mk] {
+			members[k][mk] = true
+			g.Members = append(g.Members, f.Resource)
+		}
+		if reasons[k][f.Reason] == nil {
+			reasons[k][f.Reason] = make(map[string]bool)
+		}
+		if !reasons[k][f.Reason][mk] {
+			reasons[k][f.Reason][mk] = true
+			g.Reasons[f.Reason]++
+		}
+	}
+
+	out := make([]FindingGroup, 0, len(order))
+	for _, k := range order {
+		g := groups[k]
+		g.Representative = representative(g)
+		out = append(out, *g)
+	}
+	return out
+}
+
+// representative picks the finding that best describes a group: the most severe
+// reason, and among equally severe ones the reason shared by the most members.
+func representative(g *FindingGroup) Finding {
+	best := g.Findings[0]
+	for _, f := range g.Findings[1:] {
+		switch {
+		case f.Severity.rank() > best.Severity.rank():
+			best = f
+		case f.Severity.rank() == best.Severity.rank() && g.Reasons[f.Reason] > g.Reasons[best.Reason]:
+			best = f
+		}
+	}
+	return best
+}
+
+// memberNoun names the members of a group in plural, e.g. "Pods", or "objects" for mixed kinds.
+func (g FindingGroup) memberNoun() string {
+	for _, m := range g.Members[1:] {
+		if m.Kind != g.Members[0].Kind {
+			return "objects"
+		}
+	}
+	return g.Members[0].Kind + "s"
+}
+
+// otherReasons returns the reasons of a group besides the representative one, most common first.
+func (g FindingGroup) otherReasons() []string {
+	var out []string
+	for reason := range g.Reasons {
+		if reason != g.Representative.Reason {
+			out = append(out, reason)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if g.Reasons[out[i]] != g.Reasons[out[j]] {
+			return g.Reasons[out[i]] > g.Reasons[out[j]]
+		}
+		return out[i] < out[j]
+	})
+	return out
+}
+
+// setPodOwners points the findings of a pod at its top-level controller, e.g. the
+// Deployment behind its ReplicaSet or the CronJob behind its Job.
+func setPodOwners(ctx context.Context, owners *ownerResolver, pod v1.Pod, findings []Finding) {
+	if len(findings) == 0 {
+		return
+	}
+	ref := podRef(pod.Namespace, pod.Name)
+	owners.seed(ref, pod.OwnerReferences)
+
+	chain := owners.chain(ctx, ref)
+	if len(chain) == 0 {
+		return
+	}
+	top := chain[len(chain)-1]
+	for i := range findings {
+		findings[i].Owner = &top
+	}
+}
@@ -3,156 +3,218 @@ package checks
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
-	"github.com/fatih/color"
 	"gitlab.com/kobot/kobot/pkg/logging"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 )
 
-// RunHelmReleaseCheck performs a health check on all HelmReleases
-// within a namespace or a default one (bigbang) if none is specified.
-func RunHelmReleaseCheck(dynamicClient dynamic.Interface, namespaces []string, htmlOutput bool, fluxGracePeriod int) {
-	// If user didn’t specify any namespaces, use "bigbang" by default
-	if len(namespaces) == 0 || (len(namespaces) == 1 && namespaces[0] == "") {
-		namespaces = []string{"bigbang"}
-		fmt.Println()
-		logging.Warn("No namespaces specified — defaulting to 'bigbang'.")
-	}
+func init() {
+	Register(helmReleaseCheck{})
+}
 
-	logging.Info("Scanning only HelmRelease resources.")
+var helmReleaseGVR = schema.GroupVersionResource{
+	Group:    "helm.toolkit.fluxcd.io",
+	Version:  "v2",
+	Resource: "helmreleases",
+}
 
-	if fluxGracePeriod != 5 {
-		logging.Info("Flux wait grace period set to %ds.", fluxGracePeriod)
-	} else {
-		logging.Info("No Flux wait grace period specified — using default of 5 seconds.")
-	}
+// helmReleaseCheck performs a health check on all HelmReleases
+// within the scanned namespaces.
+type helmReleaseCheck struct{}
 
-	logging.Starting("Operator-initiated HelmRelease readiness check")
-	time.Sleep(2 * time.Second)
+func (helmReleaseCheck) Name() string { return "helmreleases" }
 
-	helmReleaseGVR := schema.GroupVersionResource{
-		Group:    "helm.toolkit.fluxcd.io",
-		Version:  "v2",
-		Resource: "helmreleases",
-	}
+func (helmReleaseCheck) Description() string {
+	return "Flux HelmRelease readiness and suspension"
+}
 
-	var results []struct {
-		Name   string
-		Ready  bool
-		Reason string
-	}
+func (helmReleaseCheck) RequiredClients() []Client { return []Client{DynamicClient} }
+
+func (helmReleaseCheck) Run(ctx context.Context, env *Env) (*Result, error) {
+	logging.Info("Scanning HelmRelease resources.")
+	logging.Info("Flux wait grace period set to %s.", env.FluxGrace)
+	logging.Starting("Operator-initiated HelmRelease readiness check")
 
-	var totalHelmReleases, totalSuspended, failed int
+	result := &Result{Resource: "HelmReleases"}
+	var releases []unstructured.Unstructured
+	var pending []unstructured.Unstructured
 
 	// --- Loop over all provided namespaces
-	for _, ns := range namespaces {
-		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-		defer cancel()
-
-		logging.Info("Scanning namespace: %s", ns)
+	for _, ns := range env.Namespaces {
+		logging.Running("Scan job on namespace: %s", ns)
 
-		releases, err := dynamicClient.Resource(helmReleaseGVR).Namespace(ns).List(ctx, metav1.ListOptions{})
+		nsCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		list, err := env.Dynamic.Resource(helmReleaseGVR).Namespace(ns).List(nsCtx, metav1.ListOptions{LabelSelector: env.LabelSelector})
+		cancel()
 		if err != nil {
-			logging.Error("Unable to list HelmReleases in %s: %v", ns, err)
+			// the CRD is simply not installed on clusters without Flux
+			if apierrors.IsNotFound(err) {
+				return skipped(result.Resource, fmt.Sprintf("the HelmRelease API (%s) is not installed", helmReleaseGVR.GroupVersion())), nil
+			}
+			result.Namespaces = append(result.Namespaces, NamespaceResult{
+				Name:  ns,
+				Error: fmt.Sprintf("unable to list HelmReleases in %s: %v", ns, err),
+			})
 			continue
 		}
 
-		for _, hr := range releases.Items {
-			totalHelmReleases++
-			name := hr.GetName()
-
-			ready, reason := checkHelmReleaseWithGrace(hr, fluxGracePeriod)
-			results = append(results, struct {
-				Name   string
-				Ready  bool
-				Reason string
-			}{Name: name, Ready: ready, Reason: reason})
-
-			switch {
-			case reason == "HelmRelease is suspended":
-				totalSuspended++
-			case !ready:
-				failed++
+		result.Namespaces = append(result.Namespaces, NamespaceResult{Name: ns, Checked: len(list.Items)})
+
+		for _, hr := range list.Items {
+			releases = append(releases, hr)
+			// a suspended release will not change while we wait, so it never needs the grace period
+			if status := fluxReadiness(hr); !status.Ready && !status.Suspended {
+				pending = append(pending, hr)
 			}
 		}
 	}
 
-	logging.Info("Scan complete. Generating kobot health report.\n")
-	time.Sleep(3 * time.Second)
-
-	fmt.Println("=====================================================")
-	logging.Title("    Kobot HelmRelease Readiness Report\n")
-	fmt.Println("=====================================================\n")
-
-	fmt.Printf("Total HelmReleases checked: %d\n", totalHelmReleases)
-	fmt.Printf("Total Suspended HelmReleases: %d\n\n", totalSuspended)
-
-	for _, r := range results {
-		switch {
-		case r.Reason == "HelmRelease is suspended":
-			color.Yellow("WARN: %s (Suspended)", r.Name)
-		case r.Ready:
-			color.Green("PASS: %s", r.Name)
-		default:
-			color.Red("FAIL: %s (Reason: %s)", r.Name, r.Reason)
-			fmt.Print(logging.Kobot("HelmRelease remained unhealthy after waiting %ds to transition into Ready\n", fluxGracePeriod))
+	var refreshed map[string]unstructured.Unstructured
+	if len(pending) > 0 && env.FluxGrace > 0 {
+		logging.Info("Waiting up to %s for %d HelmRelease(s) to become Ready.", env.FluxGrace, len(pending))
+		refreshed = waitForHelmReleases(ctx, env.Dynamic, pending, env.FluxGrace)
+		for i, hr := range releases {
+			if latest, ok := refreshed[objectKey(hr)]; ok {
+				releases[i] = latest
+			}
 		}
 	}
 
-	fmt.Println()
-	if failed > 0 {
-		logging.Error("Scan completed with %d failing HelmReleases.", failed)
-		logging.Action("Operators should review failed HelmReleases and rerun kobot once reconciled.\n")
-	} else if totalSuspended > 0 {
-		logging.Warn("No failing HelmReleases were detected; however, one or more HelmReleases are currently suspended. An operator should investigate the reason for the suspension.\n")
-	} else {
-		logging.Success("All HelmReleases are in a Ready state. This reflects only the readiness condition of the HelmReleases themselves and does not confirm that the deployed services or pods are functioning correctly. To perform a full cluster health check, use the 'kobot check cluster' command.\n")
+	for _, hr := range releases {
+		if status := fluxReadiness(hr); !status.Ready {
+			// only releases that were polled actually waited for the grace period
+			var waited time.Duration
+			if _, ok := refreshed[objectKey(hr)]; ok {
+				waited = env.FluxGrace
+			}
+			result.Findings = append(result.Findings, helmReleaseFinding(hr, status, waited))
+		}
 	}
-}
 
-// isHelmReleaseReady checks the HelmRelease .status.conditions for Ready=True
-// and also ensures the resource is not suspended (.spec.suspend != true).
-func isHelmReleaseReady(obj unstructured.Unstructured) (bool, string) {
-	suspended, found, err := unstructured.NestedBool(obj.Object, "spec", "suspend")
-	if err == nil && found && suspended {
-		return false, "HelmRelease is suspended"
-	}
+	// releases blocked by a failing dependsOn entry are reported under the release that actually fails
+	graph := newDependencyGraph(releases, helmReleaseFetcher(ctx, env.Dynamic))
+	attributeRootCauses(result.Findings, graph)
 
-	conditions, found, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
-	if !found || err != nil {
-		return false, "no conditions found"
-	}
+	return result, nil
+}
 
-	for _, c := range conditions {
-		if cond, ok := c.(map[string]interface{}); ok {
-			t, _, _ := unstructured.NestedString(cond, "type")
-			s, _, _ := unstructured.NestedString(cond, "status")
-			r, _, _ := unstructured.NestedString(cond, "reason")
+// objectKey identifies a namespaced object as namespace/name.
+func objectKey(obj unstructured.Unstructured) string {
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
 
-			if t == "Ready" {
-				return s == "True", r
-			}
+// helmReleaseFinding turns a not-ready HelmRelease into a finding. Suspended
+// releases are a warning since an operator usually suspended them on purpose,
+// and so are releases helm-controller has not processed yet.
+// waited is how long the release was polled for, zero when it was not polled.
+func helmReleaseFinding(hr unstructured.Unstructured, status fluxStatus, waited time.Duration) Finding {
+	ref := helmReleaseRef(objectKey(hr))
+
+	if status.Suspended {
+		return Finding{
+			CheckID:  "helmreleases",
+			Severity: SeverityWarning,
+			Reason:   "Suspended",
+			Resource: ref,
+			Message:  "HelmRelease is suspended",
+			Action:   fmt.Sprintf("Confirm the suspension is intended, otherwise run 'flux resume helmrelease %s -n %s'.", ref.Name, ref.Namespace),
 		}
 	}
-	return false, "Ready condition missing"
+
+	f := Finding{
+		CheckID:  "helmreleases",
+		Severity: SeverityCritical,
+		Reason:   status.Reason,
+		Resource: ref,
+		Message:  fmt.Sprintf("HelmRelease is not Ready (Reason: %s)", status.Reason),
+		Action:   "Review the HelmRelease status with 'flux get helmreleases' and rerun kobot once reconciled.",
+	}
+	if waited > 0 {
+		f.Message = fmt.Sprintf("Not Ready after waiting %s for Flux to reconcile (Reason: %s)", waited, status.Reason)
+	}
+	// helm-controller has not picked the release up yet
+	if status.Pending {
+		f.Severity = SeverityWarning
+	}
+	if f.Reason == "" {
+		f.Reason = "NotReady"
+	}
+	if status.Message != "" {
+		f.Evidence = []string{status.Message}
+	}
+	return f
 }
 
-func checkHelmReleaseWithGrace(obj unstructured.Unstructured, fluxGracePeriod int) (bool, string) {
-	ready, reason := isHelmReleaseReady(obj)
-	if ready {
-		return true, reason
+// helmReleasePollInterval is how often a not-ready HelmRelease is re-fetched during the grace period.
+const helmReleasePollInterval = 2 * time.Second
+
+// waitForHelmReleases re-fetches every pending HelmRelease from the API until it
+// becomes Ready or the grace period runs out. Releases are polled concurrently
+// against one shared deadline, so the wait never exceeds the grace period no
+// matter how many releases are pending. It returns the latest copy of each release.
+func waitForHelmReleases(ctx context.Context, dynamicClient dynamic.Interface, pending []unstructured.Unstructured, grace time.Duration) map[string]unstructured.Unstructured {
+	ctx, cancel := context.WithTimeout(ctx, grace)
+	defer cancel()
+
+	// a grace period shorter than the poll interval still gets polled more than once
+	interval := min(helmReleasePollInterval, grace)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	sem := make(chan struct{}, 8) // bound the concurrent GETs so large namespaces don't trip client throttling
+	out := make(map[string]unstructured.Unstructured, len(pending))
+
+	for _, hr := range pending {
+		hr := hr
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			latest := pollHelmRelease(ctx, dynamicClient, hr, sem, interval)
+
+			mu.Lock()
+			out[objectKey(hr)] = latest
+			mu.Unlock()
+		}()
 	}
 
-	time.Sleep(time.Duration(fluxGracePeriod) * time.Second)
+	wg.Wait()
+	return out
+}
 
-	ready, reason = isHelmReleaseReady(obj)
-	if ready {
-		return true, reason
-	}
+// pollHelmRelease GETs a single HelmRelease right away and then every interval until
+// it is Ready, suspended or ctx is done, and returns the last copy it saw.
+func pollHelmRelease(ctx context.Context, dynamicClient dynamic.Interface, hr unstructured.Unstructured, sem chan struct{}, interval time.Duration) unstructured.Unstructured {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	latest := hr
+	for {
+		select {
+		case <-ctx.Done():
+			return latest
+		case sem <- struct{}{}:
+		}
+		current, err := dynamicClient.Resource(helmReleaseGVR).Namespace(hr.GetNamespace()).Get(ctx, hr.GetName(), metav1.GetOptions{})
+		<-sem
+		// transient errors keep the last known state; the next tick tries again
+		if err == nil {
+			latest = *current
+		}
 
-	return false, reason
+		if status := fluxReadiness(latest); status.Ready || status.Suspended {
+			return latest
+		}
+
+		select {
+		case <-ctx.Done():
+			return latest
+		case <-ticker.C:
+		}
+	}
 }
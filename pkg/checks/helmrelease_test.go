@@ -0,0 +1,111 @@
+package checks
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// testHelmReleaseClient returns a fake dynamic client serving releases whose GETs
+// answer with the Ready copy of every release named in becomeReady, and counts them.
+func testHelmReleaseClient(releases []unstructured.Unstructured, becomeReady ...string) (*dynamicfake.FakeDynamicClient, *atomic.Int32) {
+	objs := make([]runtime.Object, 0, len(releases))
+	for i := range releases {
+		objs = append(objs, &releases[i])
+	}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{helmReleaseGVR: "HelmReleaseList"}, objs...)
+
+	gets := &atomic.Int32{}
+	client.PrependReactor("get", "helmreleases", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		gets.Add(1)
+		get := action.(k8stesting.GetAction)
+		key := get.GetNamespace() + "/" + get.GetName()
+		for _, ready := range becomeReady {
+			if ready == key {
+				hr := testHelmRelease(key, "True", "ReconciliationSucceeded", false)
+				return true, &hr, nil
+			}
+		}
+		return false, nil, nil
+	})
+	return client, gets
+}
+
+func TestWaitForHelmReleases(t *testing.T) {
+	pending := []unstructured.Unstructured{
+		testHelmRelease("apps/web", "False", "InstallFailed", false),
+		testHelmRelease("apps/db", "False", "InstallFailed", false),
+	}
+	client, gets := testHelmReleaseClient(pending, "apps/web")
+
+	// a grace period shorter than the poll interval still fetches every release
+	grace := 300 * time.Millisecond
+	started := time.Now()
+	got := waitForHelmReleases(context.Background(), client, pending, grace)
+	if elapsed := time.Since(started); elapsed > grace+time.Second {
+		t.Errorf("waitForHelmReleases() took %s, want it bounded by the %s grace period", elapsed, grace)
+	}
+
+	if status := fluxReadiness(got["apps/web"]); !status.Ready {
+		t.Errorf("apps/web = %+v, want the Ready copy fetched during the grace period", status)
+	}
+	if status := fluxReadiness(got["apps/db"]); status.Ready || status.Reason != "InstallFailed" {
+		t.Errorf("apps/db = %+v, want it still failing", status)
+	}
+	if n := gets.Load(); n < 2 {
+		t.Errorf("GETs = %d, want every release fetched at least once", n)
+	}
+}
+
+func TestPollHelmReleaseHonoursContextWhileWaitingForSemaphore(t *testing.T) {
+	hr := testHelmRelease("apps/web", "False", "InstallFailed", false)
+	client, gets := testHelmReleaseClient([]unstructured.Unstructured{hr}, "apps/web")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	sem := make(chan struct{}, 1)
+	sem <- struct{}{} // every slot is taken
+
+	done := make(chan unstructured.Unstructured)
+	go func() { done <- pollHelmRelease(ctx, client, hr, sem, time.Second) }()
+
+	select {
+	case latest := <-done:
+		if fluxReadiness(latest).Ready || gets.Load() != 0 {
+			t.Errorf("pollHelmRelease() fetched the release without a semaphore slot")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("pollHelmRelease() kept waiting for the semaphore after ctx was done")
+	}
+}
+
+func TestHelmReleaseCheckGracePeriod(t *testing.T) {
+	releases := []unstructured.Unstructured{
+		testHelmRelease("apps/web", "False", "Progressing", false),
+		testHelmRelease("apps/db", "False", "InstallFailed", false),
+		testHelmRelease("apps/cache", "True", "ReconciliationSucceeded", false),
+	}
+	client, _ := testHelmReleaseClient(releases, "apps/web")
+	env := &Env{Dynamic: client, Namespaces: []string{"apps"}, FluxGrace: 200 * time.Millisecond}
+
+	result, err := helmReleaseCheck{}.Run(context.Background(), env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Findings) != 1 {
+		t.Fatalf("findings = %+v, want only apps/db", result.Findings)
+	}
+	f := result.Findings[0]
+	if f.Resource.Name != "db" || f.Reason != "InstallFailed" || !strings.HasPrefix(f.Message, "Not Ready after waiting 200ms") {
+		t.Errorf("finding = %+v, want apps/db not Ready after waiting", f)
+	}
+}
@@ -0,0 +1,398 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gitlab.com/kobot/kobot/pkg/logging"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func init() {
+	Register(istioCheck{})
+}
+
+// Istio networking kinds, read through the dynamic client since they are CRDs.
+var (
+	virtualServiceKind  = apiKind{Kind: "VirtualService", Group: "networking.istio.io", Resource: "virtualservices", Versions: []string{"v1", "v1beta1", "v1alpha3"}}
+	destinationRuleKind = apiKind{Kind: "DestinationRule", Group: "networking.istio.io", Resource: "destinationrules", Versions: []string{"v1", "v1beta1", "v1alpha3"}}
+	serviceEntryKind    = apiKind{Kind: "ServiceEntry", Group: "networking.istio.io", Resource: "serviceentries", Versions: []string{"v1", "v1beta1", "v1alpha3"}}
+)
+
+const (
+	// istioProxyContainer is the name of the injected sidecar container.
+	istioProxyContainer = "istio-proxy"
+	// istioRevisionLabel selects a control-plane revision on namespaces, pods and istiod itself.
+	istioRevisionLabel = "istio.io/rev"
+	// istioDefaultRevision is the revision of an istiod installed without --revision.
+	istioDefaultRevision = "default"
+)
+
+// istioCheck reports mesh problems: pods in injection-enabled namespaces running
+// without the istio-proxy sidecar or with a proxy version other than their
+// control plane's, VirtualServices and DestinationRules routing to hosts that do
+// not exist, and istiod or Istio gateway Deployments that are not available.
+type istioCheck struct{}
+
+func (istioCheck) Name() string { return "istio" }
+
+func (istioCheck) Description() string {
+	return "Istio sidecar injection and versions, VirtualService and DestinationRule hosts, istiod and gateway availability"
+}
+
+func (istioCheck) RequiredClients() []Client { return []Client{KubeClient, DynamicClient} }
+
+func (istioCheck) Run(ctx context.Context, env *Env) (*Result, error) {
+	logging.Info("Scanning the Istio mesh across %d namespace(s).", len(env.Namespaces))
+	logging.Starting("Operator-initiated Istio mesh health check")
+
+	listCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	// istiod carries istio=pilot, the classic ingress and egress gateways istio=<name>gateway
+	deployments, err := env.Clientset.AppsV1().Deployments(metav1.NamespaceAll).List(listCtx, metav1.ListOptions{LabelSelector: "istio"})
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list Istio Deployments: %w", err)
+	}
+
+	revisions := make(map[string]string) // revision -> proxy version
+	for _, d := range deployments.Items {
+		if d.Labels["istio"] == "pilot" {
+			revisions[istiodRevision(d)] = istiodVersion(d)
+		}
+	}
+	if len(revisions) == 0 {
+		return skipped("mesh objects", "no Istio control plane (istiod) is installed"), nil
+	}
+
+	result := &Result{Resource: "mesh objects"}
+	lister := newAPILister(env.Dynamic)
+	owners := newOwnerResolver(env.Clientset)
+	hosts := newMeshHosts(ctx, env, lister)
+
+	meshDeployments := make(map[string][]appsv1.Deployment)
+	for _, d := range deployments.Items {
+		meshDeployments[d.Namespace] = append(meshDeployments[d.Namespace], d)
+	}
+
+	for _, ns := range env.Namespaces {
+		logging.Running("Scan job on namespace: %s", ns)
+		nsResult := NamespaceResult{Name: ns}
+		var errs []string
+
+		for _, d := range meshDeployments[ns] {
+			nsResult.Checked++
+			result.Findings = append(result.Findings, inspectMeshDeployment(d)...)
+		}
+
+		nsCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		if revision, enabled, err := injectionRevision(nsCtx, env, ns); err != nil {
+			errs = append(errs, fmt.Sprintf("unable to read namespace: %v", err))
+		} else if enabled {
+			if pods, err := env.Clientset.CoreV1().Pods(ns).List(nsCtx, metav1.ListOptions{}); err != nil {
+				errs = append(errs, fmt.Sprintf("unable to list pods: %v", err))
+			} else {
+				nsResult.Checked += len(pods.Items)
+				for _, pod := range pods.Items {
+					findings := inspectMeshPod(pod, revision, revisions)
+					setPodOwners(nsCtx, owners, pod, findings)
+					result.Findings = append(result.Findings, findings...)
+				}
+			}
+		}
+
+		for _, kind := range []apiKind{virtualServiceKind, destinationRuleKind} {
+			items, err := lister.list(nsCtx, kind, ns)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("unable to list %ss: %v", kind.Kind, err))
+				continue
+			}
+			nsResult.Checked += len(items)
+			for _, obj := range items {
+				if kind.Kind == "VirtualService" {
+					result.Findings = append(result.Findings, inspectVirtualService(obj, hosts)...)
+				} else {
+					result.Findings = append(result.Findings, inspectDestinationRule(obj, hosts)...)
+				}
+			}
+		}
+		cancel()
+
+		if hosts.err != nil {
+			errs = append(errs, hosts.err.Error())
+			hosts.err = nil
+		}
+		nsResult.Error = strings.Join(errs, "; ")
+		result.Namespaces = append(result.Namespaces, nsResult)
+	}
+
+	return result, nil
+}
+
+// istiodRevision returns the control-plane revision an istiod Deployment serves.
+func istiodRevision(d appsv1.Deployment) string {
+	if rev := d.Labels[istioRevisionLabel]; rev != "" {
+		return rev
+	}
+	return istioDefaultRevision
+}
+
+// istiodVersion returns the Istio version of an istiod Deployment, taken from its image tag.
+func istiodVersion(d appsv1.Deployment) string {
+	for _, c := range d.Spec.Template.Spec.Containers {
+		if c.Name == "discovery" {
+			return imageVersion(c.Image)
+		}
+	}
+	return ""
+}
+
+// imageVersion returns the tag of a container image without variant suffixes
+// such as -distroless, or "" when the image is pinned by digest only.
+func imageVersion(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	i := strings.LastIndex(image, ":")
+	if i < 0 || i < strings.LastIndex(image, "/") {
+		return ""
+	}
+	tag := image[i+1:]
+	for _, variant := range []string{"-distroless", "-debug"} {
+		tag = strings.TrimSuffix(tag, variant)
+	}
+	return tag
+}
+
+// injectionRevision reports whether sidecar injection is enabled for a namespace and for which revision.
+func injectionRevision(ctx context.Context, env *Env, ns string) (string, bool, error) {
+	namespace, err := env.Clientset.CoreV1().Namespaces().Get(ctx, ns, metav1.GetOptions{})
+	if err != nil {
+		return "", false, err
+	}
+	switch injection := namespace.Labels["istio-injection"]; {
+	case injection == "disabled":
+		return "", false, nil
+	case namespace.Labels[istioRevisionLabel] != "":
+		return namespace.Labels[istioRevisionLabel], true, nil
+	case injection == "enabled":
+		return istioDefaultRevision, true, nil
+	}
+	return "", false, nil
+}
+
+// inspectMeshDeployment reports istiod and gateway Deployments without available replicas.
+func inspectMeshDeployment(d appsv1.Deployment) []Finding {
+	b := &findingBuilder{check: "istio", ref: workloadRef("Deployment", d.Namespace, d.Name)}
+
+	desired := int32(1)
+	if d.Spec.Replicas != nil {
+		desired = *d.Spec.Replicas
+	}
+	if desired == 0 || d.Status.AvailableReplicas >= desired {
+		return nil
+	}
+
+	evidence := fmt.Sprintf("%d/%d replicas available", d.Status.AvailableReplicas, desired)
+	rollout := fmt.Sprintf("kubectl rollout status deployment/%s -n %s", d.Name, d.Namespace)
+	if d.Labels["istio"] == "pilot" {
+		b.add(replicaSeverity(d.Status.AvailableReplicas), "ControlPlaneUnavailable",
+			fmt.Sprintf("istiod (revision %s) is not fully available", istiodRevision(d)),
+			fmt.Sprintf("Without istiod new pods are not injected and proxies get no configuration updates; check '%s'.", rollout),
+			evidence)
+		return b.findings
+	}
+	b.add(replicaSeverity(d.Status.AvailableReplicas), "GatewayUnavailable",
+		fmt.Sprintf("Istio gateway %s is not fully available", d.Labels["istio"]),
+		fmt.Sprintf("Traffic through this gateway is degraded; check '%s'.", rollout),
+		evidence)
+	return b.findings
+}
+
+// inspectMeshPod checks that a pod of an injection-enabled namespace runs the
+// istio-proxy sidecar at the version of the control plane it is injected by.
+func inspectMeshPod(pod v1.Pod, namespaceRevision string, revisions map[string]string) []Finding {
+	if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed || pod.Spec.HostNetwork {
+		return nil
+	}
+	// gateways run istio-proxy as their main container and are covered by inspectMeshDeployment
+	if pod.Labels["istio"] != "" {
+		return nil
+	}
+
+	b := &findingBuilder{check: "istio", ref: podRef(pod.Namespace, pod.Name)}
+
+	var proxy *v1.Container
+	// native sidecars (Kubernetes 1.29+) are injected as restartable init containers
+	for _, list := range [][]v1.Container{pod.Spec.Containers, pod.Spec.InitContainers} {
+		for i := range list {
+			if list[i].Name == istioProxyContainer {
+				proxy = &list[i]
+			}
+		}
+	}
+
+	if proxy == nil {
+		if pod.Annotations["sidecar.istio.io/inject"] == "false" || pod.Labels["sidecar.istio.io/inject"] == "false" {
+			return nil
+		}
+		b.add(SeverityWarning, "SidecarMissing", "Pod runs without the istio-proxy sidecar although injection is enabled",
+			"The pod started before injection was enabled or while istiod was unavailable; restart its workload with 'kubectl rollout restart'.")
+		return b.findings
+	}
+
+	revision := namespaceRevision
+	if rev := pod.Labels[istioRevisionLabel]; rev != "" {
+		revision = rev
+	}
+	want, ok := revisions[revision]
+	if !ok {
+		b.add(SeverityWarning, "RevisionNotFound", fmt.Sprintf("Sidecar belongs to control-plane revision %q, which is not installed", revision),
+			fmt.Sprintf("Relabel the namespace with an installed revision (%s) and restart the workload.", installedRevisions(revisions)))
+		return b.findings
+	}
+
+	if got := imageVersion(proxy.Image); got != "" && want != "" && got != want {
+		b.add(SeverityWarning, "SidecarVersionSkew", fmt.Sprintf("Sidecar runs Istio %s, control plane %s runs %s", got, revision, want),
+			"Restart the workload with 'kubectl rollout restart' to pick up the current proxy after an Istio upgrade.",
+			"proxy image "+proxy.Image)
+	}
+	return b.findings
+}
+
+// installedRevisions lists the control-plane revisions found on the cluster.
+func installedRevisions(revisions map[string]string) string {
+	names := make([]string, 0, len(revisions))
+	for rev := range revisions {
+		names = append(names, rev)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// meshHosts resolves service hosts the way Istio does, against the Services of
+// the cluster and the hosts declared by ServiceEntries. Both are loaded on first use.
+type meshHosts struct {
+	ctx    context.Context
+	env    *Env
+	lister *apiLister
+	loaded bool
+	// services holds namespace/name of every Service.
+	services map[string]bool
+	// entries holds the hosts of every ServiceEntry, possibly with a leading wildcard.
+	entries []string
+	err     error
+}
+
+func newMeshHosts(ctx context.Context, env *Env, lister *apiLister) *meshHosts {
+	return &meshHosts{ctx: ctx, env: env, lister: lister}
+}
+
+func (h *meshHosts) load() {
+	h.loaded = true
+	ctx, cancel := context.WithTimeout(h.ctx, 30*time.Second)
+	defer cancel()
+
+	services, err := h.env.Clientset.CoreV1().Services(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		h.services, h.err = nil, fmt.Errorf("unable to list Services to resolve mesh hosts: %w", err)
+		return
+	}
+	h.services = make(map[string]bool, len(services.Items))
+	for _, svc := range services.Items {
+		h.services[svc.Namespace+"/"+svc.Name] = true
+	}
+
+	entries, err := h.lister.list(ctx, serviceEntryKind, metav1.NamespaceAll)
+	if err != nil {
+		h.err = fmt.Errorf("unable to list ServiceEntries to resolve mesh hosts: %w", err)
+	}
+	for _, se := range entries {
+		declared, _, _ := unstructured.NestedStringSlice(se.Object, "spec", "hosts")
+		h.entries = append(h.entries, declared...)
+	}
+}
+
+// exists reports whether a host referenced from namespace ns resolves. Short names are
+// relative to ns; known is false when the registry could not be loaded.
+func (h *meshHosts) exists(host, ns string) (found, known bool) {
+	if !h.loaded {
+		h.load()
+	}
+	if h.services == nil {
+		return false, false
+	}
+	if strings.Contains(host, "*") {
+		return true, true
+	}
+
+	if !strings.Contains(host, ".") {
+		return h.services[ns+"/"+host], true
+	}
+	// <name>.<namespace>.svc.<cluster domain>
+	if parts := strings.SplitN(host, ".", 4); len(parts) == 4 && parts[2] == "svc" && h.services[parts[1]+"/"+parts[0]] {
+		return true, true
+	}
+	for _, entry := range h.entries {
+		if entry == host || (strings.HasPrefix(entry, "*.") && strings.HasSuffix(host, entry[1:])) {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+// inspectVirtualService reports route destinations whose host is neither a Service nor a ServiceEntry.
+func inspectVirtualService(vs unstructured.Unstructured, hosts *meshHosts) []Finding {
+	b := &findingBuilder{check: "istio", ref: customResourceRef(vs)}
+
+	seen := make(map[string]bool)
+	for _, protocol := range []string{"http", "tcp", "tls"} {
+		routes, _, _ := unstructured.NestedSlice(vs.Object, "spec", protocol)
+		for _, r := range routes {
+			route, ok := r.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			destinations, _, _ := unstructured.NestedSlice(route, "route")
+			if mirror, found, _ := unstructured.NestedMap(route, "mirror"); found {
+				destinations = append(destinations, map[string]interface{}{"destination": mirror})
+			}
+			for _, d := range destinations {
+				dest, ok := d.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				host, _, _ := unstructured.NestedString(dest, "destination", "host")
+				if host == "" || seen[host] {
+					continue
+				}
+				seen[host] = true
+				if found, known := hosts.exists(host, vs.GetNamespace()); known && !found {
+					b.add(SeverityCritical, "HostNotFound", fmt.Sprintf("Route destination %s matches no Service or ServiceEntry", host),
+						"Requests routed there fail with 503; fix the host (names with dots must be fully qualified) or add a ServiceEntry.")
+				}
+			}
+		}
+	}
+	return b.findings
+}
+
+// inspectDestinationRule reports DestinationRules whose host matches nothing, so their policy never applies.
+func inspectDestinationRule(dr unstructured.Unstructured, hosts *meshHosts) []Finding {
+	b := &findingBuilder{check: "istio", ref: customResourceRef(dr)}
+
+	host, _, _ := unstructured.NestedString(dr.Object, "spec", "host")
+	if host == "" {
+		return nil
+	}
+	if found, known := hosts.exists(host, dr.GetNamespace()); known && !found {
+		b.add(SeverityWarning, "HostNotFound", fmt.Sprintf("Host %s matches no Service or ServiceEntry", host),
+			"The traffic policy of this rule never applies; fix the host (names with dots must be fully qualified).")
+	}
+	return b.findings
+}
@@ -0,0 +1,174 @@
+package checks
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestImageVersion(t *testing.T) {
+	tests := map[string]string{
+		"docker.io/istio/proxyv2:1.22.3":                             "1.22.3",
+		"registry1.dso.mil/ironbank/istio/proxyv2:1.22.3-distroless": "1.22.3",
+		"localhost:5000/istio/pilot:1.21.0-debug":                    "1.21.0",
+		"localhost:5000/istio/pilot":                                 "",
+		"istio/proxyv2@sha256:0123abcd":                              "",
+		"istio/proxyv2:1.22.3@sha256:0123abcd":                       "1.22.3",
+	}
+	for image, want := range tests {
+		if got := imageVersion(image); got != want {
+			t.Errorf("imageVersion(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
+
+func TestInspectMeshPod(t *testing.T) {
+	revisions := map[string]string{"default": "1.22.3", "1-23": "1.23.0"}
+	pod := func(labels, annotations map[string]string, containers ...v1.Container) v1.Pod {
+		return v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web", Labels: labels, Annotations: annotations},
+			Spec:       v1.PodSpec{Containers: append([]v1.Container{{Name: "app", Image: "shop/web:2.0"}}, containers...)},
+			Status:     v1.PodStatus{Phase: v1.PodRunning},
+		}
+	}
+	proxy := func(version string) v1.Container {
+		return v1.Container{Name: istioProxyContainer, Image: "docker.io/istio/proxyv2:" + version}
+	}
+	nativeSidecar := pod(nil, nil)
+	nativeSidecar.Spec.InitContainers = []v1.Container{proxy("1.22.3")}
+	completed := pod(nil, nil)
+	completed.Status.Phase = v1.PodSucceeded
+
+	tests := []struct {
+		name     string
+		pod      v1.Pod
+		revision string
+		want     []string
+	}{
+		{name: "current sidecar", pod: pod(nil, nil, proxy("1.22.3")), revision: "default"},
+		{name: "native sidecar", pod: nativeSidecar, revision: "default"},
+		{name: "missing sidecar", pod: pod(nil, nil), revision: "default", want: []string{"warning/SidecarMissing"}},
+		{name: "injection disabled on the pod", pod: pod(nil, map[string]string{"sidecar.istio.io/inject": "false"}), revision: "default"},
+		{name: "completed pod", pod: completed, revision: "default"},
+		{name: "gateway pod", pod: pod(map[string]string{"istio": "ingressgateway"}, nil), revision: "default"},
+		{name: "old proxy", pod: pod(nil, nil, proxy("1.21.0")), revision: "default", want: []string{"warning/SidecarVersionSkew"}},
+		{name: "pod revision label wins", pod: pod(map[string]string{istioRevisionLabel: "1-23"}, nil, proxy("1.23.0")), revision: "default"},
+		{name: "revision not installed", pod: pod(nil, nil, proxy("1.20.0")), revision: "1-20", want: []string{"warning/RevisionNotFound"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findingReasons(inspectMeshPod(tt.pod, tt.revision, revisions)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("inspectMeshPod() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInspectMeshDeployment(t *testing.T) {
+	two := int32(2)
+	tests := []struct {
+		name      string
+		labels    map[string]string
+		available int32
+		want      []string
+	}{
+		{name: "available istiod", labels: map[string]string{"istio": "pilot"}, available: 2},
+		{name: "istiod down", labels: map[string]string{"istio": "pilot"}, want: []string{"critical/ControlPlaneUnavailable"}},
+		{name: "degraded gateway", labels: map[string]string{"istio": "ingressgateway"}, available: 1, want: []string{"warning/GatewayUnavailable"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "istio-system", Name: "istiod", Labels: tt.labels},
+				Spec:       appsv1.DeploymentSpec{Replicas: &two},
+				Status:     appsv1.DeploymentStatus{AvailableReplicas: tt.available},
+			}
+			if got := findingReasons(inspectMeshDeployment(d)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("inspectMeshDeployment() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMeshHostsExists(t *testing.T) {
+	hosts := &meshHosts{
+		loaded:   true,
+		services: map[string]bool{"shop/web": true, "db/postgres": true},
+		entries:  []string{"api.stripe.com", "*.googleapis.com"},
+	}
+	tests := []struct {
+		host  string
+		found bool
+	}{
+		{"web", true},
+		{"postgres", false},
+		{"postgres.db.svc.cluster.local", true},
+		{"web.db.svc.cluster.local", false},
+		{"web.shop", false}, // names with dots must be fully qualified
+		{"api.stripe.com", true},
+		{"storage.googleapis.com", true},
+		{"example.com", false},
+		{"*.example.com", true},
+	}
+	for _, tt := range tests {
+		if found, known := hosts.exists(tt.host, "shop"); found != tt.found || !known {
+			t.Errorf("exists(%q) = %v, %v, want %v, true", tt.host, found, known, tt.found)
+		}
+	}
+
+	unknown := &meshHosts{loaded: true}
+	if _, known := unknown.exists("web", "shop"); known {
+		t.Error("exists() without a loaded registry is known")
+	}
+}
+
+func TestInspectVirtualService(t *testing.T) {
+	hosts := &meshHosts{loaded: true, services: map[string]bool{"shop/web": true}}
+	destination := func(host string) interface{} {
+		return map[string]interface{}{"destination": map[string]interface{}{"host": host}}
+	}
+	vs := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "networking.istio.io/v1",
+		"kind":       "VirtualService",
+		"metadata":   map[string]interface{}{"namespace": "shop", "name": "web"},
+		"spec": map[string]interface{}{
+			"http": []interface{}{
+				map[string]interface{}{"route": []interface{}{destination("web"), destination("web-canary")}},
+				map[string]interface{}{"route": []interface{}{destination("web-canary")}, "mirror": map[string]interface{}{"host": "shadow"}},
+			},
+		},
+	}}
+
+	got := inspectVirtualService(vs, hosts)
+	if reasons := findingReasons(got); !reflect.DeepEqual(reasons, []string{"critical/HostNotFound", "critical/HostNotFound"}) {
+		t.Fatalf("inspectVirtualService() = %v, want web-canary and shadow once each", reasons)
+	}
+
+	dr := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "networking.istio.io/v1",
+		"kind":       "DestinationRule",
+		"metadata":   map[string]interface{}{"namespace": "shop", "name": "web"},
+		"spec":       map[string]interface{}{"host": "web.shop"},
+	}}
+	if reasons := findingReasons(inspectDestinationRule(dr, hosts)); !reflect.DeepEqual(reasons, []string{"warning/HostNotFound"}) {
+		t.Errorf("inspectDestinationRule() = %v, want one HostNotFound", reasons)
+	}
+}
+
+func TestIstioCheckSkipsWithoutIstiod(t *testing.T) {
+	env := &Env{Clientset: fake.NewSimpleClientset(), Namespaces: []string{"shop"}}
+	result, err := istioCheck{}.Run(context.Background(), env)
+	if err != nil {
+		t.Fatalf("Run() error = %v, want the check skipped", err)
+	}
+	if result.Skipped == "" || ScanErrors([]*Result{result}) != 0 {
+		t.Errorf("Run() = %+v, want a skipped result without scan errors", result)
+	}
+}
@@ -0,0 +1,233 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"gitlab.com/kobot/kobot/pkg/logging"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func init() {
+	Register(jobCheck{})
+}
+
+// jobStuckAfter is how long a Job without history or schedule to compare against may
+// stay active before it is reported as stuck.
+const jobStuckAfter = 6 * time.Hour
+
+// jobStuckFactor is how many times its longest successful sibling a Job may run before it is reported as stuck.
+const jobStuckFactor = 3
+
+// jobCheck reports failed and stuck Jobs and CronJobs that are suspended or have
+// not succeeded as recently as their schedule implies.
+type jobCheck struct{}
+
+func (jobCheck) Name() string { return "jobs" }
+
+func (jobCheck) Description() string {
+	return "Failed and stuck Jobs, suspended CronJobs and CronJobs missing their schedule"
+}
+
+func (jobCheck) RequiredClients() []Client { return []Client{KubeClient} }
+
+func (jobCheck) Run(ctx context.Context, env *Env) (*Result, error) {
+	logging.Info("Scanning Jobs and CronJobs across %d namespace(s).", len(env.Namespaces))
+	logging.Starting("Operator-initiated Job and CronJob check")
+
+	result := &Result{Resource: "jobs"}
+	batch := env.Clientset.BatchV1()
+	now := time.Now()
+
+	for _, ns := range env.Namespaces {
+		logging.Running("Scan job on namespace: %s", ns)
+
+		nsCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		nsResult := NamespaceResult{Name: ns}
+		var errs []string
+
+		cronJobs := make(map[string]batchv1.CronJob)
+		if list, err := batch.CronJobs(ns).List(nsCtx, metav1.ListOptions{}); err != nil {
+			errs = append(errs, fmt.Sprintf("unable to list CronJobs: %v", err))
+		} else {
+			nsResult.Checked += len(list.Items)
+			for _, cj := range list.Items {
+				cronJobs[cj.Name] = cj
+				result.Findings = append(result.Findings, inspectCronJob(cj, now)...)
+			}
+		}
+
+		if list, err := batch.Jobs(ns).List(nsCtx, metav1.ListOptions{}); err != nil {
+			errs = append(errs, fmt.Sprintf("unable to list Jobs: %v", err))
+		} else {
+			nsResult.Checked += len(list.Items)
+			history := successfulDurations(list.Items)
+			for _, job := range list.Items {
+				var parent *batchv1.CronJob
+				if owner := controllerRef(ns, job.OwnerReferences); owner != nil && owner.Kind == "CronJob" {
+					if cj, ok := cronJobs[owner.Name]; ok {
+						parent = &cj
+					}
+				}
+				result.Findings = append(result.Findings, inspectJob(job, parent, history, now)...)
+			}
+		}
+		cancel()
+
+		nsResult.Error = strings.Join(errs, "; ")
+		result.Namespaces = append(result.Namespaces, nsResult)
+	}
+
+	return result, nil
+}
+
+// batchRef returns the ResourceRef for a batch/v1 object.
+func batchRef(kind, namespace, name string) ResourceRef {
+	return ResourceRef{Group: "batch", Version: "v1", Kind: kind, Namespace: namespace, Name: name}
+}
+
+// jobCondition returns the condition of the given type if it is True.
+func jobCondition(job batchv1.Job, condType batchv1.JobConditionType) *batchv1.JobCondition {
+	for i, cond := range job.Status.Conditions {
+		if cond.Type == condType && cond.Status == v1.ConditionTrue {
+			return &job.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// successfulDurations returns the longest successful run per owning CronJob, used as the expected duration of its Jobs.
+func successfulDurations(jobs []batchv1.Job) map[string]time.Duration {
+	out := make(map[string]time.Duration)
+	for _, job := range jobs {
+		owner := controllerRef(job.Namespace, job.OwnerReferences)
+		if owner == nil || job.Status.StartTime == nil || job.Status.CompletionTime == nil {
+			continue
+		}
+		if d := job.Status.CompletionTime.Sub(job.Status.StartTime.Time); d > out[owner.Name] {
+			out[owner.Name] = d
+		}
+	}
+	return out
+}
+
+// inspectJob reports a failed Job, unless a later run of its CronJob succeeded,
+// and a Job that has been active far longer than its runs usually take.
+func inspectJob(job batchv1.Job, parent *batchv1.CronJob, history map[string]time.Duration, now time.Time) []Finding {
+	b := &findingBuilder{check: "jobs", ref: batchRef("Job", job.Namespace, job.Name)}
+	if parent != nil {
+		owner := batchRef("CronJob", parent.Namespace, parent.Name)
+		b.owner = &owner
+	}
+	describe := fmt.Sprintf("kubectl describe job %s -n %s", job.Name, job.Namespace)
+
+	if failed := jobCondition(job, batchv1.JobFailed); failed != nil {
+		// failed runs kept by failedJobsHistoryLimit stop mattering once the CronJob succeeded again
+		if parent != nil && parent.Status.LastSuccessfulTime != nil && parent.Status.LastSuccessfulTime.After(failed.LastTransitionTime.Time) {
+			return nil
+		}
+
+		action := fmt.Sprintf("Inspect the failed pods with '%s' and their logs, then rerun the Job once fixed.", describe)
+		switch failed.Reason {
+		case "DeadlineExceeded":
+			action = "The Job ran longer than its activeDeadlineSeconds; check why the workload is slow or raise the deadline."
+		case "BackoffLimitExceeded":
+			action = fmt.Sprintf("Every retry failed; inspect the pod logs with 'kubectl logs job/%s -n %s' before raising backoffLimit.", job.Name, job.Namespace)
+		}
+		reason := failed.Reason
+		if reason == "" {
+			reason = "JobFailed"
+		}
+		b.add(SeverityCritical, reason,
+			fmt.Sprintf("Job failed (%d failed, %d succeeded pod(s))", job.Status.Failed, job.Status.Succeeded),
+			action, failed.Message)
+		return b.findings
+	}
+
+	if job.Status.Active == 0 || job.Status.StartTime == nil || jobCondition(job, batchv1.JobComplete) != nil {
+		return b.findings
+	}
+
+	running := now.Sub(job.Status.StartTime.Time)
+	expected, basis := jobStuckAfter, "default threshold"
+	if parent != nil {
+		if longest, ok := history[parent.Name]; ok && longest > 0 {
+			expected, basis = longest*jobStuckFactor, fmt.Sprintf("%dx the longest successful run (%s)", jobStuckFactor, longest.Round(time.Second))
+		} else if interval, err := scheduleInterval(*parent, job.Status.StartTime.Time); err == nil {
+			expected, basis = interval, "the schedule interval"
+		}
+	}
+	if running > expected {
+		b.add(SeverityWarning, "JobStuck",
+			fmt.Sprintf("Job has been active for %s (expected at most %s)", running.Round(time.Minute), expected.Round(time.Minute)),
+			fmt.Sprintf("Check whether the pods are making progress with '%s'; set activeDeadlineSeconds so hung runs fail on their own.", describe),
+			fmt.Sprintf("expected duration based on %s", basis),
+			fmt.Sprintf("%d active pod(s), started %s", job.Status.Active, job.Status.StartTime.Format(time.RFC3339)))
+	}
+	return b.findings
+}
+
+// cronSchedule parses the schedule of a CronJob, honoring spec.timeZone.
+func cronSchedule(cj batchv1.CronJob) (cron.Schedule, error) {
+	spec := cj.Spec.Schedule
+	if cj.Spec.TimeZone != nil && *cj.Spec.TimeZone != "" {
+		spec = "CRON_TZ=" + *cj.Spec.TimeZone + " " + spec
+	}
+	return cron.ParseStandard(spec)
+}
+
+// scheduleInterval returns the time between the first two runs after from.
+func scheduleInterval(cj batchv1.CronJob, from time.Time) (time.Duration, error) {
+	sched, err := cronSchedule(cj)
+	if err != nil {
+		return 0, err
+	}
+	next := sched.Next(from)
+	return sched.Next(next).Sub(next), nil
+}
+
+// inspectCronJob reports suspended CronJobs, invalid schedules and CronJobs whose
+// last success is older than the schedule allows. A run is missed once the run
+// after it is due too: the first scheduled time after the last success has had a
+// whole interval to complete.
+func inspectCronJob(cj batchv1.CronJob, now time.Time) []Finding {
+	b := &findingBuilder{check: "jobs", ref: batchRef("CronJob", cj.Namespace, cj.Name)}
+
+	if cj.Spec.Suspend != nil && *cj.Spec.Suspend {
+		b.add(SeverityWarning, "Suspended", "CronJob is suspended and will not schedule new Jobs",
+			"Confirm the suspension is intended, otherwise set spec.suspend to false.")
+		return b.findings
+	}
+
+	sched, err := cronSchedule(cj)
+	if err != nil {
+		b.add(SeverityWarning, "InvalidSchedule", fmt.Sprintf("Schedule %q cannot be parsed", cj.Spec.Schedule),
+			"Fix spec.schedule; kobot cannot tell whether this CronJob runs on time.", err.Error())
+		return b.findings
+	}
+
+	since, what := cj.CreationTimestamp.Time, "never succeeded"
+	if last := cj.Status.LastSuccessfulTime; last != nil {
+		since, what = last.Time, "last success "+last.Format(time.RFC3339)
+	}
+
+	missed := sched.Next(since)
+	if due := sched.Next(missed); due.After(now) {
+		return b.findings
+	}
+
+	evidence := []string{what, "schedule " + cj.Spec.Schedule}
+	if cj.Status.LastScheduleTime != nil {
+		evidence = append(evidence, "last scheduled "+cj.Status.LastScheduleTime.Format(time.RFC3339))
+	}
+	b.add(SeverityCritical, "ScheduleMissed",
+		fmt.Sprintf("No successful run since the one due at %s", missed.Format(time.RFC3339)),
+		fmt.Sprintf("Check the recent Jobs with 'kubectl get jobs -n %s' and the CronJob events with 'kubectl describe cronjob %s -n %s'.", cj.Namespace, cj.Name, cj.Namespace),
+		evidence...)
+	return b.findings
+}
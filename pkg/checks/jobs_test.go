@@ -0,0 +1,82 @@
+package checks
+
+import (
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestInspectCronJob(t *testing.T) {
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(s string) *metav1.Time {
+		ts, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &metav1.Time{Time: ts}
+	}
+	utc, berlin := "UTC", "Europe/Berlin"
+	yes := true
+
+	tests := []struct {
+		name        string
+		schedule    string
+		timeZone    *string
+		suspend     *bool
+		lastSuccess *metav1.Time
+		now         string
+		want        string // finding reason, "" for none
+	}{
+		{name: "hourly on time", schedule: "0 * * * *", lastSuccess: at("2026-03-01T10:00:00Z"), now: "2026-03-01T11:30:00Z"},
+		{name: "hourly, next run due but still within its interval", schedule: "0 * * * *", lastSuccess: at("2026-03-01T10:00:00Z"), now: "2026-03-01T11:59:59Z"},
+		{name: "hourly missed once the following run is due", schedule: "0 * * * *", lastSuccess: at("2026-03-01T10:00:00Z"), now: "2026-03-01T12:00:00Z", want: "ScheduleMissed"},
+		{name: "daily never succeeded, first run still has time", schedule: "30 2 * * *", now: "2026-01-02T02:00:00Z"},
+		{name: "daily never succeeded for days", schedule: "30 2 * * *", now: "2026-01-05T00:00:00Z", want: "ScheduleMissed"},
+		{name: "time zone shifts the due time", schedule: "0 3 * * *", timeZone: &berlin, lastSuccess: at("2026-03-01T02:00:00Z"), now: "2026-03-03T01:59:00Z"},
+		{name: "time zone missed", schedule: "0 3 * * *", timeZone: &berlin, lastSuccess: at("2026-03-01T02:00:00Z"), now: "2026-03-03T02:00:00Z", want: "ScheduleMissed"},
+		{name: "explicit UTC", schedule: "@daily", timeZone: &utc, lastSuccess: at("2026-03-01T00:00:00Z"), now: "2026-03-02T12:00:00Z"},
+		{name: "suspended", schedule: "0 * * * *", suspend: &yes, now: "2027-01-01T00:00:00Z", want: "Suspended"},
+		{name: "invalid schedule", schedule: "every hour", now: "2026-03-01T00:00:00Z", want: "InvalidSchedule"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cj := batchv1.CronJob{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "backup", CreationTimestamp: metav1.Time{Time: created}},
+				Spec:       batchv1.CronJobSpec{Schedule: tt.schedule, TimeZone: tt.timeZone, Suspend: tt.suspend},
+				Status:     batchv1.CronJobStatus{LastSuccessfulTime: tt.lastSuccess},
+			}
+			findings := inspectCronJob(cj, at(tt.now).Time)
+
+			got := ""
+			if len(findings) > 0 {
+				got = findings[0].Reason
+			}
+			if got != tt.want || len(findings) > 1 {
+				t.Errorf("inspectCronJob() = %v, want reason %q", findings, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleInterval(t *testing.T) {
+	from := time.Date(2026, 3, 1, 10, 15, 0, 0, time.UTC)
+	tests := []struct {
+		schedule string
+		want     time.Duration
+	}{
+		{"*/5 * * * *", 5 * time.Minute},
+		{"0 * * * *", time.Hour},
+		{"@daily", 24 * time.Hour},
+		{"0 0 * * 1", 7 * 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		cj := batchv1.CronJob{Spec: batchv1.CronJobSpec{Schedule: tt.schedule}}
+		got, err := scheduleInterval(cj, from)
+		if err != nil || got != tt.want {
+			t.Errorf("scheduleInterval(%q) = %s, %v, want %s", tt.schedule, got, err, tt.want)
+		}
+	}
+}
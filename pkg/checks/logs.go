@@ -0,0 +1,147 @@
+package checks
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// maxLogFetchBytes bounds how much log data is read from the API server per
+// container, independent of the excerpt size shown in the report.
+const maxLogFetchBytes = 1 << 20
+
+// LogOptions controls the container log excerpts of the deep pod scan.
+type LogOptions struct {
+	// Lines is how many lines to tail from the container; 0 disables log excerpts.
+	Lines int64
+	// MaxBytes caps the size of the excerpt kept per container; the newest bytes win.
+	MaxBytes int
+}
+
+// LogExcerpt is the tail of a crashed container's log attached to a finding.
+type LogExcerpt struct {
+	Container string `json:"container"`
+	// Previous is true when the log comes from the previous (crashed) container instance.
+	Previous bool `json:"previous"`
+	// Truncated is true when older lines were dropped to stay within the byte cap.
+	Truncated bool      `json:"truncated,omitempty"`
+	Lines     []LogLine `json:"lines"`
+}
+
+// LogLine is one line of a LogExcerpt; Match names the fatal pattern it contains, if any.
+type LogLine struct {
+	Text  string `json:"text"`
+	Match string `json:"match,omitempty"`
+}
+
+// Matches returns the distinct fatal patterns found in the excerpt.
+func (e *LogExcerpt) Matches() []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, l := range e.Lines {
+		if l.Match != "" && !seen[l.Match] {
+			seen[l.Match] = true
+			out = append(out, l.Match)
+		}
+	}
+	return out
+}
+
+// fatalPatterns are the log lines most likely to explain why a container died.
+var fatalPatterns = []struct {
+	name string
+	re   *regexp.Regexp
+}{
+	{"panic", regexp.MustCompile(`(?i)\bpanic:|fatal error:|traceback \(most recent call last\)|exception in thread|segmentation fault`)},
+	{"out of memory", regexp.MustCompile(`(?i)out of memory|outofmemoryerror|oomkilled|cannot allocate memory`)},
+	{"permission denied", regexp.MustCompile(`(?i)permission denied|operation not permitted|\beacces\b`)},
+	{"connection refused", regexp.MustCompile(`(?i)connection refused|\beconnrefused\b`)},
+}
+
+// crashedContainers returns the containers of a pod whose logs explain a failure,
+// mapped to whether the previous instance holds them: crash-looping containers and
+// containers whose current or last run ended with a non-zero exit code.
+func crashedContainers(pod v1.Pod) map[string]bool {
+	out := make(map[string]bool)
+	statuses := append(append([]v1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, c := range statuses {
+		switch {
+		case c.State.Terminated != nil && c.State.Terminated.ExitCode != 0:
+			out[c.Name] = false
+		case c.LastTerminationState.Terminated != nil && c.LastTerminationState.Terminated.ExitCode != 0,
+			c.State.Waiting != nil && c.State.Waiting.Reason == "CrashLoopBackOff" && c.RestartCount > 0:
+			out[c.Name] = true
+		}
+	}
+	return out
+}
+
+// fetchLogExcerpts tails the logs of every crashed container of a pod. Containers
+// whose logs cannot be read (e.g. already garbage collected) are skipped.
+func fetchLogExcerpts(ctx context.Context, env *Env, pod v1.Pod) map[string]*LogExcerpt {
+	crashed := crashedContainers(pod)
+	if len(crashed) == 0 {
+		return nil
+	}
+
+	out := make(map[string]*LogExcerpt)
+	for container, previous := range crashed {
+		if excerpt, err := fetchLogExcerpt(ctx, env, pod, container, previous); err == nil && len(excerpt.Lines) > 0 {
+			out[container] = excerpt
+		}
+	}
+	return out
+}
+
+// fetchLogExcerpt reads the tail of one container's log and marks fatal patterns in it.
+func fetchLogExcerpt(ctx context.Context, env *Env, pod v1.Pod, container string, previous bool) (*LogExcerpt, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	lines := env.Logs.Lines
+	limit := int64(maxLogFetchBytes)
+	stream, err := env.Clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &v1.PodLogOptions{
+		Container:  container,
+		Previous:   previous,
+		TailLines:  &lines,
+		LimitBytes: &limit,
+	}).Stream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	excerpt := &LogExcerpt{Container: container, Previous: previous}
+	if max := env.Logs.MaxBytes; max > 0 && len(data) > max {
+		// keep the newest bytes and drop the partial first line
+		data = data[len(data)-max:]
+		if i := strings.IndexByte(string(data), '\n'); i >= 0 {
+			data = data[i+1:]
+		}
+		excerpt.Truncated = true
+	}
+
+	if len(data) == 0 {
+		return excerpt, nil
+	}
+	for _, text := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		line := LogLine{Text: strings.TrimRight(text, "\r")}
+		for _, p := range fatalPatterns {
+			if p.re.MatchString(line.Text) {
+				line.Match = p.name
+				break
+			}
+		}
+		excerpt.Lines = append(excerpt.Lines, line)
+	}
+	return excerpt, nil
+}
@@ -0,0 +1,97 @@
+package checks
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCrashedContainers(t *testing.T) {
+	terminated := func(code int32) *v1.ContainerStateTerminated {
+		return &v1.ContainerStateTerminated{ExitCode: code}
+	}
+	pod := v1.Pod{Status: v1.PodStatus{
+		InitContainerStatuses: []v1.ContainerStatus{
+			{Name: "migrate", State: v1.ContainerState{Terminated: terminated(1)}},
+			{Name: "setup", State: v1.ContainerState{Terminated: terminated(0)}},
+		},
+		ContainerStatuses: []v1.ContainerStatus{
+			{Name: "app", RestartCount: 4, State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+			{Name: "sidecar", RestartCount: 1, LastTerminationState: v1.ContainerState{Terminated: terminated(137)}, State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}},
+			{Name: "healthy", RestartCount: 1, LastTerminationState: v1.ContainerState{Terminated: terminated(0)}, State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}},
+			{Name: "pulling", State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ImagePullBackOff"}}},
+		},
+	}}
+
+	want := map[string]bool{"migrate": false, "app": true, "sidecar": true}
+	if got := crashedContainers(pod); !reflect.DeepEqual(got, want) {
+		t.Errorf("crashedContainers() = %v, want %v", got, want)
+	}
+}
+
+func TestFatalPatterns(t *testing.T) {
+	tests := map[string]string{
+		"panic: runtime error: invalid memory address":           "panic",
+		"Traceback (most recent call last):":                     "panic",
+		`Exception in thread "main" java.lang.RuntimeException`:  "panic",
+		"java.lang.OutOfMemoryError: Java heap space":            "out of memory",
+		"open /data/db.lock: permission denied":                  "permission denied",
+		"dial tcp 10.0.0.1:5432: connect: connection refused":    "connection refused",
+		"Error: connect ECONNREFUSED 127.0.0.1:6379":             "connection refused",
+		"level=info msg=\"listening on :8080\"":                  "",
+		"no panics here, just a word containing panic in it: ok": "",
+	}
+	for text, want := range tests {
+		got := ""
+		for _, p := range fatalPatterns {
+			if p.re.MatchString(text) {
+				got = p.name
+				break
+			}
+		}
+		if got != want {
+			t.Errorf("%q matched %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestLogExcerptMatches(t *testing.T) {
+	e := &LogExcerpt{Lines: []LogLine{
+		{Text: "starting"},
+		{Text: "panic: boom", Match: "panic"},
+		{Text: "connection refused", Match: "connection refused"},
+		{Text: "panic: again", Match: "panic"},
+	}}
+	if got, want := e.Matches(), []string{"panic", "connection refused"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Matches() = %v, want %v", got, want)
+	}
+}
+
+func TestFetchLogExcerpts(t *testing.T) {
+	pod := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web"},
+		Status: v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{
+			{Name: "app", RestartCount: 2, State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+			{Name: "sidecar", State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}},
+		}},
+	}
+	env := &Env{Clientset: fake.NewSimpleClientset(&pod), Logs: LogOptions{Lines: 20}}
+
+	// the fake clientset answers every log request with "fake logs"
+	got := fetchLogExcerpts(context.Background(), env, pod)
+	if len(got) != 1 || got["app"] == nil {
+		t.Fatalf("fetchLogExcerpts() = %v, want an excerpt for app only", got)
+	}
+	if app := got["app"]; !app.Previous || app.Truncated || len(app.Lines) != 1 || app.Lines[0].Text != "fake logs" {
+		t.Errorf("app excerpt = %+v", app)
+	}
+
+	env.Logs.MaxBytes = 4
+	if app := fetchLogExcerpts(context.Background(), env, pod)["app"]; app == nil || !app.Truncated || app.Lines[0].Text != "logs" {
+		t.Errorf("truncated excerpt = %+v, want the newest 4 bytes", app)
+	}
+}
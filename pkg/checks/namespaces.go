@@ -0,0 +1,160 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"gitlab.com/kobot/kobot/pkg/logging"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// NamespaceSelection is the operator's choice of namespaces to scan. Include and
+// Exclude entries are plain names, globs (e.g. "kube-*") or regular expressions
+// written between slashes (e.g. "/^test-[0-9]+$/").
+type NamespaceSelection struct {
+	// Include lists the namespaces to scan (-n, --namespace); empty means all.
+	Include []string
+	// Exclude lists namespaces never to scan (--exclude-namespace).
+	Exclude []string
+	// Selector is a label selector the namespaces must match (--namespace-selector).
+	Selector string
+}
+
+// namespacePattern matches namespace names against one Include or Exclude entry.
+type namespacePattern struct {
+	value string
+	regex *regexp.Regexp
+}
+
+// parseNamespacePattern validates a name, glob or /regex/ entry.
+func parseNamespacePattern(s string) (namespacePattern, error) {
+	if len(s) > 2 && strings.HasPrefix(s, "/") && strings.HasSuffix(s, "/") {
+		re, err := regexp.Compile(s[1 : len(s)-1])
+		if err != nil {
+			return namespacePattern{}, fmt.Errorf("invalid namespace pattern %q: %w", s, err)
+		}
+		return namespacePattern{value: s, regex: re}, nil
+	}
+	if _, err := path.Match(s, ""); err != nil {
+		return namespacePattern{}, fmt.Errorf("invalid namespace pattern %q: %w", s, err)
+	}
+	return namespacePattern{value: s}, nil
+}
+
+// literal reports whether the pattern is a plain namespace name.
+func (p namespacePattern) literal() bool {
+	return p.regex == nil && !strings.ContainsAny(p.value, `*?[\`)
+}
+
+func (p namespacePattern) matches(name string) bool {
+	if p.regex != nil {
+		return p.regex.MatchString(name)
+	}
+	ok, _ := path.Match(p.value, name)
+	return ok
+}
+
+// parseNamespacePatterns parses a list of entries, skipping empty ones.
+func parseNamespacePatterns(list []string) ([]namespacePattern, error) {
+	var out []namespacePattern
+	for _, s := range list {
+		if s == "" {
+			continue
+		}
+		p, err := parseNamespacePattern(s)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// Validate checks the patterns and the label selector, so a typo fails the run
+// before it connects.
+func (s NamespaceSelection) Validate() error {
+	if _, err := parseNamespacePatterns(s.Include); err != nil {
+		return err
+	}
+	if _, err := parseNamespacePatterns(s.Exclude); err != nil {
+		return err
+	}
+	if _, err := labels.Parse(s.Selector); err != nil {
+		return fmt.Errorf("invalid namespace selector: %w", err)
+	}
+	return nil
+}
+
+// ResolveNamespaces returns the namespaces a scan should cover. Plain names given
+// with -n are used as they are; otherwise the cluster's namespaces are listed
+// (filtered by the label selector) and matched against the include patterns.
+// Excluded namespaces are always left out.
+func ResolveNamespaces(ctx context.Context, clientset kubernetes.Interface, sel NamespaceSelection) ([]string, error) {
+	include, err := parseNamespacePatterns(sel.Include)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := parseNamespacePatterns(sel.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	excluded := func(name string) bool {
+		for _, p := range exclude {
+			if p.matches(name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	literal := len(include) > 0 && sel.Selector == ""
+	for _, p := range include {
+		literal = literal && p.literal()
+	}
+	if literal {
+		var resolved []string
+		for _, p := range include {
+			if !excluded(p.value) {
+				resolved = append(resolved, p.value)
+			}
+		}
+		return nonEmpty(resolved)
+	}
+
+	if len(include) == 0 && sel.Selector == "" {
+		logging.Warn("No namespaces specified — checking all namespaces.")
+	}
+
+	nsList, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{LabelSelector: sel.Selector})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list namespaces: %w", err)
+	}
+
+	resolved := make([]string, 0, len(nsList.Items))
+	for _, ns := range nsList.Items {
+		if excluded(ns.Name) {
+			continue
+		}
+		matched := len(include) == 0
+		for _, p := range include {
+			matched = matched || p.matches(ns.Name)
+		}
+		if matched {
+			resolved = append(resolved, ns.Name)
+		}
+	}
+	return nonEmpty(resolved)
+}
+
+// nonEmpty fails a selection that leaves nothing to scan, since checks expect at least one namespace.
+func nonEmpty(namespaces []string) ([]string, error) {
+	if len(namespaces) == 0 {
+		return nil, fmt.Errorf("no namespaces match the namespace selection")
+	}
+	return namespaces, nil
+}
@@ -0,0 +1,166 @@
+package checks
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNamespacePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		literal bool
+		match   []string
+		noMatch []string
+		wantErr bool
+	}{
+		{pattern: "shop", literal: true, match: []string{"shop"}, noMatch: []string{"shop-dev", "workshop"}},
+		{pattern: "kube-*", match: []string{"kube-system", "kube-public"}, noMatch: []string{"kube", "my-kube-x"}},
+		{pattern: "team-?", match: []string{"team-a"}, noMatch: []string{"team-ab"}},
+		{pattern: "[ab]pp", match: []string{"app", "bpp"}, noMatch: []string{"cpp"}},
+		{pattern: "/^test-[0-9]+$/", match: []string{"test-1", "test-42"}, noMatch: []string{"test-a", "my-test-1"}},
+		{pattern: "/dev/", match: []string{"dev", "shop-dev-1"}, noMatch: []string{"prod"}},
+		{pattern: "//", literal: true, match: []string{"//"}},
+		{pattern: "/[/", wantErr: true},
+		{pattern: "[a-", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		p, err := parseNamespacePattern(tt.pattern)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseNamespacePattern(%q) error = %v, want error %v", tt.pattern, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if p.literal() != tt.literal {
+			t.Errorf("%q.literal() = %v, want %v", tt.pattern, p.literal(), tt.literal)
+		}
+		for _, name := range tt.match {
+			if !p.matches(name) {
+				t.Errorf("%q does not match %q", tt.pattern, name)
+			}
+		}
+		for _, name := range tt.noMatch {
+			if p.matches(name) {
+				t.Errorf("%q matches %q", tt.pattern, name)
+			}
+		}
+	}
+}
+
+func TestNamespaceSelectionValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		sel     NamespaceSelection
+		wantErr string
+	}{
+		{name: "valid", sel: NamespaceSelection{Include: []string{"shop", "kube-*"}, Exclude: []string{"/^tmp-/"}, Selector: "env=prod"}},
+		{name: "invalid include", sel: NamespaceSelection{Include: []string{"/(/"}}, wantErr: `invalid namespace pattern "/(/"`},
+		{name: "invalid exclude", sel: NamespaceSelection{Exclude: []string{"[a-"}}, wantErr: `invalid namespace pattern "[a-"`},
+		{name: "invalid selector", sel: NamespaceSelection{Selector: "env in ("}, wantErr: "invalid namespace selector"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.sel.Validate()
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveNamespaces(t *testing.T) {
+	namespace := func(name string, labels map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+	cs := fake.NewSimpleClientset(
+		namespace("bigbang", map[string]string{"env": "prod"}),
+		namespace("kube-public", nil),
+		namespace("kube-system", nil),
+		namespace("shop", map[string]string{"env": "prod"}),
+		namespace("shop-dev", map[string]string{"env": "dev"}),
+		namespace("test-1", map[string]string{"env": "dev"}),
+	)
+
+	tests := []struct {
+		name    string
+		sel     NamespaceSelection
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "all namespaces",
+			want: []string{"bigbang", "kube-public", "kube-system", "shop", "shop-dev", "test-1"},
+		},
+		{
+			name: "exclude glob and regex",
+			sel:  NamespaceSelection{Exclude: []string{"kube-*", "/^test-[0-9]+$/"}},
+			want: []string{"bigbang", "shop", "shop-dev"},
+		},
+		{
+			name: "label selector",
+			sel:  NamespaceSelection{Selector: "env=prod"},
+			want: []string{"bigbang", "shop"},
+		},
+		{
+			name: "include pattern",
+			sel:  NamespaceSelection{Include: []string{"shop*"}},
+			want: []string{"shop", "shop-dev"},
+		},
+		{
+			name: "include pattern with selector and exclude",
+			sel:  NamespaceSelection{Include: []string{"shop*", "test-1"}, Exclude: []string{"shop"}, Selector: "env=dev"},
+			want: []string{"shop-dev", "test-1"},
+		},
+		{
+			name: "literal names are used without listing",
+			sel:  NamespaceSelection{Include: []string{"missing", "shop"}},
+			want: []string{"missing", "shop"},
+		},
+		{
+			name: "literal names with exclude",
+			sel:  NamespaceSelection{Include: []string{"shop", "shop-dev"}, Exclude: []string{"*-dev"}},
+			want: []string{"shop"},
+		},
+		{
+			name:    "everything excluded",
+			sel:     NamespaceSelection{Include: []string{"shop"}, Exclude: []string{"shop"}},
+			wantErr: "no namespaces match",
+		},
+		{
+			name:    "nothing matches",
+			sel:     NamespaceSelection{Include: []string{"/^prod-/"}},
+			wantErr: "no namespaces match",
+		},
+		{
+			name:    "invalid pattern",
+			sel:     NamespaceSelection{Exclude: []string{"/(/"}},
+			wantErr: "invalid namespace pattern",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveNamespaces(context.Background(), cs, tt.sel)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ResolveNamespaces() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveNamespaces() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveNamespaces() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
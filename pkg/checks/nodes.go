@@ -0,0 +1,272 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gitlab.com/kobot/kobot/pkg/logging"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+func init() {
+	Register(nodeCheck{})
+}
+
+// expectedTaints are taint keys kobot never reports: control-plane isolation and the
+// condition taints the node lifecycle controller adds (those are reported via conditions).
+var expectedTaints = []string{
+	"node-role.kubernetes.io/control-plane",
+	"node-role.kubernetes.io/master",
+	"node.kubernetes.io/",
+	"node.cloudprovider.kubernetes.io/",
+}
+
+// requestedCapacityWarn is the share of allocatable CPU/memory/pods requested
+// by pods on a node above which new pods are likely to stop fitting.
+const requestedCapacityWarn = 0.9
+
+// nodeCheck reports node conditions, schedulability, taints, kubelet version skew
+// and how much of each node's allocatable capacity is already requested.
+// Nodes are cluster-scoped, so the --namespace selection does not apply.
+type nodeCheck struct{}
+
+func (nodeCheck) Name() string { return "nodes" }
+
+func (nodeCheck) Description() string {
+	return "Node readiness, pressure conditions, taints, kubelet version skew and requested capacity"
+}
+
+func (nodeCheck) RequiredClients() []Client { return []Client{KubeClient} }
+
+func (nodeCheck) ClusterScoped() bool { return true }
+
+func (nodeCheck) Run(ctx context.Context, env *Env) (*Result, error) {
+	logging.Info("Scanning node health.")
+	logging.Starting("Operator-initiated node health check")
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	nodes, err := env.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list nodes: %w", err)
+	}
+
+	result := &Result{Resource: "nodes"}
+	scope := NamespaceResult{Name: ClusterScope, Checked: len(nodes.Items)}
+
+	var serverVersion *version.Version
+	if info, err := env.Clientset.Discovery().ServerVersion(); err != nil {
+		logging.Warn("Unable to read the API server version, skipping kubelet version skew: %v", err)
+	} else if serverVersion, err = version.ParseGeneric(info.GitVersion); err != nil {
+		logging.Warn("Unable to parse API server version %q: %v", info.GitVersion, err)
+	}
+
+	// requests are summed over every pod bound to a node, regardless of --namespace
+	requested, err := requestedByNode(ctx, env)
+	if err != nil {
+		scope.Error = fmt.Sprintf("unable to list pods for requested capacity: %v", err)
+	}
+
+	for _, node := range nodes.Items {
+		result.Findings = append(result.Findings, inspectNode(node, serverVersion, requested[node.Name])...)
+	}
+
+	result.Namespaces = append(result.Namespaces, scope)
+	return result, nil
+}
+
+// nodeRef returns the ResourceRef for a core/v1 Node.
+func nodeRef(name string) ResourceRef {
+	return ResourceRef{Version: "v1", Kind: "Node", Name: name}
+}
+
+// inspectNode evaluates a single node against the server version and the resources requested on it.
+func inspectNode(node v1.Node, serverVersion *version.Version, requested v1.ResourceList) []Finding {
+	b := &findingBuilder{check: "nodes", ref: nodeRef(node.Name)}
+	describe := fmt.Sprintf("kubectl describe node %s", node.Name)
+
+	for _, cond := range node.Status.Conditions {
+		evidence := conditionEvidence(cond.Reason, cond.Message)
+
+		switch cond.Type {
+		case v1.NodeReady:
+			if cond.Status != v1.ConditionTrue {
+				b.add(SeverityCritical, "NodeNotReady", fmt.Sprintf("Node is not Ready (status %s)", cond.Status),
+					fmt.Sprintf("Check the kubelet and container runtime on the node and run '%s'.", describe),
+					evidence, fmt.Sprintf("last heartbeat %s", cond.LastHeartbeatTime.Format(time.RFC3339)))
+			}
+		case v1.NodeMemoryPressure, v1.NodeDiskPressure, v1.NodePIDPressure:
+			if cond.Status == v1.ConditionTrue {
+				b.add(SeverityWarning, string(cond.Type), fmt.Sprintf("Node reports %s", cond.Type),
+					"The kubelet will evict pods from this node; free resources or move workloads elsewhere.",
+					evidence)
+			}
+		case v1.NodeNetworkUnavailable:
+			if cond.Status == v1.ConditionTrue {
+				b.add(SeverityCritical, "NetworkUnavailable", "Node network is not configured",
+					"Check the CNI plugin pods on this node.",
+					evidence)
+			}
+		}
+	}
+
+	if node.Spec.Unschedulable {
+		b.add(SeverityWarning, "Cordoned", "Node is cordoned (unschedulable)",
+			fmt.Sprintf("If maintenance is finished run 'kubectl uncordon %s'.", node.Name))
+	}
+
+	for _, taint := range node.Spec.Taints {
+		if taint.Effect == v1.TaintEffectPreferNoSchedule || isExpectedTaint(taint.Key) {
+			continue
+		}
+		severity := SeverityInfo
+		if taint.Effect == v1.TaintEffectNoExecute {
+			severity = SeverityWarning
+		}
+		b.add(severity, "UnexpectedTaint", fmt.Sprintf("Node has taint %s", taint.ToString()),
+			"Confirm the taint is intended; only pods tolerating it can run on this node.")
+	}
+
+	if serverVersion != nil {
+		inspectKubeletSkew(b, node, serverVersion)
+	}
+
+	if requested != nil {
+		var evidence []string
+		worst := 0.0
+		for _, name := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory, v1.ResourcePods} {
+			alloc, ok := node.Status.Allocatable[name]
+			if !ok || alloc.IsZero() {
+				continue
+			}
+			req := requested[name]
+			ratio := float64(req.MilliValue()) / float64(alloc.MilliValue())
+			if ratio > worst {
+				worst = ratio
+			}
+			evidence = append(evidence, fmt.Sprintf("%s requested %s of %s allocatable (%.0f%%)", name, req.String(), alloc.String(), ratio*100))
+		}
+		if worst >= requestedCapacityWarn {
+			b.add(SeverityWarning, "HighRequestedCapacity",
+				fmt.Sprintf("%.0f%% of allocatable capacity is already requested", worst*100),
+				"New pods may not fit on this node; add capacity or review resource requests.",
+				evidence...)
+		}
+	}
+
+	return b.findings
+}
+
+// inspectKubeletSkew compares the kubelet version against the API server following the
+// Kubernetes version skew policy: kubelets may be up to three minor versions older, never newer.
+func inspectKubeletSkew(b *findingBuilder, node v1.Node, serverVersion *version.Version) {
+	kubelet, err := version.ParseGeneric(node.Status.NodeInfo.KubeletVersion)
+	if err != nil {
+		return
+	}
+
+	evidence := fmt.Sprintf("kubelet %s, API server %s", node.Status.NodeInfo.KubeletVersion, serverVersion.String())
+	skew := int(serverVersion.Minor()) - int(kubelet.Minor())
+	newer := kubelet.Major() > serverVersion.Major() || (kubelet.Major() == serverVersion.Major() && skew < 0)
+
+	switch {
+	case newer:
+		b.add(SeverityCritical, "KubeletVersionSkew", "Kubelet is newer than the API server, which is not supported",
+			"Upgrade the control plane before the nodes.", evidence)
+	case kubelet.Major() != serverVersion.Major():
+		b.add(SeverityCritical, "KubeletVersionSkew",
+			fmt.Sprintf("Kubelet is %d major version(s) behind the API server, which is not supported", serverVersion.Major()-kubelet.Major()),
+			"Upgrade the node to a supported kubelet version.", evidence)
+	case skew > 3:
+		b.add(SeverityWarning, "KubeletVersionSkew", fmt.Sprintf("Kubelet is %d minor versions behind the API server (max 3 supported)", skew),
+			"Upgrade the node to a supported kubelet version.", evidence)
+	case skew > 0:
+		b.add(SeverityInfo, "KubeletVersionSkew", fmt.Sprintf("Kubelet is %d minor version(s) behind the API server", skew),
+			"Plan a node upgrade to keep within the supported skew.", evidence)
+	}
+}
+
+// conditionEvidence renders a condition's reason and message as one evidence line.
+func conditionEvidence(reason, message string) string {
+	switch {
+	case reason == "":
+		return message
+	case message == "":
+		return reason
+	}
+	return reason + ": " + message
+}
+
+// isExpectedTaint reports whether a taint key belongs to the well known, expected taints.
+func isExpectedTaint(key string) bool {
+	for _, expected := range expectedTaints {
+		if key == expected || (strings.HasSuffix(expected, "/") && strings.HasPrefix(key, expected)) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestedByNode sums the CPU, memory and pod count requested by non-terminated pods on each node.
+func requestedByNode(ctx context.Context, env *Env) (map[string]v1.ResourceList, error) {
+	pods, err := env.Clientset.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: "spec.nodeName!=,status.phase!=Succeeded,status.phase!=Failed",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]v1.ResourceList)
+	for _, pod := range pods.Items {
+		list, ok := out[pod.Spec.NodeName]
+		if !ok {
+			list = v1.ResourceList{
+				v1.ResourceCPU:    resource.Quantity{},
+				v1.ResourceMemory: resource.Quantity{},
+				v1.ResourcePods:   resource.Quantity{},
+			}
+			out[pod.Spec.NodeName] = list
+		}
+
+		for name, q := range podRequests(pod) {
+			total := list[name]
+			total.Add(q)
+			list[name] = total
+		}
+		count := list[v1.ResourcePods]
+		count.Add(*resource.NewQuantity(1, resource.DecimalSI))
+		list[v1.ResourcePods] = count
+	}
+	return out, nil
+}
+
+// podRequests returns the effective CPU and memory requests of a pod the way the
+// scheduler computes them: the larger of the summed app containers and any single
+// init container, plus the pod overhead.
+func podRequests(pod v1.Pod) v1.ResourceList {
+	out := v1.ResourceList{}
+	for _, name := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+		var sum resource.Quantity
+		for _, c := range pod.Spec.Containers {
+			if q, ok := c.Resources.Requests[name]; ok {
+				sum.Add(q)
+			}
+		}
+		for _, c := range pod.Spec.InitContainers {
+			if q, ok := c.Resources.Requests[name]; ok && q.Cmp(sum) > 0 {
+				sum = q.DeepCopy()
+			}
+		}
+		if q, ok := pod.Spec.Overhead[name]; ok {
+			sum.Add(q)
+		}
+		out[name] = sum
+	}
+	return out
+}
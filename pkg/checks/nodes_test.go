@@ -0,0 +1,137 @@
+package checks
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+func TestInspectKubeletSkew(t *testing.T) {
+	tests := []struct {
+		kubelet string
+		server  string
+		want    []string
+		message string
+	}{
+		{kubelet: "v1.31.4", server: "v1.31.2"},
+		{kubelet: "v1.30.1", server: "v1.31.2", want: []string{"info/KubeletVersionSkew"}},
+		{kubelet: "v1.28.9", server: "v1.31.2", want: []string{"info/KubeletVersionSkew"}},
+		{kubelet: "v1.27.0", server: "v1.31.2", want: []string{"warning/KubeletVersionSkew"}, message: "Kubelet is 4 minor versions behind the API server (max 3 supported)"},
+		{kubelet: "v1.32.0", server: "v1.31.2", want: []string{"critical/KubeletVersionSkew"}, message: "Kubelet is newer than the API server, which is not supported"},
+		// across a major version the minor numbers say nothing about the direction
+		{kubelet: "v2.0.0", server: "v1.31.2", want: []string{"critical/KubeletVersionSkew"}, message: "Kubelet is newer than the API server, which is not supported"},
+		{kubelet: "v1.35.0", server: "v2.1.0", want: []string{"critical/KubeletVersionSkew"}, message: "Kubelet is 1 major version(s) behind the API server, which is not supported"},
+		{kubelet: "not-a-version", server: "v1.31.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kubelet+" on "+tt.server, func(t *testing.T) {
+			node := v1.Node{Status: v1.NodeStatus{NodeInfo: v1.NodeSystemInfo{KubeletVersion: tt.kubelet}}}
+			b := &findingBuilder{check: "nodes", ref: nodeRef("n1")}
+			inspectKubeletSkew(b, node, version.MustParseGeneric(tt.server))
+
+			if got := findingReasons(b.findings); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("inspectKubeletSkew() = %v, want %v", got, tt.want)
+			}
+			if tt.message != "" && b.findings[0].Message != tt.message {
+				t.Errorf("message = %q, want %q", b.findings[0].Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestInspectNode(t *testing.T) {
+	ready := v1.NodeCondition{Type: v1.NodeReady, Status: v1.ConditionTrue}
+	allocatable := v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse("4"),
+		v1.ResourceMemory: resource.MustParse("16Gi"),
+		v1.ResourcePods:   resource.MustParse("110"),
+	}
+	tests := []struct {
+		name      string
+		spec      v1.NodeSpec
+		condition []v1.NodeCondition
+		requested v1.ResourceList
+		want      []string
+	}{
+		{name: "healthy", condition: []v1.NodeCondition{ready}},
+		{
+			name:      "not ready and under pressure",
+			condition: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionUnknown}, {Type: v1.NodeDiskPressure, Status: v1.ConditionTrue}},
+			want:      []string{"critical/NodeNotReady", "warning/DiskPressure"},
+		},
+		{
+			name:      "network unavailable",
+			condition: []v1.NodeCondition{ready, {Type: v1.NodeNetworkUnavailable, Status: v1.ConditionTrue}},
+			want:      []string{"critical/NetworkUnavailable"},
+		},
+		{
+			name:      "cordoned",
+			spec:      v1.NodeSpec{Unschedulable: true},
+			condition: []v1.NodeCondition{ready},
+			want:      []string{"warning/Cordoned"},
+		},
+		{
+			name: "only unexpected hard taints are reported",
+			spec: v1.NodeSpec{Taints: []v1.Taint{
+				{Key: "node-role.kubernetes.io/control-plane", Effect: v1.TaintEffectNoSchedule},
+				{Key: "node.kubernetes.io/unreachable", Effect: v1.TaintEffectNoExecute},
+				{Key: "dedicated", Value: "gpu", Effect: v1.TaintEffectPreferNoSchedule},
+				{Key: "dedicated", Value: "gpu", Effect: v1.TaintEffectNoSchedule},
+				{Key: "drain", Effect: v1.TaintEffectNoExecute},
+			}},
+			condition: []v1.NodeCondition{ready},
+			want:      []string{"info/UnexpectedTaint", "warning/UnexpectedTaint"},
+		},
+		{
+			name:      "requests below the threshold",
+			condition: []v1.NodeCondition{ready},
+			requested: v1.ResourceList{v1.ResourceCPU: resource.MustParse("3"), v1.ResourceMemory: resource.MustParse("8Gi"), v1.ResourcePods: resource.MustParse("20")},
+		},
+		{
+			name:      "memory nearly fully requested",
+			condition: []v1.NodeCondition{ready},
+			requested: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("15Gi"), v1.ResourcePods: resource.MustParse("20")},
+			want:      []string{"warning/HighRequestedCapacity"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := v1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "n1"},
+				Spec:       tt.spec,
+				Status:     v1.NodeStatus{Conditions: tt.condition, Allocatable: allocatable},
+			}
+			if got := findingReasons(inspectNode(node, nil, tt.requested)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("inspectNode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPodRequests(t *testing.T) {
+	container := func(cpu, memory string) v1.Container {
+		return v1.Container{Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse(cpu),
+			v1.ResourceMemory: resource.MustParse(memory),
+		}}}
+	}
+	pod := v1.Pod{Spec: v1.PodSpec{
+		Containers:     []v1.Container{container("250m", "256Mi"), container("250m", "256Mi")},
+		InitContainers: []v1.Container{container("1", "128Mi")},
+		Overhead:       v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m")},
+	}}
+
+	got := podRequests(pod)
+	if cpu := got[v1.ResourceCPU]; cpu.Cmp(resource.MustParse("1100m")) != 0 {
+		t.Errorf("cpu = %s, want 1100m (largest init container plus overhead)", cpu.String())
+	}
+	if memory := got[v1.ResourceMemory]; memory.Cmp(resource.MustParse("512Mi")) != 0 {
+		t.Errorf("memory = %s, want 512Mi (summed app containers)", memory.String())
+	}
+}
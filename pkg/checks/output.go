@@ -0,0 +1,239 @@
+package checks
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// ReportAPIVersion versions the machine-readable report schema. Bump it on any
+// breaking change to the types below so pipelines can detect it.
+const ReportAPIVersion = "kobot.io/v1alpha1"
+
+// Output formats supported by WriteReport.
+const (
+	OutputConsole = "console"
+	OutputJSON    = "json"
+	OutputYAML    = "yaml"
+)
+
+// ParseOutput validates an --output flag value.
+func ParseOutput(s string) (string, error) {
+	switch s {
+	case "", OutputConsole:
+		return OutputConsole, nil
+	case OutputJSON, OutputYAML:
+		return s, nil
+	}
+	return "", fmt.Errorf("unknown output format %q (expected json or yaml)", s)
+}
+
+// Report is the stable, versioned document emitted by --output json|yaml.
+type Report struct {
+	APIVersion string         `json:"apiVersion"`
+	Kind       string         `json:"kind"`
+	Metadata   ReportMetadata `json:"metadata"`
+	Summary    ReportSummary  `json:"summary"`
+	// Namespaces aggregates every check's findings per namespace.
+	Namespaces []NamespaceSummary `json:"namespaces"`
+	Checks     []CheckReport      `json:"checks"`
+}
+
+// ReportMetadata describes the scan run itself.
+type ReportMetadata struct {
+	KobotVersion    string    `json:"kobotVersion"`
+	Context         string    `json:"context,omitempty"`
+	Server          string    `json:"server,omitempty"`
+	StartedAt       time.Time `json:"startedAt"`
+	FinishedAt      time.Time `json:"finishedAt"`
+	DurationSeconds float64   `json:"durationSeconds"`
+	Namespaces      []string  `json:"namespaces"`
+	Checks          []string  `json:"checks"`
+}
+
+// ReportSummary holds the totals over all checks.
+type ReportSummary struct {
+	Status         string `json:"status"`
+	ObjectsChecked int    `json:"objectsChecked"`
+	Critical       int    `json:"critical"`
+	Warning        int    `json:"warning"`
+	Info           int    `json:"info"`
+	Waived         int    `json:"waived"`
+	ScanErrors     int    `json:"scanErrors"`
+}
+
+// NamespaceSummary is the per-namespace view of a report.
+type NamespaceSummary struct {
+	Name     string   `json:"name"`
+	Status   string   `json:"status"`
+	Checked  int      `json:"checked"`
+	Failed   int      `json:"failed"`
+	Critical int      `json:"critical"`
+	Warning  int      `json:"warning"`
+	Info     int      `json:"info"`
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// CheckReport is the machine-readable form of a single check Result.
+type CheckReport struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Resource    string             `json:"resource"`
+	Status      string             `json:"status"`
+	Error       string             `json:"error,omitempty"`
+	Skipped     string             `json:"skipped,omitempty"`
+	Namespaces  []NamespaceSummary `json:"namespaces"`
+	// Groups summarizes the findings per workload, the primary unit of the report.
+	Groups   []FindingGroup `json:"groups"`
+	Findings []Finding      `json:"findings"`
+	// Waived are the findings accepted by the waiver file.
+	Waived []Finding `json:"waived,omitempty"`
+}
+
+// statusOf maps the worst severity seen (and whether errors occurred) to a report status.
+func statusOf(max Severity, errored bool) string {
+	switch {
+	case max == SeverityCritical:
+		return "fail"
+	case max == SeverityWarning:
+		return "warn"
+	case errored:
+		return "error"
+	}
+	return "pass"
+}
+
+// BuildReport converts check results into the versioned report document.
+func BuildReport(results []*Result, meta ReportMetadata) *Report {
+	report := &Report{
+		APIVersion: ReportAPIVersion,
+		Kind:       "HealthReport",
+		Metadata:   meta,
+		Namespaces: []NamespaceSummary{},
+		Checks:     []CheckReport{},
+	}
+	report.Metadata.DurationSeconds = meta.FinishedAt.Sub(meta.StartedAt).Seconds()
+
+	overall := make(map[string]*NamespaceSummary)
+	var worst Severity
+
+	for _, r := range results {
+		cr := CheckReport{
+			Name:        r.Check,
+			Description: r.Description,
+			Resource:    r.Resource,
+			Error:       r.Error,
+			Skipped:     r.Skipped,
+			Namespaces:  []NamespaceSummary{},
+			Groups:      groupFindings(r.Findings),
+			Findings:    r.Findings,
+			Waived:      r.Waived,
+		}
+		if cr.Findings == nil {
+			cr.Findings = []Finding{}
+		}
+		report.Metadata.Checks = append(report.Metadata.Checks, r.Check)
+
+		checkErrored := r.Error != ""
+		if checkErrored {
+			report.Summary.ScanErrors++
+		}
+
+		for _, ns := range r.Namespaces {
+			counts := CountBySeverity(namespaceFindings(r, ns.Name))
+			nsSummary := NamespaceSummary{
+				Name:     ns.Name,
+				Status:   statusOf(r.MaxSeverity(ns.Name), ns.Error != ""),
+				Checked:  ns.Checked,
+				Failed:   r.Failed(ns.Name),
+				Critical: counts[SeverityCritical],
+				Warning:  counts[SeverityWarning],
+				Info:     counts[SeverityInfo],
+			}
+			if ns.Error != "" {
+				nsSummary.Errors = []string{ns.Error}
+				report.Summary.ScanErrors++
+				checkErrored = true
+			}
+			cr.Namespaces = append(cr.Namespaces, nsSummary)
+
+			agg, ok := overall[ns.Name]
+			if !ok {
+				agg = &NamespaceSummary{Name: ns.Name}
+				overall[ns.Name] = agg
+			}
+			agg.Checked += nsSummary.Checked
+			agg.Failed += nsSummary.Failed
+			agg.Critical += nsSummary.Critical
+			agg.Warning += nsSummary.Warning
+			agg.Info += nsSummary.Info
+			agg.Errors = append(agg.Errors, nsSummary.Errors...)
+		}
+
+		max := maxSeverity(r.Findings)
+		if max.rank() > worst.rank() {
+			worst = max
+		}
+		cr.Status = statusOf(max, checkErrored)
+		if r.Skipped != "" {
+			cr.Status = "skipped"
+		}
+		counts := CountBySeverity(r.Findings)
+		report.Summary.ObjectsChecked += r.Checked()
+		report.Summary.Critical += counts[SeverityCritical]
+		report.Summary.Warning += counts[SeverityWarning]
+		report.Summary.Info += counts[SeverityInfo]
+		report.Summary.Waived += len(r.Waived)
+
+		report.Checks = append(report.Checks, cr)
+	}
+
+	for _, agg := range overall {
+		switch {
+		case agg.Critical > 0:
+			agg.Status = statusOf(SeverityCritical, false)
+		case agg.Warning > 0:
+			agg.Status = statusOf(SeverityWarning, false)
+		default:
+			agg.Status = statusOf("", len(agg.Errors) > 0)
+		}
+		report.Namespaces = append(report.Namespaces, *agg)
+	}
+	sort.Slice(report.Namespaces, func(i, j int) bool { return report.Namespaces[i].Name < report.Namespaces[j].Name })
+
+	report.Summary.Status = statusOf(worst, report.Summary.ScanErrors > 0)
+	return report
+}
+
+// maxSeverity returns the highest severity in a list of findings.
+func maxSeverity(findings []Finding) Severity {
+	var max Severity
+	for _, f := range findings {
+		if f.Severity.rank() > max.rank() {
+			max = f.Severity
+		}
+	}
+	return max
+}
+
+// WriteReport encodes the report to w in the given machine-readable format.
+func WriteReport(w io.Writer, report *Report, format string) error {
+	switch format {
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	case OutputYAML:
+		data, err := yaml.Marshal(report)
+		if err != nil {
+			return fmt.Errorf("failed to encode report as YAML: %w", err)
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	return fmt.Errorf("unsupported output format %q", format)
+}
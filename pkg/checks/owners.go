@@ -0,0 +1,103 @@
+package checks
+
+import (
+	"context"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+)
+
+// maxOwnerDepth bounds how far ownerReferences are followed (Pod -> Job -> CronJob is three levels).
+const maxOwnerDepth = 4
+
+// ownerResolver follows controller ownerReferences from an object up to its
+// top-level controller (e.g. Pod -> ReplicaSet -> Deployment). Lookups are
+// cached so objects shared by many failing pods are fetched only once.
+type ownerResolver struct {
+	clientset kubernetes.Interface
+
+	mu    sync.Mutex
+	cache map[string]*ResourceRef // object key -> controller, nil when it has none
+}
+
+func newOwnerResolver(clientset kubernetes.Interface) *ownerResolver {
+	return &ownerResolver{clientset: clientset, cache: make(map[string]*ResourceRef)}
+}
+
+// chain returns the controllers of ref from its direct owner up to the top-level one.
+func (o *ownerResolver) chain(ctx context.Context, ref ResourceRef) []ResourceRef {
+	var out []ResourceRef
+	for i := 0; i < maxOwnerDepth; i++ {
+		owner := o.controller(ctx, ref)
+		if owner == nil {
+			break
+		}
+		out = append(out, *owner)
+		ref = *owner
+	}
+	return out
+}
+
+// controller returns the controller owning ref, or nil when it has none or it cannot be read.
+func (o *ownerResolver) controller(ctx context.Context, ref ResourceRef) *ResourceRef {
+	key := ref.key()
+	o.mu.Lock()
+	owner, ok := o.cache[key]
+	o.mu.Unlock()
+	if ok {
+		return owner
+	}
+
+	owner = controllerRef(ref.Namespace, o.ownerReferences(ctx, ref))
+
+	o.mu.Lock()
+	o.cache[key] = owner
+	o.mu.Unlock()
+	return owner
+}
+
+// seed records the owners of an object the caller already has in hand, saving a GET.
+func (o *ownerResolver) seed(ref ResourceRef, owners []metav1.OwnerReference) {
+	o.mu.Lock()
+	o.cache[ref.key()] = controllerRef(ref.Namespace, owners)
+	o.mu.Unlock()
+}
+
+// ownerReferences fetches the ownerReferences of the kinds that are commonly owned
+// by another controller. Anything else is treated as a top-level object.
+func (o *ownerResolver) ownerReferences(ctx context.Context, ref ResourceRef) []metav1.OwnerReference {
+	var meta metav1.Object
+	var err error
+
+	switch ref.GVK() {
+	case schema.GroupVersionKind{Version: "v1", Kind: "Pod"}:
+		meta, err = o.clientset.CoreV1().Pods(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	case schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "ReplicaSet"}:
+		meta, err = o.clientset.AppsV1().ReplicaSets(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	case schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"}:
+		meta, err = o.clientset.BatchV1().Jobs(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	default:
+		return nil
+	}
+	if err != nil {
+		return nil
+	}
+	return meta.GetOwnerReferences()
+}
+
+// controllerRef converts the controller entry of a list of ownerReferences into a ResourceRef.
+func controllerRef(namespace string, owners []metav1.OwnerReference) *ResourceRef {
+	for _, owner := range owners {
+		if owner.Controller == nil || !*owner.Controller {
+			continue
+		}
+		gv, err := schema.ParseGroupVersion(owner.APIVersion)
+		if err != nil {
+			return nil
+		}
+		return &ResourceRef{Group: gv.Group, Version: gv.Version, Kind: owner.Kind, Namespace: namespace, Name: owner.Name}
+	}
+	return nil
+}
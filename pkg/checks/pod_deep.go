@@ -5,294 +5,241 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/fatih/color"
 	"gitlab.com/kobot/kobot/pkg/logging"
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
 )
 
-// PodFinding represents detailed findings for a single pod.
-type PodFinding struct {
-	PodName string
-	Issues  []string
-}
-
-// RunPodDeepCheck performs a deep concurrent inspection of pods and containers.
+// runPodDeepScan performs a deep concurrent inspection of pods and containers.
 // It handles API throttling gracefully with exponential backoff and retry logic.
-func RunPodDeepCheck(clientset *kubernetes.Clientset, namespaces []string, htmlOutput bool) {
-	ctx := context.Background()
-	fmt.Println()
-
-	// Discover namespaces if none provided
-	if len(namespaces) == 0 || (len(namespaces) == 1 && namespaces[0] == "") {
-		logging.Warn("No namespaces specified — checking all namespaces. A deep pod health scan across all namespaces could take slightly longer than a quick pod health scan.")
-		logging.Info("Throttling for deep pod health scans are in place by default.")
-		nsList, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
-		if err != nil {
-			logging.Error("Unable to list namespaces: %v", err)
-			return
-		}
-		namespaces = make([]string, len(nsList.Items))
-		for i, ns := range nsList.Items {
-			namespaces[i] = ns.Name
-		}
-	}
-
-	logging.Info("Performing deep pod health scan across %d namespace(s).", len(namespaces))
+func runPodDeepScan(ctx context.Context, env *Env) (*Result, error) {
+	logging.Info("Performing deep pod health scan across %d namespace(s).", len(env.Namespaces))
+	logging.Info("Throttling for deep pod health scans are in place by default.")
 	logging.Starting("Operator-initiated deep pod readiness check")
-	fmt.Println()
 
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	sem := make(chan struct{}, 4) // slightly lower concurrency to reduce throttling
 
-	var totalNamespaces, totalPods, failedNamespaces int
-	failingMap := make(map[string]int)
-	var results []PodCheckResult
+	result := &Result{Resource: "pods"}
+	owners := newOwnerResolver(env.Clientset)
 
-	wg.Add(len(namespaces))
-	for _, ns := range namespaces {
+	wg.Add(len(env.Namespaces))
+	for _, ns := range env.Namespaces {
 		ns := ns
 		go func() {
 			defer wg.Done()
 			sem <- struct{}{}
 			defer func() { <-sem }()
 
-			nsCtx, cancel := context.WithTimeout(context.Background(), 45*time.Second)
+			nsCtx, cancel := context.WithTimeout(ctx, 45*time.Second)
 			defer cancel()
 
-			mu.Lock()
-			totalNamespaces++
-			mu.Unlock()
-
-			// --- Fetch pods with retry + backoff for throttling
-			var pods *v1.PodList
-			var err error
-			maxRetries := 3
-
-			for attempt := 1; attempt <= maxRetries; attempt++ {
-				pods, err = clientset.CoreV1().Pods(ns).List(nsCtx, metav1.ListOptions{})
-				if err == nil {
-					break
-				}
-
-				if apierrors.IsTooManyRequests(err) || strings.Contains(strings.ToLower(err.Error()), "throttl") {
-					backoff := time.Duration(rand.Intn(500)+500*attempt) * time.Millisecond
-					mu.Lock()
-					fmt.Printf("%s Scan job on namespace: %s ... %s (attempt %d/%d, retrying in %s)\n",
-						color.YellowString("THROTTLED"),
-						ns,
-						color.YellowString("client-side API rate limit hit"),
-						attempt,
-						maxRetries,
-						backoff)
-					mu.Unlock()
-					time.Sleep(backoff)
-					continue
-				}
-
-				if errors.Is(err, context.DeadlineExceeded) {
-					mu.Lock()
-					fmt.Printf("%s Scan job on namespace: %s ... %s (attempt %d/%d)\n",
-						color.YellowString("TIMEOUT"),
-						ns,
-						color.YellowString("API slow or busy"),
-						attempt,
-						maxRetries)
-					mu.Unlock()
-					time.Sleep(1 * time.Second)
-					continue
-				}
-
-				// Non-retryable error
-				mu.Lock()
-				fmt.Printf("%s Scan job on namespace: %s ... %s (%v)\n",
-					color.RedString("ERROR"), ns,
-					color.RedString("Unable to list pods"), err)
-				failedNamespaces++
-				mu.Unlock()
-				return
-			}
-
+			pods, err := listPodsWithRetry(nsCtx, env, ns, &mu)
 			if err != nil {
 				mu.Lock()
-				fmt.Printf("%s Scan job on namespace: %s ... %s (exceeded retry limit)\n",
-					color.RedString("ERROR"), ns,
-					color.RedString("Pod listing failed after retries"))
-				failedNamespaces++
+				logging.Error("Scan job on namespace: %s ... %v", ns, err)
+				result.Namespaces = append(result.Namespaces, NamespaceResult{Name: ns, Error: err.Error()})
 				mu.Unlock()
 				return
 			}
 
-			// --- Skip namespaces with no pods
-			if len(pods.Items) == 0 {
-				mu.Lock()
-				fmt.Printf("%s Scan job on namespace: %s ... %s\n",
-					color.HiBlackString("RUNNING    "), ns,
-					color.HiBlackString("SKIP (no pods found)"))
-				mu.Unlock()
-				return
+			var findings []Finding
+			for _, pod := range pods.Items {
+				var logs map[string]*LogExcerpt
+				if env.Logs.Lines > 0 {
+					logs = fetchLogExcerpts(ctx, env, pod)
+				}
+				podFindings := inspectPod(pod, logs)
+				setPodOwners(nsCtx, owners, pod, podFindings)
+				findings = append(findings, podFindings...)
 			}
 
-			localPods := len(pods.Items)
-			var podFindings []PodFinding
-
-			// --- Analyze each pod
-			for _, pod := range pods.Items {
-				podName := pod.Name
-				var issues []string
+			mu.Lock()
+			logging.Running("Scan job on namespace: %s ... done (%d pods)", ns, len(pods.Items))
+			result.Namespaces = append(result.Namespaces, NamespaceResult{Name: ns, Checked: len(pods.Items)})
+			result.Findings = append(result.Findings, findings...)
+			mu.Unlock()
+		}()
+	}
 
-				// Skip completed pods
-				if pod.Status.Phase == v1.PodSucceeded {
-					continue
-				}
+	wg.Wait()
 
-				// Evicted or failed
-				if pod.Status.Reason == "Evicted" || pod.Status.Phase == v1.PodFailed {
-					issues = append(issues,
-						fmt.Sprintf("Pod phase: %s (Reason: %s)", pod.Status.Phase, pod.Status.Reason))
-				}
+	// namespaces finish in any order, keep the report stable
+	sort.Slice(result.Namespaces, func(i, j int) bool { return result.Namespaces[i].Name < result.Namespaces[j].Name })
+	sort.SliceStable(result.Findings, func(i, j int) bool {
+		return result.Findings[i].Resource.Namespace < result.Findings[j].Resource.Namespace
+	})
+	return result, nil
+}
 
-				// Pod conditions
-				for _, cond := range pod.Status.Conditions {
-					if cond.Type == v1.PodReady && cond.Status != v1.ConditionTrue {
-						issues = append(issues, fmt.Sprintf("PodReady=False (%s)", cond.Reason))
-					}
-					if cond.Type == v1.PodScheduled && cond.Status != v1.ConditionTrue {
-						issues = append(issues, fmt.Sprintf("NotScheduled (%s)", cond.Reason))
-					}
-				}
+// listPodsWithRetry lists the pods of a namespace, retrying on client-side throttling and timeouts.
+func listPodsWithRetry(ctx context.Context, env *Env, ns string, mu *sync.Mutex) (*v1.PodList, error) {
+	var pods *v1.PodList
+	var err error
+	maxRetries := 3
 
-				// Init containers
-				for _, init := range pod.Status.InitContainerStatuses {
-					if init.State.Terminated != nil && init.State.Terminated.ExitCode != 0 {
-						issues = append(issues,
-							fmt.Sprintf("Init container %s failed (exit %d, reason=%s)",
-								init.Name,
-								init.State.Terminated.ExitCode,
-								init.State.Terminated.Reason))
-					}
-				}
+	for attempt := 1; attempt <= maxRetries; attempt++ {
+		pods, err = env.Clientset.CoreV1().Pods(ns).List(ctx, env.podListOptions())
+		if err == nil {
+			return pods, nil
+		}
 
-				// Main containers
-				for _, c := range pod.Status.ContainerStatuses {
-					name := c.Name
-					state := c.State
+		if apierrors.IsTooManyRequests(err) || strings.Contains(strings.ToLower(err.Error()), "throttl") {
+			backoff := time.Duration(rand.Intn(500)+500*attempt) * time.Millisecond
+			mu.Lock()
+			logging.Warn("Scan job on namespace: %s ... client-side API rate limit hit (attempt %d/%d, retrying in %s)",
+				ns, attempt, maxRetries, backoff)
+			mu.Unlock()
+			time.Sleep(backoff)
+			continue
+		}
 
-					if state.Waiting != nil {
-						reason := state.Waiting.Reason
-						if strings.Contains(reason, "BackOff") || strings.Contains(reason, "Err") {
-							issues = append(issues,
-								fmt.Sprintf("Container %s waiting: %s", name, reason))
-						}
-					}
+		if errors.Is(err, context.DeadlineExceeded) {
+			mu.Lock()
+			logging.Warn("Scan job on namespace: %s ... API slow or busy (attempt %d/%d)", ns, attempt, maxRetries)
+			mu.Unlock()
+			time.Sleep(1 * time.Second)
+			continue
+		}
 
-					if state.Terminated != nil && state.Terminated.ExitCode != 0 {
-						issues = append(issues,
-							fmt.Sprintf("Container %s terminated (exit %d, reason=%s)",
-								name,
-								state.Terminated.ExitCode,
-								state.Terminated.Reason))
-					}
+		// Non-retryable error
+		return nil, fmt.Errorf("unable to list pods in %s: %w", ns, err)
+	}
 
-					if !c.Ready {
-						issues = append(issues, fmt.Sprintf("Container %s not ready", name))
-					}
+	return nil, fmt.Errorf("pod listing in %s failed after %d retries: %w", ns, maxRetries, err)
+}
 
-					if c.RestartCount > 0 {
-						old := c.RestartCount
-						time.Sleep(3 * time.Second)
-						if c.RestartCount > old {
-							issues = append(issues,
-								fmt.Sprintf("Container %s restarted recently (%d restarts)", name, c.RestartCount))
-						} else {
-							issues = append(issues,
-								fmt.Sprintf("Container %s has restarted %d time(s)", name, c.RestartCount))
-						}
-					}
-				}
+// inspectPod returns every finding on a single pod at the pod, condition and container level.
+// Log excerpts, keyed by container name, are attached to the first finding about that container.
+func inspectPod(pod v1.Pod, logs map[string]*LogExcerpt) []Finding {
+	b := &findingBuilder{check: "pods", ref: podRef(pod.Namespace, pod.Name)}
+	attachLogs := func(container string) {
+		if excerpt, ok := logs[container]; ok {
+			b.findings[len(b.findings)-1].Logs = excerpt
+			delete(logs, container)
+		}
+	}
 
-				if len(issues) > 0 {
-					podFindings = append(podFindings, PodFinding{
-						PodName: podName,
-						Issues:  issues,
-					})
-				}
-			}
+	// Skip completed pods
+	if pod.Status.Phase == v1.PodSucceeded {
+		return nil
+	}
 
-			// --- Namespace summary
-			resultMsg := ""
-			if len(podFindings) > 0 {
-				resultMsg = color.RedString("FAIL (%d pods unhealthy)", len(podFindings))
-			} else {
-				resultMsg = color.GreenString("PASS (%d pods healthy)", localPods)
-			}
+	// Evicted or failed
+	if pod.Status.Reason == "Evicted" {
+		reason, action := evictionReason(pod.Status.Message)
+		b.add(SeverityCritical, reason,
+			fmt.Sprintf("Pod phase: %s (Reason: %s)", pod.Status.Phase, pod.Status.Reason),
+			action,
+			pod.Status.Message)
+	} else if pod.Status.Phase == v1.PodFailed {
+		b.add(SeverityCritical, "PodFailed",
+			fmt.Sprintf("Pod phase: %s (Reason: %s)", pod.Status.Phase, pod.Status.Reason),
+			"Review the pod events, then delete the failed pod once the cause is resolved so its controller can replace it.",
+			pod.Status.Message)
+	}
 
-			mu.Lock()
-			totalPods += localPods
-			if len(podFindings) > 0 {
-				failedNamespaces++
-				failingMap[ns] = len(podFindings)
-			}
-			results = append(results, PodCheckResult{
-				Name:        ns,
-				PodsChecked: localPods,
-				PodsFailed:  len(podFindings),
-			})
+	specs := containerSpecs(pod)
+	for _, spec := range append(append([]v1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...) {
+		inspectResourceLimits(b, spec)
+	}
 
-			fmt.Printf("%s Scan job on namespace: %s ... %s\n",
-				color.BlueString("RUNNING    "), ns, resultMsg)
+	// Pod conditions
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == v1.PodReady && cond.Status != v1.ConditionTrue {
+			b.add(SeverityCritical, "PodNotReady", fmt.Sprintf("PodReady=False (%s)", cond.Reason),
+				"Inspect the container states below and 'kubectl describe pod' for failing probes.",
+				cond.Message)
+		}
+		if cond.Type == v1.PodScheduled && cond.Status != v1.ConditionTrue {
+			b.add(SeverityCritical, "Unschedulable", fmt.Sprintf("NotScheduled (%s)", cond.Reason),
+				"Check node capacity, taints, affinity rules and PersistentVolumeClaim binding.",
+				cond.Message)
+		}
+	}
 
-			if len(podFindings) > 0 {
-				for i, pf := range podFindings {
-					prefix := "└──"
-					if i < len(podFindings)-1 {
-						prefix = "├──"
-					}
-					fmt.Printf("        %s %s\n", prefix, color.YellowString(pf.PodName))
-					for _, issue := range pf.Issues {
-						fmt.Printf("             ↳ %s\n", issue)
-					}
-				}
+	// Init containers
+	for _, init := range pod.Status.InitContainerStatuses {
+		if init.State.Terminated != nil && init.State.Terminated.ExitCode != 0 {
+			if inspectTermination(b, specs[init.Name], init.State.Terminated, false) {
+				attachLogs(init.Name)
+				continue
 			}
-			mu.Unlock()
-		}()
+			b.add(SeverityCritical, "InitContainerFailed",
+				fmt.Sprintf("Init container %s failed (%s)", init.Name, describeTermination(init.State.Terminated)),
+				fmt.Sprintf("Check the init container logs with 'kubectl logs %s -c %s -n %s'.", pod.Name, init.Name, pod.Namespace),
+				init.State.Terminated.Message)
+			attachLogs(init.Name)
+		}
 	}
 
-	wg.Wait()
+	// Main containers
+	for _, c := range pod.Status.ContainerStatuses {
+		name := c.Name
+		state := c.State
+
+		if state.Waiting != nil {
+			reason := state.Waiting.Reason
+			if strings.Contains(reason, "BackOff") || strings.Contains(reason, "Err") {
+				b.add(SeverityCritical, reason,
+					fmt.Sprintf("Container %s waiting: %s", name, reason),
+					waitingAction(pod, name, reason),
+					state.Waiting.Message)
+				attachLogs(name)
+			}
+		}
 
-	// --- Summary
-	fmt.Println()
-	fmt.Println(strings.Repeat("=", 55))
-	logging.Title("            Kobot Deep Pod Health Report\n")
-	fmt.Println(strings.Repeat("=", 55))
-	fmt.Println()
+		if state.Terminated != nil && state.Terminated.ExitCode != 0 {
+			if !inspectTermination(b, specs[name], state.Terminated, false) {
+				b.add(SeverityCritical, "ContainerTerminated",
+					fmt.Sprintf("Container %s terminated (%s)", name, describeTermination(state.Terminated)),
+					fmt.Sprintf("Check the container logs with 'kubectl logs %s -c %s -n %s'.", pod.Name, name, pod.Namespace),
+					state.Terminated.Message)
+			}
+			attachLogs(name)
+		} else if last := c.LastTerminationState.Terminated; last != nil {
+			// the current instance runs or waits; explain why the previous one died
+			if inspectTermination(b, specs[name], last, true) {
+				attachLogs(name)
+			}
+		}
 
-	fmt.Printf("Namespaces checked: %d\n", totalNamespaces)
-	fmt.Printf("Total pods checked: %d\n\n", totalPods)
+		if !c.Ready {
+			b.add(SeverityWarning, "ContainerNotReady",
+				fmt.Sprintf("Container %s not ready", name),
+				"Check the readiness probe and the container logs.")
+		}
 
-	if failedNamespaces > 0 {
-		logging.Error("%d namespace(s) failed deep pod health check.\n", failedNamespaces)
-		for ns, count := range failingMap {
-			fmt.Printf("  - %s (%d unhealthy pods)\n", ns, count)
+		if c.RestartCount > 0 {
+			var evidence []string
+			if last := c.LastTerminationState.Terminated; last != nil {
+				evidence = restartEvidence(specs[name], last)
+			}
+			b.add(SeverityWarning, "ContainerRestarts",
+				fmt.Sprintf("Container %s has restarted %d time(s)", name, c.RestartCount),
+				fmt.Sprintf("Check the previous container logs with 'kubectl logs %s -c %s -n %s --previous'.", pod.Name, name, pod.Namespace),
+				evidence...)
+			attachLogs(name)
 		}
-		fmt.Println()
-		logging.Warn("Operators should investigate failing containers, restarts, or scheduling issues.\n")
-	} else {
-		logging.Success("%d namespace(s) were scanned and reported healthy across pod, container, and condition levels.\n", totalNamespaces)
 	}
 
-	if htmlOutput {
-		if err := WriteHTMLReport(results, totalPods, totalNamespaces, failedNamespaces); err != nil {
-			logging.Error("Failed to write HTML report: %v", err)
-		} else {
-			logging.Success("HTML report saved as kobot-deep-report.html\n")
-		}
+	return b.findings
+}
+
+// waitingAction suggests the next step for a container stuck in a waiting state.
+func waitingAction(pod v1.Pod, container, reason string) string {
+	switch {
+	case strings.Contains(reason, "ImagePull"), reason == "ErrImageNeverPull", reason == "InvalidImageName":
+		return "Verify the image name and tag exist and that the pod's imagePullSecrets grant access to the registry."
+	case reason == "CreateContainerConfigError":
+		return "Verify the ConfigMaps and Secrets referenced by the container exist."
+	default:
+		return fmt.Sprintf("Check the previous container logs with 'kubectl logs %s -c %s -n %s --previous'.", pod.Name, container, pod.Namespace)
 	}
 }
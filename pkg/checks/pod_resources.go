@@ -0,0 +1,241 @@
+package checks
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// exitCodeMeanings explains the exit codes that point at a specific cause.
+var exitCodeMeanings = map[int32]string{
+	1:   "application error",
+	126: "command not executable",
+	127: "command not found",
+	134: "aborted (SIGABRT)",
+	137: "killed by SIGKILL",
+	139: "segmentation fault (SIGSEGV)",
+	143: "terminated by SIGTERM",
+}
+
+// exitCodeClass is the finding reason and remediation for a class of non-zero exits.
+type exitCodeClass struct {
+	reason, action string
+}
+
+// exitCodeClasses classifies the exit codes of containers that terminated with reason Error.
+var exitCodeClasses = map[int32]exitCodeClass{
+	126: {"CommandNotExecutable", "The container command is not executable; check its file mode and that the image's entrypoint is correct."},
+	127: {"CommandNotFound", "The container command does not exist in the image; check the command, args and the image tag."},
+	134: {"Aborted", "The process aborted itself (SIGABRT), usually on a failed assertion or a fatal runtime error; check the logs."},
+	137: {"Killed", "The container was killed (SIGKILL) without being OOM-killed, typically after failing its liveness probe; check the probe timeouts and the CPU limit."},
+	139: {"SegmentationFault", "The process crashed with a segmentation fault; check the image for native library or architecture mismatches."},
+	143: {"Terminated", "The container stopped on SIGTERM, typically when its liveness probe failed or it was shut down; check the probe and the events."},
+}
+
+// classifyExit returns the finding reason and remediation for a non-zero exit code.
+func classifyExit(code int32) exitCodeClass {
+	if class, ok := exitCodeClasses[code]; ok {
+		return class
+	}
+	return exitCodeClass{"ApplicationError", "The application exited with an error; check the container logs for the cause."}
+}
+
+// describeTermination renders a terminated state as "exit 137 (killed by SIGKILL), reason=Error".
+func describeTermination(t *v1.ContainerStateTerminated) string {
+	s := fmt.Sprintf("exit %d", t.ExitCode)
+	if meaning, ok := exitCodeMeanings[t.ExitCode]; ok {
+		s += " (" + meaning + ")"
+	}
+	if t.Reason != "" {
+		s += ", reason=" + t.Reason
+	}
+	return s
+}
+
+// containerSpecs indexes the app and init containers of a pod by name.
+func containerSpecs(pod v1.Pod) map[string]v1.Container {
+	out := make(map[string]v1.Container)
+	for _, c := range pod.Spec.InitContainers {
+		out[c.Name] = c
+	}
+	for _, c := range pod.Spec.Containers {
+		out[c.Name] = c
+	}
+	return out
+}
+
+// inspectTermination classifies how a container last terminated and adds a specific
+// finding for the failures kobot can explain: OOMKilled, ContainerCannotRun, Evicted
+// and non-zero exits with reason Error, which are classified by exit code (see
+// exitCodeClasses). It reports whether it added one.
+// A previous instance's termination is a warning: the container has been restarted,
+// and if it keeps failing the waiting state is reported as critical on its own.
+func inspectTermination(b *findingBuilder, spec v1.Container, t *v1.ContainerStateTerminated, previous bool) bool {
+	severity, when := SeverityCritical, "terminated"
+	if previous {
+		severity, when = SeverityWarning, "terminated on its last run"
+	}
+	finished := ""
+	if !t.FinishedAt.IsZero() {
+		finished = "finished at " + t.FinishedAt.Format(time.RFC3339)
+	}
+
+	switch t.Reason {
+	case "OOMKilled":
+		action, evidence := memoryAdvice(spec)
+		b.add(severity, "OOMKilled",
+			fmt.Sprintf("Container %s was OOM-killed (%s)", spec.Name, when),
+			action, evidence, finished)
+	case "ContainerCannotRun", "StartError":
+		b.add(severity, "ContainerCannotRun",
+			fmt.Sprintf("Container %s could not be started (%s)", spec.Name, describeTermination(t)),
+			"Check the container command, entrypoint and volume mounts against the image; the runtime error is shown above.",
+			t.Message, finished)
+	case "Evicted":
+		b.add(severity, "Evicted",
+			fmt.Sprintf("Container %s was evicted (%s)", spec.Name, when),
+			"Set resource requests that match the container's real usage so the kubelet does not pick it for eviction.",
+			t.Message, finished)
+	case "Error", "":
+		if t.ExitCode == 0 {
+			return false
+		}
+		class := classifyExit(t.ExitCode)
+		b.add(severity, class.reason,
+			fmt.Sprintf("Container %s %s with exit code %d", spec.Name, when, t.ExitCode),
+			class.action, describeTermination(t), throttlingEvidence(spec, t), t.Message, finished)
+	default:
+		return false
+	}
+	return true
+}
+
+// Headroom thresholds used by inspectResourceLimits.
+const (
+	// memoryOvercommitRatio is the memory limit/request ratio above which a container
+	// can grow far beyond what the scheduler reserved for it.
+	memoryOvercommitRatio = 4.0
+	// cpuThrottleRatio is the CPU limit/request ratio below which normal bursts
+	// (startup, garbage collection) are throttled.
+	cpuThrottleRatio = 1.2
+)
+
+// inspectResourceLimits reports requests and limits that are likely to hurt the
+// container: no memory limit, a memory limit without headroom above the request,
+// a memory limit far above the request that overcommits the node, and a CPU limit
+// so close to the request that the container is throttled on every burst.
+// The API server already rejects limits below requests, so those are not checked.
+func inspectResourceLimits(b *findingBuilder, spec v1.Container) {
+	memRequest, hasMemRequest := spec.Resources.Requests[v1.ResourceMemory]
+	memLimit, hasMemLimit := spec.Resources.Limits[v1.ResourceMemory]
+	memEvidence := "memory request " + quantityOrNone(memRequest, hasMemRequest) + ", limit " + quantityOrNone(memLimit, hasMemLimit)
+
+	switch {
+	case !hasMemLimit:
+		b.add(SeverityInfo, "MemoryLimitMissing",
+			fmt.Sprintf("Container %s has no memory limit", spec.Name),
+			"Set a memory limit so a leak is contained to this container instead of pushing the node into memory pressure and evicting its neighbours.",
+			memEvidence)
+	case hasMemRequest && memLimit.Cmp(memRequest) == 0:
+		b.add(SeverityInfo, "NoMemoryHeadroom",
+			fmt.Sprintf("Container %s has its memory limit equal to its request", spec.Name),
+			"The container is OOM-killed as soon as it uses more than it was scheduled with; leave headroom above the request unless Guaranteed QoS is intended.",
+			memEvidence)
+	case hasMemRequest && ratio(memLimit, memRequest) > memoryOvercommitRatio:
+		b.add(SeverityWarning, "MemoryOvercommitted",
+			fmt.Sprintf("Container %s has a memory limit more than %g times its request", spec.Name, memoryOvercommitRatio),
+			fmt.Sprintf("The node only reserves %s for it; raise the request towards the real usage or lower the limit so the node is not overcommitted and the pod is not OOM-killed or evicted under memory pressure.", memRequest.String()),
+			memEvidence)
+	}
+
+	cpuRequest, hasCPURequest := spec.Resources.Requests[v1.ResourceCPU]
+	cpuLimit, hasCPULimit := spec.Resources.Limits[v1.ResourceCPU]
+	if hasCPULimit && hasCPURequest && ratio(cpuLimit, cpuRequest) < cpuThrottleRatio {
+		b.add(SeverityWarning, "CPUThrottlingRisk",
+			fmt.Sprintf("Container %s has a CPU limit close to its request", spec.Name),
+			"The container is throttled whenever it bursts above its request, which slows startup and can fail liveness probes; raise or remove the CPU limit.",
+			fmt.Sprintf("cpu request %s, limit %s", cpuRequest.String(), cpuLimit.String()))
+	}
+}
+
+// ratio returns a/b, or +Inf when b is zero.
+func ratio(a, b resource.Quantity) float64 {
+	if b.IsZero() {
+		return math.Inf(1)
+	}
+	return float64(a.MilliValue()) / float64(b.MilliValue())
+}
+
+// memoryAdvice returns the remediation for an OOM-killed container and the
+// memory request/limit evidence it is based on.
+func memoryAdvice(spec v1.Container) (string, string) {
+	request, hasRequest := spec.Resources.Requests[v1.ResourceMemory]
+	limit, hasLimit := spec.Resources.Limits[v1.ResourceMemory]
+
+	evidence := "memory request " + quantityOrNone(request, hasRequest) + ", limit " + quantityOrNone(limit, hasLimit)
+
+	if !hasLimit {
+		// without a limit the kernel OOM killer only fires under node memory pressure
+		return "The container has no memory limit and was killed under node memory pressure; set a memory request matching its real usage (and a limit) so it is scheduled onto a node with enough memory.", evidence
+	}
+
+	action := fmt.Sprintf("Raise the memory limit above %s (e.g. %s) or reduce the application's memory usage.",
+		limit.String(), suggestedMemory(limit))
+	if hasRequest && request.Cmp(limit) < 0 {
+		action += fmt.Sprintf(" Consider raising the request (%s) as well so the pod is scheduled with the memory it needs.", request.String())
+	}
+	return action, evidence
+}
+
+// suggestedMemory proposes a new memory limit 50% above the current one, rounded up to whole MiB.
+func suggestedMemory(limit resource.Quantity) string {
+	const mi = 1 << 20
+	next := int64(math.Ceil(float64(limit.Value())*1.5/mi)) * mi
+	return resource.NewQuantity(next, resource.BinarySI).String()
+}
+
+// quantityOrNone renders a resource quantity, or "none" when it is not set.
+func quantityOrNone(q resource.Quantity, ok bool) string {
+	if !ok {
+		return "none"
+	}
+	return q.String()
+}
+
+// restartEvidence describes the last termination of a restarting container and, for
+// SIGKILLs that were not OOM kills (typically a failed liveness probe), points at the
+// CPU limit since a throttled container is often too slow to answer its probe.
+func restartEvidence(spec v1.Container, t *v1.ContainerStateTerminated) []string {
+	evidence := []string{"last run: " + describeTermination(t)}
+	if e := throttlingEvidence(spec, t); e != "" {
+		evidence = append(evidence, e)
+	}
+	return evidence
+}
+
+// throttlingEvidence points at the CPU limit of a container SIGKILLed without an OOM kill, or returns "".
+func throttlingEvidence(spec v1.Container, t *v1.ContainerStateTerminated) string {
+	if t.ExitCode != 137 || t.Reason == "OOMKilled" {
+		return ""
+	}
+	if cpu, ok := spec.Resources.Limits[v1.ResourceCPU]; ok {
+		return fmt.Sprintf("CPU limit %s: a throttled container can fail its liveness probe and be killed", cpu.String())
+	}
+	return ""
+}
+
+// evictionReason classifies a pod eviction by the resource the node ran out of.
+func evictionReason(message string) (string, string) {
+	msg := strings.ToLower(message)
+	switch {
+	case strings.Contains(msg, "ephemeral"):
+		return "EphemeralStorageEviction", "Set ephemeral-storage requests and limits on the containers writing to local disk, add a sizeLimit to emptyDir volumes, or move the data to a PersistentVolume."
+	case strings.Contains(msg, "low on resource: memory"):
+		return "MemoryPressureEviction", "Raise the memory requests of the pod's containers to their real usage so the node is not overcommitted, or add node capacity."
+	}
+	return "Evicted", "Review the pod events, then delete the evicted pod once the cause is resolved so its controller can replace it."
+}
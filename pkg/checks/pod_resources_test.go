@@ -0,0 +1,163 @@
+package checks
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// resources builds a ResourceList from alternating resource names and quantities.
+func resources(pairs ...string) v1.ResourceList {
+	list := v1.ResourceList{}
+	for i := 0; i < len(pairs); i += 2 {
+		list[v1.ResourceName(pairs[i])] = resource.MustParse(pairs[i+1])
+	}
+	return list
+}
+
+func TestClassifyExit(t *testing.T) {
+	tests := map[int32]string{
+		1:   "ApplicationError",
+		2:   "ApplicationError",
+		126: "CommandNotExecutable",
+		127: "CommandNotFound",
+		134: "Aborted",
+		137: "Killed",
+		139: "SegmentationFault",
+		143: "Terminated",
+		255: "ApplicationError",
+	}
+	for code, want := range tests {
+		class := classifyExit(code)
+		if class.reason != want || class.action == "" {
+			t.Errorf("classifyExit(%d) = %+v, want reason %s with an action", code, class, want)
+		}
+	}
+}
+
+func TestMemoryAdvice(t *testing.T) {
+	tests := []struct {
+		name         string
+		requests     v1.ResourceList
+		limits       v1.ResourceList
+		wantEvidence string
+		wantAction   []string
+	}{
+		{
+			name:         "no limit",
+			requests:     resources("memory", "256Mi"),
+			wantEvidence: "memory request 256Mi, limit none",
+			wantAction:   []string{"no memory limit", "node memory pressure"},
+		},
+		{
+			name:         "limit equals request",
+			requests:     resources("memory", "256Mi"),
+			limits:       resources("memory", "256Mi"),
+			wantEvidence: "memory request 256Mi, limit 256Mi",
+			wantAction:   []string{"above 256Mi (e.g. 384Mi)"},
+		},
+		{
+			name:         "request below limit",
+			requests:     resources("memory", "128Mi"),
+			limits:       resources("memory", "512Mi"),
+			wantEvidence: "memory request 128Mi, limit 512Mi",
+			wantAction:   []string{"above 512Mi (e.g. 768Mi)", "raising the request (128Mi)"},
+		},
+		{
+			name:         "limit only",
+			limits:       resources("memory", "1Gi"),
+			wantEvidence: "memory request none, limit 1Gi",
+			wantAction:   []string{"above 1Gi (e.g. 1536Mi)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, evidence := memoryAdvice(v1.Container{Name: "app", Resources: v1.ResourceRequirements{Requests: tt.requests, Limits: tt.limits}})
+			if evidence != tt.wantEvidence {
+				t.Errorf("evidence = %q, want %q", evidence, tt.wantEvidence)
+			}
+			for _, want := range tt.wantAction {
+				if !strings.Contains(action, want) {
+					t.Errorf("action = %q, want it to mention %q", action, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSuggestedMemory(t *testing.T) {
+	tests := map[string]string{
+		"256Mi": "384Mi",
+		"1Gi":   "1536Mi",
+		"100Mi": "150Mi",
+		"1M":    "2Mi", // 1.5 MB rounds up to whole MiB
+		"2Gi":   "3Gi",
+	}
+	for limit, want := range tests {
+		if got := suggestedMemory(resource.MustParse(limit)); got != want {
+			t.Errorf("suggestedMemory(%s) = %s, want %s", limit, got, want)
+		}
+	}
+}
+
+func TestEvictionReason(t *testing.T) {
+	tests := map[string]string{
+		"Pod ephemeral local storage usage exceeds the total limit of containers 1Gi.": "EphemeralStorageEviction",
+		"The node was low on resource: ephemeral-storage.":                             "EphemeralStorageEviction",
+		"The node was low on resource: memory. Threshold quantity: 100Mi.":             "MemoryPressureEviction",
+		"The node had condition: [DiskPressure].":                                      "Evicted",
+		"": "Evicted",
+	}
+	for message, want := range tests {
+		if reason, action := evictionReason(message); reason != want || action == "" {
+			t.Errorf("evictionReason(%q) = %s, %q, want %s with an action", message, reason, action, want)
+		}
+	}
+}
+
+func TestInspectResourceLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		requests v1.ResourceList
+		limits   v1.ResourceList
+		want     []string
+	}{
+		{name: "memory headroom, no CPU limit", requests: resources("memory", "256Mi", "cpu", "100m"), limits: resources("memory", "512Mi")},
+		{name: "no resources at all", want: []string{"info/MemoryLimitMissing"}},
+		{name: "memory limit missing", requests: resources("memory", "256Mi"), want: []string{"info/MemoryLimitMissing"}},
+		{name: "memory limit equals request", requests: resources("memory", "256Mi"), limits: resources("memory", "256Mi"), want: []string{"info/NoMemoryHeadroom"}},
+		{name: "same memory in other units", requests: resources("memory", "1Gi"), limits: resources("memory", "1024Mi"), want: []string{"info/NoMemoryHeadroom"}},
+		{name: "memory limit four times the request", requests: resources("memory", "256Mi"), limits: resources("memory", "1Gi")},
+		{name: "memory overcommitted", requests: resources("memory", "128Mi"), limits: resources("memory", "2Gi"), want: []string{"warning/MemoryOvercommitted"}},
+		{name: "memory limit only", limits: resources("memory", "512Mi")},
+		{
+			name:     "CPU limit equals request",
+			requests: resources("memory", "256Mi", "cpu", "500m"), limits: resources("memory", "512Mi", "cpu", "500m"),
+			want: []string{"warning/CPUThrottlingRisk"},
+		},
+		{
+			name:     "CPU limit just above request",
+			requests: resources("memory", "256Mi", "cpu", "1"), limits: resources("memory", "512Mi", "cpu", "1100m"),
+			want: []string{"warning/CPUThrottlingRisk"},
+		},
+		{name: "CPU limit with room to burst", requests: resources("memory", "256Mi", "cpu", "250m"), limits: resources("memory", "512Mi", "cpu", "1")},
+		{name: "CPU limit without request", limits: resources("memory", "512Mi", "cpu", "500m")},
+		{
+			name:     "both",
+			requests: resources("cpu", "200m"), limits: resources("cpu", "200m"),
+			want: []string{"info/MemoryLimitMissing", "warning/CPUThrottlingRisk"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &findingBuilder{check: "pods", ref: podRef("shop", "web-1")}
+			inspectResourceLimits(b, v1.Container{Name: "app", Resources: v1.ResourceRequirements{Requests: tt.requests, Limits: tt.limits}})
+			if got := findingReasons(b.findings); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("inspectResourceLimits() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,130 +5,90 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"time"
 	"strings"
+	"time"
 
 	// non-standard or custom packages
-	"github.com/fatih/color"                      // helps with the logging and nice colors
-	"gitlab.com/kobot/kobot/pkg/logging"          // custom package I made so my logging could look a certain way
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1" // gives us access to global types and options like GET and List options to get and list resources in the cluster
-	"k8s.io/client-go/kubernetes"                 // allows us to make a clientset to access different resources like corev1, appv1, batchv1 etc.
+	"gitlab.com/kobot/kobot/pkg/logging" // custom package I made so my logging could look a certain way
+	v1 "k8s.io/api/core/v1"              // core types like Pod and its phases
 )
 
-// RunPodCheck performs a health check for pods in one or more namespaces.
-// CLI usage: kobot check cluster
-// If no namespace is provided (-n, --namespace), it will check all namespaces.
-func RunPodCheck(clientset *kubernetes.Clientset, namespaces []string, htmlOutput bool) {
-	ctx := context.Background()
+func init() {
+	Register(podCheck{})
+}
 
-	// visual gap between the commmand in the first log message
-	fmt.Println()
+// podCheck performs a health check for pods in one or more namespaces.
+// CLI usage: kobot check cluster (add --deep for the container level analysis)
+type podCheck struct{}
 
-	// If no namespace flags were passed, list all namespaces
-	if len(namespaces) == 0 || (len(namespaces) == 1 && namespaces[0] == "") {
-		logging.Warn("No namespaces specified — checking all namespaces.")
+func (podCheck) Name() string { return "pods" }
 
-		nsList, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
-		if err != nil {
-			logging.Error("Unable to list namespaces: %v", err)
-			return
-		}
+func (podCheck) Description() string {
+	return "Pod readiness across namespaces (container and condition level with --deep)"
+}
 
-		namespaces = make([]string, len(nsList.Items))
-		for i, ns := range nsList.Items {
-			namespaces[i] = ns.Name
-		}
+func (podCheck) RequiredClients() []Client { return []Client{KubeClient} }
+
+func (p podCheck) Run(ctx context.Context, env *Env) (*Result, error) {
+	if env.Deep {
+		return runPodDeepScan(ctx, env)
 	}
+	return runPodScan(ctx, env)
+}
 
-	logging.Info("Scanning pod health across %d namespace(s).", len(namespaces))
+// runPodScan is the quick scan: any pod that is not Running or Succeeded is reported.
+func runPodScan(ctx context.Context, env *Env) (*Result, error) {
+	logging.Info("Scanning pod health across %d namespace(s).", len(env.Namespaces))
 	logging.Starting("Operator-initiated pod readiness check")
-	time.Sleep(2 * time.Second)
-	fmt.Println()
-	time.Sleep(2 * time.Second) // short grace period for pods that are still starting
 
-	var totalNamespaces, totalPods, failedNamespaces int
-	failingMap := make(map[string]int) // ns -> failed pod count
-	var results []PodCheckResult       // for HTML output
+	result := &Result{Resource: "pods"}
+	owners := newOwnerResolver(env.Clientset)
 
 	// Iterate through all namespaces to check their pod health
-	for _, ns := range namespaces {
-		nsCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-
-		totalNamespaces++
+	for _, ns := range env.Namespaces {
 		logging.Running("Scan job on namespace: %s", ns)
 
-		pods, err := clientset.CoreV1().Pods(ns).List(nsCtx, metav1.ListOptions{})
+		nsCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		pods, err := env.Clientset.CoreV1().Pods(ns).List(nsCtx, env.podListOptions())
+		cancel()
 		if err != nil {
+			msg := fmt.Sprintf("unable to list pods in %s: %v", ns, err)
 			if errors.Is(err, context.DeadlineExceeded) {
-				fmt.Printf("   %s %s\n", color.YellowString("WARN:"), fmt.Sprintf("Timeout listing pods in %s — API slow or busy", ns))
-			} else {
-				fmt.Printf("   %s %s\n", color.RedString("ERROR:"), fmt.Sprintf("Unable to list pods in %s: %v", ns, err))
+				msg = fmt.Sprintf("timeout listing pods in %s — API slow or busy", ns)
 			}
-			failedNamespaces++
+			result.Namespaces = append(result.Namespaces, NamespaceResult{Name: ns, Error: msg})
 			continue
 		}
 
-		totalPods += len(pods.Items)
-		var nonRunning []string
+		result.Namespaces = append(result.Namespaces, NamespaceResult{Name: ns, Checked: len(pods.Items)})
 
 		for _, pod := range pods.Items {
-			if pod.Status.Phase != "Running" && pod.Status.Phase != "Succeeded" {
-				nonRunning = append(nonRunning, fmt.Sprintf("%s (%s)", pod.Name, pod.Status.Phase))
+			if pod.Status.Phase == v1.PodRunning || pod.Status.Phase == v1.PodSucceeded {
+				continue
 			}
-		}
 
-		results = append(results, PodCheckResult{
-			Name:        ns,
-			PodsChecked: len(pods.Items),
-			PodsFailed:  len(nonRunning),
-			FailedPods:  nonRunning,
-		})
-
-		if len(nonRunning) > 0 {
-			fmt.Printf("   %s %s (%d pods not running)\n", color.RedString("FAIL:"), ns, len(nonRunning))
-			for i, p := range nonRunning {
-				prefix := "└──"
-				if i < len(nonRunning)-1 {
-					prefix = "├──"
-				}
-				fmt.Printf("        %s %s\n", prefix, color.YellowString(p))
+			// pods that are still starting are a warning, anything else is broken
+			severity := SeverityCritical
+			if pod.Status.Phase == v1.PodPending {
+				severity = SeverityWarning
 			}
-			failedNamespaces++
-			failingMap[ns] = len(nonRunning)
-		} else {
-			fmt.Printf("   %s %s (%d pods running)\n", color.GreenString("PASS:"), ns, len(pods.Items))
-		}
-	}
 
-	// --- Summary report
-	fmt.Println()
-	fmt.Println(strings.Repeat("=", 55))
-	logging.Title("            Kobot Deep Pod Health Report\n")
-	fmt.Println(strings.Repeat("=", 55))
-	fmt.Println()
-
-	fmt.Printf("Namespaces checked: %d\n", totalNamespaces)
-	fmt.Printf("Total pods checked: %d\n\n", totalPods)
-
-	if failedNamespaces > 0 {
-		logging.Error("%d namespace(s) failed pod health check.\n", failedNamespaces)
-		fmt.Println("Failing namespaces:")
-		for ns, count := range failingMap {
-			fmt.Printf("  - %s (%d pods not running)\n", ns, count)
+			finding := Finding{
+				CheckID:  "pods",
+				Severity: severity,
+				Reason:   "Pod" + string(pod.Status.Phase),
+				Resource: podRef(ns, pod.Name),
+				Message:  fmt.Sprintf("Pod phase: %s", pod.Status.Phase),
+				Action:   "Rerun with --deep or run 'kubectl describe pod' to see the container and scheduling details.",
+			}
+			if pod.Status.Reason != "" || pod.Status.Message != "" {
+				finding.Evidence = append(finding.Evidence, strings.TrimSpace(pod.Status.Reason+" "+pod.Status.Message))
+			}
+			findings := []Finding{finding}
+			setPodOwners(ctx, owners, pod, findings)
+			result.Findings = append(result.Findings, findings...)
 		}
-		fmt.Println()
-		logging.Warn("Operators should perform a deeper analysis of those namespaces to ensure critical applications are operational.\n")
-	} else {
-		logging.Success("%d namespace(s) were scanned and reported healthy.\n", totalNamespaces)
 	}
 
-	// Generate HTML report if requested
-	if htmlOutput {
-		if err := WriteHTMLReport(results, totalPods, totalNamespaces, failedNamespaces); err != nil {
-			logging.Error("Failed to write HTML report: %v", err)
-		} else {
-			logging.Success("HTML report saved as kobot-report.html\n")
-		}
-	}
+	return result, nil
 }
@@ -1,13 +1,81 @@
 package checks
 
 import (
+	"fmt"
 	"html/template"
 	"os"
+	"strings"
 )
 
-// WriteHTMLReport writes an HTML summary file of all namespace checks.
-func WriteHTMLReport(results []PodCheckResult, totalPods, totalNamespaces, failed int) error {
+// htmlNamespaceRow is one namespace line of a check table in the HTML report.
+type htmlNamespaceRow struct {
+	Name    string
+	Checked int
+	Failed  int
+	Error   string
+	Status  Severity
+	Groups  []htmlFindingGroup
+}
+
+// htmlFindingGroup is a FindingGroup with the labels the HTML template shows for it.
+type htmlFindingGroup struct {
+	FindingGroup
+	Grouped bool
+	// Owned is set for a single object shown under its controller, e.g. "Deployment → Pod".
+	Owned               bool
+	MemberNoun          string
+	MemberList          string
+	RepresentativeCount int
+	Others              string
+}
+
+// htmlGroups prepares the finding groups of one namespace for the template.
+func htmlGroups(findings []Finding) []htmlFindingGroup {
+	var out []htmlFindingGroup
+	for _, g := range groupFindings(findings) {
+		var others []string
+		for _, reason := range g.otherReasons() {
+			others = append(others, fmt.Sprintf("%s (%d)", reason, g.Reasons[reason]))
+		}
+		out = append(out, htmlFindingGroup{
+			FindingGroup:        g,
+			Grouped:             g.grouped() && len(g.Members) > 1,
+			Owned:               g.grouped() && len(g.Members) == 1,
+			MemberNoun:          g.memberNoun(),
+			MemberList:          memberList(g.Members),
+			RepresentativeCount: g.Reasons[g.Representative.Reason],
+			Others:              strings.Join(others, ", "),
+		})
+	}
+	return out
+}
+
+// htmlCheckSection is the HTML view of a single check result.
+type htmlCheckSection struct {
+	Check       string
+	Description string
+	Resource    string
+	Error       string
+	Skipped     string
+	Rows        []htmlNamespaceRow
+	Waived      []Finding
+}
+
+// WriteHTMLReport writes an HTML summary file of all check results to path.
+func WriteHTMLReport(results []*Result, path string) error {
 	tmpl := `
+	{{define "finding"}}
+		{{.Message}}
+		{{range .Evidence}}<br><span class="evidence">{{.}}</span>{{end}}
+		{{range .Events}}<br><span class="event">event</span> <span class="evidence">{{.}}</span>{{end}}
+		{{with .Logs}}
+		<br><span class="event">logs</span> <span class="evidence">{{logTitle .}}</span>
+		<pre class="logs">{{range .Lines}}{{if .Match}}<span class="critical">{{.Text}}</span>{{else}}{{.Text}}{{end}}
+{{end}}</pre>
+		{{end}}
+		{{if .Dependents}}<br><span class="critical">Blocks: {{refList .Dependents}}</span>{{end}}
+		{{if .Action}}<br><i>Suggested action: {{.Action}}</i>{{end}}
+	{{end}}
 	<!DOCTYPE html>
 	<html>
 	<head>
@@ -16,56 +84,139 @@ func WriteHTMLReport(results []PodCheckResult, totalPods, totalNamespaces, faile
 		<style>
 			body { font-family: Arial, sans-serif; margin: 40px; color: #333; }
 			h1 { color: #326CE5; }
+			h2 { margin-top: 40px; }
 			table { border-collapse: collapse; width: 100%; margin-top: 20px; }
-			th, td { border: 1px solid #ccc; padding: 8px 12px; text-align: left; }
+			th, td { border: 1px solid #ccc; padding: 8px 12px; text-align: left; vertical-align: top; }
 			th { background-color: #f2f2f2; }
+			ul { margin: 0; padding-left: 18px; }
 			.pass { color: green; font-weight: bold; }
 			.fail { color: red; font-weight: bold; }
+			.warn { color: #b38f00; font-weight: bold; }
+			.error { color: #b35900; font-weight: bold; }
+			.critical { color: red; }
+			.warning { color: #b38f00; }
+			.info { color: #326CE5; }
+			.evidence { color: #777; font-family: monospace; }
+			.event { color: #8a2be2; }
+			.logs { background: #f7f7f7; border: 1px solid #ddd; padding: 6px; margin: 4px 0; font-size: 12px; white-space: pre-wrap; }
 		</style>
 	</head>
 	<body>
 		<h1>Kobot Health Check Report</h1>
-		<p><b>Total Namespaces:</b> {{.TotalNamespaces}} |
-		<b>Total Pods:</b> {{.TotalPods}} |
-		<b>Failed Namespaces:</b> {{.Failed}}</p>
-		<table>
-			<tr>
-				<th>Namespace</th>
-				<th>Status</th>
-				<th>Pods Checked</th>
-				<th>Pods Failed</th>
-			</tr>
-			{{range .Results}}
+		{{range .Sections}}
+			<h2>{{.Check}}</h2>
+			<p>{{.Description}}</p>
+			{{if .Error}}
+				<p class="error">Check did not run: {{.Error}}</p>
+			{{else if .Skipped}}
+				<p class="evidence">Skipped: {{.Skipped}}</p>
+			{{else}}
+			<table>
 				<tr>
-					<td>{{.Name}}</td>
-					{{if gt .PodsFailed 0}}
-						<td class="fail">FAIL</td>
-					{{else}}
-						<td class="pass">PASS</td>
-					{{end}}
-					<td>{{.PodsChecked}}</td>
-					<td>{{.PodsFailed}}</td>
+					<th>Namespace</th>
+					<th>Status</th>
+					<th>{{.Resource}} Checked</th>
+					<th>{{.Resource}} Failed</th>
+					<th>Findings</th>
 				</tr>
+				{{range .Rows}}
+					<tr>
+						<td>{{.Name}}</td>
+						{{if .Error}}
+							<td class="error">ERROR</td>
+						{{else if eq .Status "critical"}}
+							<td class="fail">FAIL</td>
+						{{else if eq .Status "warning"}}
+							<td class="warn">WARN</td>
+						{{else}}
+							<td class="pass">PASS</td>
+						{{end}}
+						<td>{{.Checked}}</td>
+						<td>{{.Failed}}</td>
+						<td>
+							{{if .Error}}{{.Error}}{{end}}
+							<ul>
+							{{range .Groups}}
+								{{if .Grouped}}
+								<li>
+									<span class="{{.Severity}}">[{{.Severity}}]</span>
+									<b>{{.Owner}}</b> ({{len .Members}} {{.MemberNoun}}: {{.MemberList}})
+									<br>{{template "finding" .Representative}}
+									<br><span class="evidence">seen on {{.RepresentativeCount}} of {{len .Members}} {{.MemberNoun}}, e.g. {{.Representative.Resource}}</span>
+									{{if .Others}}<br><span class="evidence">also: {{.Others}}</span>{{end}}
+								</li>
+								{{else}}
+								{{$group := .}}
+								{{range .Findings}}
+								<li>
+									<span class="{{.Severity}}">[{{.Severity}}]</span>
+									{{if $group.Owned}}<b>{{$group.Owner}}</b> <span class="evidence">→ {{.Resource}}</span>{{else}}<b>{{.Resource}}</b>{{end}}:
+									{{if .CausedBy}}
+									<span class="evidence">blocked by {{refList .CausedBy}}</span>
+									{{else}}
+									{{template "finding" .}}
+									{{end}}
+								</li>
+								{{end}}
+								{{end}}
+							{{end}}
+							</ul>
+						</td>
+					</tr>
+				{{end}}
+			</table>
+			{{if .Waived}}
+			<p class="evidence">Waived findings:</p>
+			<ul>
+			{{range .Waived}}
+				<li class="evidence">
+					[{{.Severity}}] <b>{{qualified .Resource}}</b> {{.Reason}}: {{.Message}}
+					<br>waived{{with .Waiver.Expires}} until {{.}}{{end}}: {{.Waiver.Reason}}
+				</li>
+			{{end}}
+			</ul>
 			{{end}}
-		</table>
+			{{end}}
+		{{end}}
 	</body>
 	</html>
 	`
 
+	var sections []htmlCheckSection
+	for _, r := range results {
+		section := htmlCheckSection{
+			Check:       r.Check,
+			Description: r.Description,
+			Resource:    r.Resource,
+			Error:       r.Error,
+			Skipped:     r.Skipped,
+			Waived:      r.Waived,
+		}
+		for _, ns := range r.Namespaces {
+			// skip namespaces without any of the checked objects to keep the report readable
+			if ns.Checked == 0 && ns.Error == "" && r.Failed(ns.Name) == 0 {
+				continue
+			}
+			section.Rows = append(section.Rows, htmlNamespaceRow{
+				Name:    scopeLabel(ns.Name),
+				Checked: ns.Checked,
+				Failed:  r.Failed(ns.Name),
+				Error:   ns.Error,
+				Status:  r.MaxSeverity(ns.Name),
+				Groups:  htmlGroups(namespaceFindings(r, ns.Name)),
+			})
+		}
+		sections = append(sections, section)
+	}
+
 	data := struct {
-		Results         []PodCheckResult
-		TotalPods       int
-		TotalNamespaces int
-		Failed          int
+		Sections []htmlCheckSection
 	}{
-		Results:         results,
-		TotalPods:       totalPods,
-		TotalNamespaces: totalNamespaces,
-		Failed:          failed,
+		Sections: sections,
 	}
 
-	t := template.Must(template.New("report").Parse(tmpl))
-	f, err := os.Create("kobot-report.html")
+	t := template.Must(template.New("report").Funcs(template.FuncMap{"refList": refList, "logTitle": logExcerptTitle, "qualified": qualifiedRef}).Parse(tmpl))
+	f, err := os.Create(path)
 	if err != nil {
 		return err
 	}
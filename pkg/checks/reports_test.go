@@ -0,0 +1,83 @@
+package checks
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// captureStdout returns what fn prints to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+	w.Close()
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestSingleMemberGroupRendering(t *testing.T) {
+	owner := workloadRef("Deployment", "shop", "web")
+	tests := []struct {
+		name        string
+		owner       *ResourceRef
+		wantConsole string
+		wantHTML    string
+	}{
+		{
+			name:        "pod under its controller",
+			owner:       &owner,
+			wantConsole: "Deployment/web → Pod/web-7d9f-abcde",
+			wantHTML:    "<b>Deployment/web</b> <span class=\"evidence\">→ Pod/web-7d9f-abcde</span>:",
+		},
+		{
+			name:        "bare pod",
+			wantConsole: "└── Pod/web-7d9f-abcde\n",
+			wantHTML:    "<b>Pod/web-7d9f-abcde</b>:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := []Finding{{
+				CheckID:  "pods",
+				Severity: SeverityCritical,
+				Reason:   "CrashLoopBackOff",
+				Resource: podRef("shop", "web-7d9f-abcde"),
+				Owner:    tt.owner,
+				Message:  "Container app waiting: CrashLoopBackOff",
+			}}
+
+			console := captureStdout(t, func() { printFindingTree(findings) })
+			if !strings.Contains(console, tt.wantConsole) || !strings.Contains(console, "Container app waiting: CrashLoopBackOff") {
+				t.Errorf("console output = %q, want %q and the finding", console, tt.wantConsole)
+			}
+
+			path := filepath.Join(t.TempDir(), "report.html")
+			result := &Result{Check: "pods", Resource: "Pods", Namespaces: []NamespaceResult{{Name: "shop", Checked: 1}}, Findings: findings}
+			if err := WriteHTMLReport([]*Result{result}, path); err != nil {
+				t.Fatal(err)
+			}
+			html, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(html), tt.wantHTML) || !strings.Contains(string(html), "Container app waiting: CrashLoopBackOff") {
+				t.Errorf("HTML report does not contain %q and the finding:\n%s", tt.wantHTML, html)
+			}
+		})
+	}
+}
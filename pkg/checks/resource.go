@@ -0,0 +1,190 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gitlab.com/kobot/kobot/pkg/logging"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// reconcilingGrace is how long an object may report Reconciling=True before it is a warning.
+	reconcilingGrace = 10 * time.Minute
+	// terminatingGrace is how long an object may wait on its finalizers after deletion.
+	terminatingGrace = 5 * time.Minute
+)
+
+// ParseResource parses a resource given as group/version/resource (e.g.
+// "postgresql.cnpg.io/v1/clusters") or version/resource for the core API group.
+func ParseResource(s string) (schema.GroupVersionResource, error) {
+	parts := strings.Split(strings.TrimSpace(s), "/")
+	for _, p := range parts {
+		if p == "" {
+			return schema.GroupVersionResource{}, fmt.Errorf("invalid resource %q (expected group/version/resource)", s)
+		}
+	}
+	switch len(parts) {
+	case 2:
+		return schema.GroupVersionResource{Version: parts[0], Resource: strings.ToLower(parts[1])}, nil
+	case 3:
+		return schema.GroupVersionResource{Group: parts[0], Version: parts[1], Resource: strings.ToLower(parts[2])}, nil
+	}
+	return schema.GroupVersionResource{}, fmt.Errorf("invalid resource %q (expected group/version/resource)", s)
+}
+
+// resourceCheck evaluates any resource with the kstatus conventions most operators
+// follow: Stalled, Ready, Available and Reconciling conditions, status.observedGeneration
+// against metadata.generation, spec.suspend and objects stuck on their finalizers.
+// It is not registered; NewResourceCheck builds one per requested resource.
+type resourceCheck struct {
+	gvr schema.GroupVersionResource
+}
+
+// NewResourceCheck returns the generic condition-based check for one resource.
+func NewResourceCheck(gvr schema.GroupVersionResource) Check {
+	return resourceCheck{gvr: gvr}
+}
+
+func (c resourceCheck) Name() string {
+	if c.gvr.Group == "" {
+		return c.gvr.Resource
+	}
+	return c.gvr.Resource + "." + c.gvr.Group
+}
+
+func (c resourceCheck) Description() string {
+	return fmt.Sprintf("Generic condition, generation and suspension health of %s", c.gvr.String())
+}
+
+func (resourceCheck) RequiredClients() []Client { return []Client{KubeClient, DynamicClient} }
+
+func (c resourceCheck) Run(ctx context.Context, env *Env) (*Result, error) {
+	logging.Info("Scanning %s with the generic condition rules.", c.gvr.String())
+	logging.Starting("Operator-initiated %s health check", c.Name())
+
+	api, err := discoverResource(env, c.gvr)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Resource: c.gvr.Resource}
+	now := time.Now()
+
+	if !api.Namespaced {
+		listCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		list, err := env.Dynamic.Resource(c.gvr).List(listCtx, metav1.ListOptions{})
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("unable to list %s: %w", c.gvr.Resource, err)
+		}
+		result.Namespaces = append(result.Namespaces, NamespaceResult{Name: ClusterScope, Checked: len(list.Items)})
+		for _, obj := range list.Items {
+			result.Findings = append(result.Findings, inspectResource(c.Name(), obj, now)...)
+		}
+		return result, nil
+	}
+
+	for _, ns := range env.Namespaces {
+		logging.Running("Scan job on namespace: %s", ns)
+
+		nsCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		list, err := env.Dynamic.Resource(c.gvr).Namespace(ns).List(nsCtx, metav1.ListOptions{})
+		cancel()
+		if err != nil {
+			result.Namespaces = append(result.Namespaces, NamespaceResult{
+				Name:  ns,
+				Error: fmt.Sprintf("unable to list %s in %s: %v", c.gvr.Resource, ns, err),
+			})
+			continue
+		}
+
+		result.Namespaces = append(result.Namespaces, NamespaceResult{Name: ns, Checked: len(list.Items)})
+		for _, obj := range list.Items {
+			result.Findings = append(result.Findings, inspectResource(c.Name(), obj, now)...)
+		}
+	}
+
+	return result, nil
+}
+
+// discoverResource looks a resource up in the API server's discovery document, which
+// tells whether it is namespaced and confirms the cluster serves it at all.
+func discoverResource(env *Env, gvr schema.GroupVersionResource) (metav1.APIResource, error) {
+	list, err := env.Clientset.Discovery().ServerResourcesForGroupVersion(gvr.GroupVersion().String())
+	if apierrors.IsNotFound(err) {
+		return metav1.APIResource{}, fmt.Errorf("API %s is not available on this cluster", gvr.GroupVersion())
+	}
+	if err != nil {
+		return metav1.APIResource{}, fmt.Errorf("unable to discover %s: %w", gvr.GroupVersion(), err)
+	}
+	for _, r := range list.APIResources {
+		if r.Name == gvr.Resource {
+			return r, nil
+		}
+	}
+	return metav1.APIResource{}, fmt.Errorf("resource %q is not served by %s", gvr.Resource, gvr.GroupVersion())
+}
+
+// inspectResource applies the kstatus-style rules to a single object.
+func inspectResource(check string, obj unstructured.Unstructured, now time.Time) []Finding {
+	b := &findingBuilder{check: check, ref: customResourceRef(obj)}
+	kind := obj.GetKind()
+	describe := fmt.Sprintf("kubectl describe %s %s", strings.ToLower(kind), obj.GetName())
+	if obj.GetNamespace() != "" {
+		describe += " -n " + obj.GetNamespace()
+	}
+
+	if deleted := obj.GetDeletionTimestamp(); deleted != nil && now.Sub(deleted.Time) > terminatingGrace {
+		b.add(SeverityWarning, "Terminating", fmt.Sprintf("%s has been terminating for %s", kind, now.Sub(deleted.Time).Round(time.Minute)),
+			"Check the controller owning the finalizers; it may be gone or failing to clean up.",
+			"finalizers "+strings.Join(obj.GetFinalizers(), ", "))
+	}
+
+	if suspended, found, _ := unstructured.NestedBool(obj.Object, "spec", "suspend"); found && suspended {
+		b.add(SeverityWarning, "Suspended", fmt.Sprintf("%s is suspended", kind),
+			"Confirm the suspension is intended; the controller does not reconcile it.")
+		return b.findings
+	}
+
+	if observed, found, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration"); found && observed < obj.GetGeneration() {
+		b.add(SeverityWarning, "GenerationNotObserved",
+			fmt.Sprintf("Controller has not reconciled the latest spec (generation %d, observed %d)", obj.GetGeneration(), observed),
+			"Check that the operator managing this resource is running.")
+	}
+
+	conditions := conditionsOf(obj)
+	if cond, ok := findCondition(conditions, "Stalled"); ok && cond.status == "True" {
+		b.add(SeverityCritical, "Stalled", fmt.Sprintf("%s is stalled (Reason: %s)", kind, cond.reason),
+			fmt.Sprintf("The controller gave up retrying; fix the cause shown by '%s'.", describe),
+			cond.message)
+		return b.findings
+	}
+
+	for _, condType := range []string{"Ready", "Available"} {
+		cond, ok := findCondition(conditions, condType)
+		if !ok || cond.status == "True" {
+			continue
+		}
+		severity := SeverityCritical
+		if cond.status == "Unknown" {
+			severity = SeverityWarning
+		}
+		b.add(severity, "Not"+condType, fmt.Sprintf("%s is not %s (Reason: %s)", kind, condType, cond.reason),
+			fmt.Sprintf("Review the status with '%s' and the operator logs.", describe),
+			cond.message)
+	}
+
+	if cond, ok := findCondition(conditions, "Reconciling"); ok && cond.status == "True" && !cond.since.IsZero() && now.Sub(cond.since) > reconcilingGrace {
+		b.add(SeverityWarning, "ReconcilingTooLong", fmt.Sprintf("%s has been reconciling for %s", kind, now.Sub(cond.since).Round(time.Minute)),
+			fmt.Sprintf("Check what the controller is waiting for with '%s'.", describe),
+			conditionEvidence(cond.reason, cond.message))
+	}
+
+	return b.findings
+}
@@ -0,0 +1,121 @@
+package checks
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestInspectResource(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) string { return now.Add(-d).Format(time.RFC3339) }
+	cond := func(condType, status, since string) interface{} {
+		return map[string]interface{}{"type": condType, "status": status, "reason": "Testing", "lastTransitionTime": since}
+	}
+
+	tests := []struct {
+		name       string
+		spec       map[string]interface{}
+		status     map[string]interface{}
+		generation int64
+		deleted    time.Duration // how long ago deletion was requested, 0 for not deleted
+		want       []string
+	}{
+		{name: "ready", status: map[string]interface{}{"conditions": []interface{}{cond("Ready", "True", ago(time.Hour))}}},
+		{name: "no conditions", status: map[string]interface{}{}},
+		{name: "not ready", status: map[string]interface{}{"conditions": []interface{}{cond("Ready", "False", ago(time.Hour))}}, want: []string{"critical/NotReady"}},
+		{name: "readiness unknown", status: map[string]interface{}{"conditions": []interface{}{cond("Ready", "Unknown", ago(time.Hour))}}, want: []string{"warning/NotReady"}},
+		{name: "not available", status: map[string]interface{}{"conditions": []interface{}{cond("Available", "False", ago(time.Hour))}}, want: []string{"critical/NotAvailable"}},
+		{
+			name:   "stalled hides readiness",
+			status: map[string]interface{}{"conditions": []interface{}{cond("Stalled", "True", ago(time.Hour)), cond("Ready", "False", ago(time.Hour))}},
+			want:   []string{"critical/Stalled"},
+		},
+		{name: "reconciling briefly", status: map[string]interface{}{"conditions": []interface{}{cond("Reconciling", "True", ago(time.Minute))}}},
+		{name: "reconciling too long", status: map[string]interface{}{"conditions": []interface{}{cond("Reconciling", "True", ago(time.Hour))}}, want: []string{"warning/ReconcilingTooLong"}},
+		{name: "reconciling without a time", status: map[string]interface{}{"conditions": []interface{}{cond("Reconciling", "True", "")}}},
+		{name: "generation not observed", generation: 3, status: map[string]interface{}{"observedGeneration": int64(2)}, want: []string{"warning/GenerationNotObserved"}},
+		{name: "generation observed", generation: 3, status: map[string]interface{}{"observedGeneration": int64(3)}},
+		{
+			name:   "suspended skips the status",
+			spec:   map[string]interface{}{"suspend": true},
+			status: map[string]interface{}{"conditions": []interface{}{cond("Ready", "False", ago(time.Hour))}},
+			want:   []string{"warning/Suspended"},
+		},
+		{name: "terminating briefly", deleted: time.Minute},
+		{name: "stuck terminating", deleted: time.Hour, want: []string{"warning/Terminating"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "postgresql.cnpg.io/v1",
+				"kind":       "Cluster",
+				"metadata":   map[string]interface{}{"namespace": "db", "name": "main"},
+				"spec":       map[string]interface{}{},
+				"status":     tt.status,
+			}}
+			if tt.spec != nil {
+				obj.Object["spec"] = tt.spec
+			}
+			obj.SetGeneration(tt.generation)
+			if tt.deleted > 0 {
+				deleted := metav1.NewTime(now.Add(-tt.deleted))
+				obj.SetDeletionTimestamp(&deleted)
+				obj.SetFinalizers([]string{"cnpg.io/cleanup"})
+			}
+
+			findings := inspectResource("clusters.postgresql.cnpg.io", obj, now)
+			if got := findingReasons(findings); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("inspectResource() = %v, want %v", got, tt.want)
+			}
+			for _, f := range findings {
+				if f.CheckID != "clusters.postgresql.cnpg.io" || f.Resource.Group != "postgresql.cnpg.io" {
+					t.Errorf("finding = %+v, want it attributed to the check and the Cluster", f)
+				}
+			}
+		})
+	}
+}
+
+func TestDiscoverResource(t *testing.T) {
+	cs := fake.NewSimpleClientset()
+	cs.Resources = []*metav1.APIResourceList{{
+		GroupVersion: "postgresql.cnpg.io/v1",
+		APIResources: []metav1.APIResource{{Name: "clusters", Kind: "Cluster", Namespaced: true}},
+	}}
+	env := &Env{Clientset: cs}
+
+	tests := []struct {
+		gvr     schema.GroupVersionResource
+		wantErr string
+	}{
+		{gvr: schema.GroupVersionResource{Group: "postgresql.cnpg.io", Version: "v1", Resource: "clusters"}},
+		{gvr: schema.GroupVersionResource{Group: "postgresql.cnpg.io", Version: "v1", Resource: "backups"}, wantErr: `resource "backups" is not served by postgresql.cnpg.io/v1`},
+		{gvr: schema.GroupVersionResource{Group: "postgresql.cnpg.io", Version: "v2", Resource: "clusters"}, wantErr: "API postgresql.cnpg.io/v2 is not available on this cluster"},
+	}
+	for _, tt := range tests {
+		api, err := discoverResource(env, tt.gvr)
+		switch {
+		case tt.wantErr == "" && (err != nil || !api.Namespaced):
+			t.Errorf("discoverResource(%s) = %+v, %v, want the namespaced clusters resource", tt.gvr, api, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("discoverResource(%s) error = %v, want %q", tt.gvr, err, tt.wantErr)
+		}
+	}
+}
+
+func TestResourceCheckName(t *testing.T) {
+	if got := NewResourceCheck(schema.GroupVersionResource{Group: "postgresql.cnpg.io", Version: "v1", Resource: "clusters"}).Name(); got != "clusters.postgresql.cnpg.io" {
+		t.Errorf("Name() = %q", got)
+	}
+	if got := NewResourceCheck(schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}).Name(); got != "configmaps" {
+		t.Errorf("Name() of a core resource = %q", got)
+	}
+}
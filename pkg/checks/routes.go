@@ -0,0 +1,449 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gitlab.com/kobot/kobot/pkg/logging"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func init() {
+	Register(routeCheck{})
+}
+
+// Gateway API kinds, read through the dynamic client since they are CRDs.
+var (
+	gatewayClassKind = apiKind{Kind: "GatewayClass", Group: "gateway.networking.k8s.io", Resource: "gatewayclasses", Versions: []string{"v1", "v1beta1"}}
+	gatewayKind      = apiKind{Kind: "Gateway", Group: "gateway.networking.k8s.io", Resource: "gateways", Versions: []string{"v1", "v1beta1"}}
+	httpRouteKind    = apiKind{Kind: "HTTPRoute", Group: "gateway.networking.k8s.io", Resource: "httproutes", Versions: []string{"v1", "v1beta1"}}
+)
+
+// routeCheck validates the routing layer in front of the workloads: Ingresses and
+// Gateway API Gateways and HTTPRoutes, their classes, backends and TLS secrets.
+type routeCheck struct{}
+
+func (routeCheck) Name() string { return "routes" }
+
+func (routeCheck) Description() string {
+	return "Ingress and Gateway API classes, backends, TLS secrets and route status"
+}
+
+func (routeCheck) RequiredClients() []Client { return []Client{KubeClient, DynamicClient} }
+
+func (routeCheck) Run(ctx context.Context, env *Env) (*Result, error) {
+	logging.Info("Scanning Ingresses and Gateway API routes across %d namespace(s).", len(env.Namespaces))
+	logging.Starting("Operator-initiated route validation check")
+
+	result := &Result{Resource: "routes"}
+	lister := newAPILister(env.Dynamic)
+	backends := newBackendIndex(ctx, env)
+	now := time.Now()
+
+	clusterCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	ingressClasses, defaultIngressClass, err := listIngressClasses(clusterCtx, env)
+	if err != nil {
+		logging.Warn("Unable to list IngressClasses, skipping the class check: %v", err)
+	}
+	gatewayClasses, err := lister.list(clusterCtx, gatewayClassKind, "")
+	cancel()
+	if err != nil {
+		logging.Warn("Unable to list GatewayClasses, skipping the class check: %v", err)
+	}
+	// nil when the classes are unknown, so Gateways are not compared against an empty set
+	var gatewayClassNames map[string]bool
+	if _, installed := lister.gvr(gatewayClassKind); installed && err == nil {
+		gatewayClassNames = make(map[string]bool, len(gatewayClasses))
+		for _, gc := range gatewayClasses {
+			gatewayClassNames[gc.GetName()] = true
+		}
+	}
+
+	for _, ns := range env.Namespaces {
+		logging.Running("Scan job on namespace: %s", ns)
+		nsResult := NamespaceResult{Name: ns}
+		var errs []string
+
+		nsCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		if ingresses, err := env.Clientset.NetworkingV1().Ingresses(ns).List(nsCtx, metav1.ListOptions{}); err != nil {
+			errs = append(errs, fmt.Sprintf("unable to list Ingresses: %v", err))
+		} else {
+			nsResult.Checked += len(ingresses.Items)
+			for _, ing := range ingresses.Items {
+				result.Findings = append(result.Findings, inspectIngress(ing, ingressClasses, defaultIngressClass, backends, now)...)
+			}
+		}
+
+		if gateways, err := lister.list(nsCtx, gatewayKind, ns); err != nil {
+			errs = append(errs, fmt.Sprintf("unable to list Gateways: %v", err))
+		} else {
+			nsResult.Checked += len(gateways)
+			for _, gw := range gateways {
+				result.Findings = append(result.Findings, inspectGateway(gw, gatewayClassNames, backends, now)...)
+			}
+		}
+
+		if routes, err := lister.list(nsCtx, httpRouteKind, ns); err != nil {
+			errs = append(errs, fmt.Sprintf("unable to list HTTPRoutes: %v", err))
+		} else {
+			nsResult.Checked += len(routes)
+			for _, route := range routes {
+				result.Findings = append(result.Findings, inspectHTTPRoute(route, backends)...)
+			}
+		}
+		cancel()
+
+		nsResult.Error = strings.Join(errs, "; ")
+		result.Namespaces = append(result.Namespaces, nsResult)
+	}
+
+	return result, nil
+}
+
+// listIngressClasses returns the IngressClass names and the default class, if one is marked.
+func listIngressClasses(ctx context.Context, env *Env) (map[string]bool, string, error) {
+	list, err := env.Clientset.NetworkingV1().IngressClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, "", err
+	}
+	names := make(map[string]bool, len(list.Items))
+	def := ""
+	for _, ic := range list.Items {
+		names[ic.Name] = true
+		if ic.Annotations[networkingv1.AnnotationIsDefaultIngressClass] == "true" {
+			def = ic.Name
+		}
+	}
+	return names, def, nil
+}
+
+// inspectIngress checks the class, backends and TLS secrets of an Ingress.
+// classes is nil when the IngressClasses could not be listed.
+func inspectIngress(ing networkingv1.Ingress, classes map[string]bool, defaultClass string, backends *backendIndex, now time.Time) []Finding {
+	b := &findingBuilder{check: "routes", ref: ResourceRef{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress", Namespace: ing.Namespace, Name: ing.Name}}
+
+	class := ing.Annotations["kubernetes.io/ingress.class"]
+	if ing.Spec.IngressClassName != nil {
+		class = *ing.Spec.IngressClassName
+	}
+	switch {
+	case classes == nil:
+		// IngressClasses could not be listed, so there is nothing to compare against
+	case class == "" && defaultClass == "":
+		b.add(SeverityWarning, "IngressClassMissing", "Ingress names no IngressClass and the cluster has no default one",
+			"Set spec.ingressClassName so an ingress controller picks the Ingress up.")
+	case class != "" && !classes[class]:
+		b.add(SeverityCritical, "IngressClassNotFound", fmt.Sprintf("IngressClass %q does not exist", class),
+			"Point spec.ingressClassName at an installed controller ('kubectl get ingressclass').")
+	}
+
+	var refs []networkingv1.IngressBackend
+	if ing.Spec.DefaultBackend != nil {
+		refs = append(refs, *ing.Spec.DefaultBackend)
+	}
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			refs = append(refs, path.Backend)
+		}
+	}
+	seen := make(map[string]bool)
+	for _, ref := range refs {
+		if ref.Service == nil {
+			continue
+		}
+		port := servicePortRef{number: ref.Service.Port.Number, name: ref.Service.Port.Name}
+		if key := ref.Service.Name + ":" + port.String(); !seen[key] {
+			seen[key] = true
+			backends.inspect(b, ing.Namespace, ref.Service.Name, port)
+		}
+	}
+
+	for _, t := range ing.Spec.TLS {
+		if t.SecretName == "" {
+			continue
+		}
+		backends.inspectTLSSecret(b, ing.Namespace, t.SecretName, t.Hosts, now)
+	}
+
+	return b.findings
+}
+
+// inspectGateway checks the GatewayClass, status conditions and listener certificates of a Gateway.
+// classes is nil when the GatewayClasses could not be listed.
+func inspectGateway(gw unstructured.Unstructured, classes map[string]bool, backends *backendIndex, now time.Time) []Finding {
+	b := &findingBuilder{check: "routes", ref: customResourceRef(gw)}
+
+	class, _, _ := unstructured.NestedString(gw.Object, "spec", "gatewayClassName")
+	if classes != nil && !classes[class] {
+		b.add(SeverityCritical, "GatewayClassNotFound", fmt.Sprintf("GatewayClass %q does not exist", class),
+			"Install the Gateway controller or point spec.gatewayClassName at an existing class ('kubectl get gatewayclass').")
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(gw.Object, "status", "conditions")
+	for _, condType := range []string{"Accepted", "Programmed"} {
+		if cond, ok := findCondition(conditions, condType); ok && cond.status != "True" {
+			b.add(SeverityCritical, "GatewayNot"+condType, fmt.Sprintf("Gateway is not %s (%s)", condType, cond.reason),
+				fmt.Sprintf("Check the Gateway controller logs and 'kubectl describe gateway %s -n %s'.", gw.GetName(), gw.GetNamespace()),
+				cond.message)
+		}
+	}
+
+	listeners, _, _ := unstructured.NestedSlice(gw.Object, "spec", "listeners")
+	for _, l := range listeners {
+		listener, ok := l.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		hostname, _, _ := unstructured.NestedString(listener, "hostname")
+		certRefs, _, _ := unstructured.NestedSlice(listener, "tls", "certificateRefs")
+		for _, c := range certRefs {
+			ref, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			kind, _, _ := unstructured.NestedString(ref, "kind")
+			if kind != "" && kind != "Secret" {
+				continue
+			}
+			name, _, _ := unstructured.NestedString(ref, "name")
+			ns, _, _ := unstructured.NestedString(ref, "namespace")
+			if ns == "" {
+				ns = gw.GetNamespace()
+			}
+			var hosts []string
+			if hostname != "" && !strings.HasPrefix(hostname, "*") {
+				hosts = []string{hostname}
+			}
+			backends.inspectTLSSecret(b, ns, name, hosts, now)
+		}
+	}
+
+	return b.findings
+}
+
+// inspectHTTPRoute checks the per-parent status conditions and the backend Services of an HTTPRoute.
+func inspectHTTPRoute(route unstructured.Unstructured, backends *backendIndex) []Finding {
+	b := &findingBuilder{check: "routes", ref: customResourceRef(route)}
+	describe := fmt.Sprintf("kubectl describe httproute %s -n %s", route.GetName(), route.GetNamespace())
+
+	parents, _, _ := unstructured.NestedSlice(route.Object, "status", "parents")
+	for _, p := range parents {
+		parent, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		parentName, _, _ := unstructured.NestedString(parent, "parentRef", "name")
+		conditions, _, _ := unstructured.NestedSlice(parent, "conditions")
+
+		if cond, ok := findCondition(conditions, "Accepted"); ok && cond.status != "True" {
+			b.add(SeverityCritical, "RouteNotAccepted", fmt.Sprintf("Route is not accepted by Gateway %s (%s)", parentName, cond.reason),
+				fmt.Sprintf("Check the Gateway's allowedRoutes and the route's parentRefs with '%s'.", describe),
+				cond.message)
+		}
+		if cond, ok := findCondition(conditions, "ResolvedRefs"); ok && cond.status != "True" {
+			b.add(SeverityCritical, "RefsNotResolved", fmt.Sprintf("Route references cannot be resolved (%s)", cond.reason),
+				"Fix the backendRefs; cross-namespace references need a ReferenceGrant in the target namespace.",
+				cond.message)
+		}
+	}
+
+	rules, _, _ := unstructured.NestedSlice(route.Object, "spec", "rules")
+	seen := make(map[string]bool)
+	for _, r := range rules {
+		rule, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		refs, _, _ := unstructured.NestedSlice(rule, "backendRefs")
+		for _, br := range refs {
+			ref, ok := br.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			group, _, _ := unstructured.NestedString(ref, "group")
+			kind, _, _ := unstructured.NestedString(ref, "kind")
+			if group != "" || (kind != "" && kind != "Service") {
+				continue
+			}
+			name, _, _ := unstructured.NestedString(ref, "name")
+			ns, _, _ := unstructured.NestedString(ref, "namespace")
+			port, _, _ := unstructured.NestedInt64(ref, "port")
+			if ns == "" {
+				ns = route.GetNamespace()
+			}
+
+			portRef := servicePortRef{number: int32(port)}
+			if key := ns + "/" + name + ":" + portRef.String(); !seen[key] {
+				seen[key] = true
+				backends.inspect(b, ns, name, portRef)
+			}
+		}
+	}
+
+	return b.findings
+}
+
+// servicePortRef is a Service port referenced by number or by name.
+type servicePortRef struct {
+	number int32
+	name   string
+}
+
+func (p servicePortRef) String() string {
+	if p.name != "" {
+		return p.name
+	}
+	return fmt.Sprintf("%d", p.number)
+}
+
+// backendIndex loads the Services, ready endpoint counts and Secrets of a namespace
+// on first use, so routes pointing into other namespaces are resolved too.
+type backendIndex struct {
+	ctx       context.Context
+	env       *Env
+	services  map[string]map[string]v1.Service
+	endpoints map[string]map[string]int
+	loadErr   map[string]error
+	secrets   map[string]*v1.Secret
+}
+
+func newBackendIndex(ctx context.Context, env *Env) *backendIndex {
+	return &backendIndex{
+		ctx:       ctx,
+		env:       env,
+		services:  make(map[string]map[string]v1.Service),
+		endpoints: make(map[string]map[string]int),
+		loadErr:   make(map[string]error),
+		secrets:   make(map[string]*v1.Secret),
+	}
+}
+
+// load reads the Services and endpoints of a namespace once.
+func (x *backendIndex) load(ns string) error {
+	if _, ok := x.services[ns]; ok {
+		return x.loadErr[ns]
+	}
+
+	ctx, cancel := context.WithTimeout(x.ctx, 15*time.Second)
+	defer cancel()
+
+	x.services[ns] = make(map[string]v1.Service)
+	list, err := x.env.Clientset.CoreV1().Services(ns).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		x.loadErr[ns] = err
+		return err
+	}
+	for _, svc := range list.Items {
+		x.services[ns][svc.Name] = svc
+	}
+	if x.endpoints[ns], err = readyEndpoints(ctx, x.env, ns); err != nil {
+		x.loadErr[ns] = err
+	}
+	return x.loadErr[ns]
+}
+
+// inspect verifies that a backend Service exists, exposes the port and has ready endpoints.
+func (x *backendIndex) inspect(b *findingBuilder, ns, name string, port servicePortRef) {
+	if err := x.load(ns); err != nil {
+		b.add(SeverityWarning, "BackendUnknown", fmt.Sprintf("Backend Service %s/%s could not be verified", ns, name),
+			"Grant kobot read access to Services and EndpointSlices in the namespace.", err.Error())
+		return
+	}
+
+	svc, ok := x.services[ns][name]
+	if !ok {
+		b.add(SeverityCritical, "BackendServiceNotFound", fmt.Sprintf("Backend Service %s/%s does not exist", ns, name),
+			"Create the Service or fix the backend reference; requests to this path fail.")
+		return
+	}
+
+	found := port.number == 0 && port.name == ""
+	for _, p := range svc.Spec.Ports {
+		if (port.name != "" && p.Name == port.name) || (port.number != 0 && p.Port == port.number) {
+			found = true
+		}
+	}
+	if !found {
+		var ports []string
+		for _, p := range svc.Spec.Ports {
+			ports = append(ports, fmt.Sprintf("%d(%s)", p.Port, p.Name))
+		}
+		b.add(SeverityCritical, "BackendPortNotFound", fmt.Sprintf("Backend Service %s/%s has no port %s", ns, name, port),
+			"Fix the backend port to one the Service exposes.", "service ports "+strings.Join(ports, ", "))
+		return
+	}
+
+	if svc.Spec.Type != v1.ServiceTypeExternalName && x.endpoints[ns][name] == 0 {
+		b.add(SeverityCritical, "BackendNoEndpoints", fmt.Sprintf("Backend Service %s/%s has no ready endpoints", ns, name),
+			"The route is valid but nothing serves it; see the services check for the Service's selector and pods.")
+	}
+}
+
+// secret returns a Secret from the cache or the API; nil with a nil error means it does not exist.
+func (x *backendIndex) secret(ns, name string) (*v1.Secret, error) {
+	key := ns + "/" + name
+	if s, ok := x.secrets[key]; ok {
+		return s, nil
+	}
+
+	ctx, cancel := context.WithTimeout(x.ctx, 10*time.Second)
+	defer cancel()
+	s, err := x.env.Clientset.CoreV1().Secrets(ns).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		x.secrets[key] = nil
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	x.secrets[key] = s
+	return s, nil
+}
+
+// inspectTLSSecret verifies that a referenced TLS Secret exists, holds a valid,
+// unexpired certificate with a matching key and covers the given hosts.
+func (x *backendIndex) inspectTLSSecret(b *findingBuilder, ns, name string, hosts []string, now time.Time) {
+	secret, err := x.secret(ns, name)
+	if err != nil {
+		b.add(SeverityWarning, "TLSSecretUnknown", fmt.Sprintf("TLS Secret %s/%s could not be read", ns, name),
+			"Grant kobot read access to Secrets to validate certificates.", err.Error())
+		return
+	}
+	if secret == nil {
+		b.add(SeverityCritical, "TLSSecretNotFound", fmt.Sprintf("TLS Secret %s/%s does not exist", ns, name),
+			"Create the Secret (or check the cert-manager Certificate that should issue it); clients get the controller's default certificate.")
+		return
+	}
+
+	cert, err := tlsCertificate(secret)
+	if err != nil {
+		b.add(SeverityCritical, "TLSSecretInvalid", fmt.Sprintf("TLS Secret %s/%s is invalid", ns, name),
+			"Replace the Secret with a matching PEM certificate and key.", err.Error())
+		return
+	}
+
+	if now.After(cert.NotAfter) {
+		b.add(SeverityCritical, "TLSCertificateExpired", fmt.Sprintf("Certificate in %s/%s expired on %s", ns, name, cert.NotAfter.Format(time.RFC3339)),
+			"Renew the certificate; clients reject the connection.", "subject "+cert.Subject.String())
+	}
+
+	var uncovered []string
+	for _, host := range hosts {
+		if cert.VerifyHostname(host) != nil {
+			uncovered = append(uncovered, host)
+		}
+	}
+	if len(uncovered) > 0 {
+		b.add(SeverityWarning, "TLSHostMismatch", fmt.Sprintf("Certificate in %s/%s does not cover %s", ns, name, strings.Join(uncovered, ", ")),
+			"Reissue the certificate with the missing DNS names.", "DNS names "+strings.Join(cert.DNSNames, ", "))
+	}
+}
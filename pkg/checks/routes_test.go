@@ -0,0 +1,258 @@
+package checks
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// testKeyPair generates a self-signed certificate for hosts valid until notAfter
+// and returns it and its private key PEM encoded, as stored in a TLS Secret.
+func testKeyPair(t *testing.T, notAfter time.Time, hosts ...string) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "kobot-test"},
+		DNSNames:     hosts,
+		NotBefore:    notAfter.AddDate(-1, 0, 0),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// testTLSSecret builds a kubernetes.io/tls Secret holding the given PEM data.
+func testTLSSecret(ns, name string, certPEM, keyPEM []byte) *v1.Secret {
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name},
+		Type:       v1.SecretTypeTLS,
+		Data:       map[string][]byte{v1.TLSCertKey: certPEM, v1.TLSPrivateKeyKey: keyPEM},
+	}
+}
+
+// testBackends returns a backendIndex over the Service web in shop, which exposes
+// port 80 (http) with one ready endpoint, the endpoint-less Service idle, and the
+// given Secrets.
+func testBackends(secrets ...*v1.Secret) *backendIndex {
+	cs := fake.NewSimpleClientset(
+		&v1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web"}, Spec: v1.ServiceSpec{Ports: []v1.ServicePort{{Name: "http", Port: 80}}}},
+		&v1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "idle"}, Spec: v1.ServiceSpec{Ports: []v1.ServicePort{{Name: "http", Port: 80}}}},
+		&discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web-abc", Labels: map[string]string{discoveryv1.LabelServiceName: "web"}},
+			Endpoints:  []discoveryv1.Endpoint{{Addresses: []string{"10.0.0.1"}}},
+		},
+	)
+	for _, s := range secrets {
+		if err := cs.Tracker().Add(s); err != nil {
+			panic(err)
+		}
+	}
+	return newBackendIndex(context.Background(), &Env{Clientset: cs})
+}
+
+func TestInspectIngress(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	certPEM, keyPEM := testKeyPair(t, now.AddDate(0, 6, 0), "shop.example.com")
+	expiredPEM, expiredKey := testKeyPair(t, now.AddDate(0, -1, 0), "shop.example.com")
+	backends := func() *backendIndex {
+		return testBackends(testTLSSecret("shop", "shop-tls", certPEM, keyPEM), testTLSSecret("shop", "old-tls", expiredPEM, expiredKey))
+	}
+	classes := map[string]bool{"nginx": true}
+	nginx, traefik := "nginx", "traefik"
+	backend := func(service string, port networkingv1.ServiceBackendPort) networkingv1.IngressBackend {
+		return networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{Name: service, Port: port}}
+	}
+	rules := func(backends ...networkingv1.IngressBackend) []networkingv1.IngressRule {
+		var paths []networkingv1.HTTPIngressPath
+		for _, b := range backends {
+			paths = append(paths, networkingv1.HTTPIngressPath{Path: "/", Backend: b})
+		}
+		return []networkingv1.IngressRule{{Host: "shop.example.com", IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{Paths: paths}}}}
+	}
+	http := networkingv1.ServiceBackendPort{Name: "http"}
+
+	tests := []struct {
+		name         string
+		spec         networkingv1.IngressSpec
+		noClasses    bool // the IngressClasses could not be listed
+		defaultClass string
+		want         []string
+	}{
+		{
+			name: "healthy",
+			spec: networkingv1.IngressSpec{IngressClassName: &nginx, Rules: rules(backend("web", http)), TLS: []networkingv1.IngressTLS{{Hosts: []string{"shop.example.com"}, SecretName: "shop-tls"}}},
+		},
+		{name: "unknown class", spec: networkingv1.IngressSpec{IngressClassName: &traefik, Rules: rules(backend("web", http))}, want: []string{"critical/IngressClassNotFound"}},
+		{name: "no class and no default", spec: networkingv1.IngressSpec{Rules: rules(backend("web", http))}, want: []string{"warning/IngressClassMissing"}},
+		{name: "no class but a default", spec: networkingv1.IngressSpec{Rules: rules(backend("web", http))}, defaultClass: "nginx"},
+		{name: "classes unreadable", spec: networkingv1.IngressSpec{IngressClassName: &traefik, Rules: rules(backend("web", http))}, noClasses: true},
+		{name: "missing backend", spec: networkingv1.IngressSpec{IngressClassName: &nginx, Rules: rules(backend("api", http))}, want: []string{"critical/BackendServiceNotFound"}},
+		{name: "wrong port", spec: networkingv1.IngressSpec{IngressClassName: &nginx, Rules: rules(backend("web", networkingv1.ServiceBackendPort{Number: 8080}))}, want: []string{"critical/BackendPortNotFound"}},
+		{name: "no endpoints", spec: networkingv1.IngressSpec{IngressClassName: &nginx, DefaultBackend: &[]networkingv1.IngressBackend{backend("idle", http)}[0]}, want: []string{"critical/BackendNoEndpoints"}},
+		{name: "same backend reported once", spec: networkingv1.IngressSpec{IngressClassName: &nginx, Rules: rules(backend("api", http), backend("api", http))}, want: []string{"critical/BackendServiceNotFound"}},
+		{
+			name: "missing TLS secret",
+			spec: networkingv1.IngressSpec{IngressClassName: &nginx, Rules: rules(backend("web", http)), TLS: []networkingv1.IngressTLS{{SecretName: "gone-tls"}}},
+			want: []string{"critical/TLSSecretNotFound"},
+		},
+		{
+			name: "expired certificate not covering the host",
+			spec: networkingv1.IngressSpec{IngressClassName: &nginx, Rules: rules(backend("web", http)), TLS: []networkingv1.IngressTLS{{Hosts: []string{"api.example.com"}, SecretName: "old-tls"}}},
+			want: []string{"critical/TLSCertificateExpired", "warning/TLSHostMismatch"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ing := networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "shop"}, Spec: tt.spec}
+			c := classes
+			if tt.noClasses {
+				c = nil
+			}
+			if got := findingReasons(inspectIngress(ing, c, tt.defaultClass, backends(), now)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("inspectIngress() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// testRouteObject builds a Gateway API object from its spec and status.
+func testRouteObject(kind string, spec, status map[string]interface{}) unstructured.Unstructured {
+	return unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "gateway.networking.k8s.io/v1",
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"namespace": "shop", "name": "shop"},
+		"spec":       spec,
+		"status":     status,
+	}}
+}
+
+func TestInspectGateway(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	certPEM, keyPEM := testKeyPair(t, now.AddDate(0, 6, 0), "*.example.com")
+	listener := func(hostname, secret string) map[string]interface{} {
+		return map[string]interface{}{
+			"name":     "https",
+			"hostname": hostname,
+			"tls":      map[string]interface{}{"certificateRefs": []interface{}{map[string]interface{}{"name": secret}}},
+		}
+	}
+	conditions := func(status string) map[string]interface{} {
+		return map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "Accepted", "status": "True"},
+			map[string]interface{}{"type": "Programmed", "status": status, "reason": "AddressNotAssigned"},
+		}}
+	}
+
+	tests := []struct {
+		name   string
+		spec   map[string]interface{}
+		status map[string]interface{}
+		want   []string
+	}{
+		{
+			name:   "healthy",
+			spec:   map[string]interface{}{"gatewayClassName": "istio", "listeners": []interface{}{listener("shop.example.com", "wildcard-tls")}},
+			status: conditions("True"),
+		},
+		{
+			name:   "unknown class and not programmed",
+			spec:   map[string]interface{}{"gatewayClassName": "cilium"},
+			status: conditions("False"),
+			want:   []string{"critical/GatewayClassNotFound", "critical/GatewayNotProgrammed"},
+		},
+		{
+			name:   "listener certificate missing",
+			spec:   map[string]interface{}{"gatewayClassName": "istio", "listeners": []interface{}{listener("", "gone-tls")}},
+			status: conditions("True"),
+			want:   []string{"critical/TLSSecretNotFound"},
+		},
+		{
+			name:   "listener host not covered",
+			spec:   map[string]interface{}{"gatewayClassName": "istio", "listeners": []interface{}{listener("shop.example.org", "wildcard-tls")}},
+			status: conditions("True"),
+			want:   []string{"warning/TLSHostMismatch"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gw := testRouteObject("Gateway", tt.spec, tt.status)
+			backends := testBackends(testTLSSecret("shop", "wildcard-tls", certPEM, keyPEM))
+			if got := findingReasons(inspectGateway(gw, map[string]bool{"istio": true}, backends, now)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("inspectGateway() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInspectHTTPRoute(t *testing.T) {
+	backendRef := func(name string, port int64) map[string]interface{} {
+		return map[string]interface{}{"name": name, "port": port}
+	}
+	spec := func(refs ...interface{}) map[string]interface{} {
+		return map[string]interface{}{"rules": []interface{}{map[string]interface{}{"backendRefs": refs}}}
+	}
+	parent := func(accepted, resolved string) map[string]interface{} {
+		return map[string]interface{}{"parents": []interface{}{map[string]interface{}{
+			"parentRef": map[string]interface{}{"name": "shop"},
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Accepted", "status": accepted, "reason": "NotAllowedByListeners"},
+				map[string]interface{}{"type": "ResolvedRefs", "status": resolved, "reason": "RefNotPermitted"},
+			},
+		}}}
+	}
+
+	tests := []struct {
+		name   string
+		spec   map[string]interface{}
+		status map[string]interface{}
+		want   []string
+	}{
+		{name: "healthy", spec: spec(backendRef("web", 80)), status: parent("True", "True")},
+		{name: "not accepted", spec: spec(backendRef("web", 80)), status: parent("False", "True"), want: []string{"critical/RouteNotAccepted"}},
+		{name: "refs not resolved", spec: spec(backendRef("web", 80)), status: parent("True", "False"), want: []string{"critical/RefsNotResolved"}},
+		{name: "backend port missing", spec: spec(backendRef("web", 8080)), status: parent("True", "True"), want: []string{"critical/BackendPortNotFound"}},
+		{
+			name:   "non-Service backends are skipped",
+			spec:   spec(map[string]interface{}{"group": "example.com", "kind": "Bucket", "name": "assets"}),
+			status: parent("True", "True"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route := testRouteObject("HTTPRoute", tt.spec, tt.status)
+			if got := findingReasons(inspectHTTPRoute(route, testBackends())); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("inspectHTTPRoute() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,387 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	"gitlab.com/kobot/kobot/pkg/logging"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// ruleCostLimit bounds the work a single rule evaluation may do, so a careless
+// expression over a large related list cannot stall the scan.
+const ruleCostLimit = 10_000_000
+
+// ruleIDPattern keeps rule IDs usable as check names and finding CheckIDs.
+var ruleIDPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// RuleFile is the format of a custom rules file.
+type RuleFile struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Rule is a site-specific check written in YAML: a CEL expression that must hold
+// for every object of the target resource. Objects for which it evaluates to false
+// become findings with the configured severity and message.
+type Rule struct {
+	// ID names the rule; it is the check name and the CheckID of its findings.
+	ID          string `json:"id"`
+	Description string `json:"description,omitempty"`
+	// Resource is the target as group/version/resource (version/resource for core).
+	// Namespaced targets and Namespaces follow the namespace selection of the run;
+	// other cluster-scoped targets are always evaluated in full.
+	Resource string `json:"resource"`
+	// Selector is an optional label selector limiting the target objects.
+	Selector string `json:"selector,omitempty"`
+	// Related lists further resources by name. They are listed in the target's
+	// namespace (for Namespace targets: the namespace itself) and exposed to the
+	// expression as related.<name>.
+	Related map[string]string `json:"related,omitempty"`
+	// Expression is the CEL expression that must evaluate to true, with the target
+	// available as object.
+	Expression string `json:"expression"`
+	// Severity of the findings: info, warning (default) or critical.
+	Severity string `json:"severity,omitempty"`
+	// Reason is the CamelCase finding reason; it defaults to "RuleViolated".
+	Reason string `json:"reason,omitempty"`
+	// Message is the finding message; MessageExpression, when set, is a CEL
+	// expression returning the message instead.
+	Message           string `json:"message,omitempty"`
+	MessageExpression string `json:"messageExpression,omitempty"`
+	Action            string `json:"action,omitempty"`
+}
+
+// LoadRules reads and compiles a rules file, returning one check per rule.
+// Every rule is validated up front so a typo fails the run before it connects.
+func LoadRules(path string) ([]Check, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read rules file: %w", err)
+	}
+	var file RuleFile
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid rules file %s: %w", path, err)
+	}
+
+	env, err := cel.NewEnv(
+		cel.Variable("object", cel.DynType),
+		cel.Variable("related", cel.MapType(cel.StringType, cel.ListType(cel.DynType))),
+		ext.Strings(),
+		ext.Lists(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create CEL environment: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var out []Check
+	for i, rule := range file.Rules {
+		c, err := compileRule(env, rule)
+		if err != nil {
+			if rule.ID == "" {
+				return nil, fmt.Errorf("%s: rule #%d: %w", path, i+1, err)
+			}
+			return nil, fmt.Errorf("%s: rule %q: %w", path, rule.ID, err)
+		}
+		if seen[rule.ID] {
+			return nil, fmt.Errorf("%s: rule %q is defined twice", path, rule.ID)
+		}
+		if _, exists := Lookup(rule.ID); exists {
+			return nil, fmt.Errorf("%s: rule %q has the name of a built-in check", path, rule.ID)
+		}
+		seen[rule.ID] = true
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// compileRule validates a rule and compiles its expressions.
+func compileRule(env *cel.Env, rule Rule) (*ruleCheck, error) {
+	if !ruleIDPattern.MatchString(rule.ID) {
+		return nil, fmt.Errorf("id must be lowercase letters, digits and dashes")
+	}
+	target, err := ParseResource(rule.Resource)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := labels.Parse(rule.Selector); err != nil {
+		return nil, fmt.Errorf("invalid selector: %w", err)
+	}
+
+	c := &ruleCheck{rule: rule, target: target, severity: SeverityWarning, related: make(map[string]schema.GroupVersionResource)}
+	if rule.Severity != "" {
+		if c.severity, err = ParseSeverity(rule.Severity); err != nil {
+			return nil, err
+		}
+	}
+	if c.rule.Reason == "" {
+		c.rule.Reason = "RuleViolated"
+	}
+	for name, resource := range rule.Related {
+		if c.related[name], err = ParseResource(resource); err != nil {
+			return nil, fmt.Errorf("related %s: %w", name, err)
+		}
+	}
+
+	if rule.Expression == "" {
+		return nil, fmt.Errorf("expression is required")
+	}
+	if c.expression, err = compileExpression(env, rule.Expression, cel.BoolType); err != nil {
+		return nil, fmt.Errorf("expression: %w", err)
+	}
+	if rule.MessageExpression != "" {
+		if c.message, err = compileExpression(env, rule.MessageExpression, cel.StringType); err != nil {
+			return nil, fmt.Errorf("messageExpression: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// compileExpression compiles a CEL expression and checks its result type.
+func compileExpression(env *cel.Env, expr string, want *cel.Type) (cel.Program, error) {
+	ast, issues := env.Compile(expr)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if !ast.OutputType().IsExactType(want) && !ast.OutputType().IsExactType(cel.DynType) {
+		return nil, fmt.Errorf("must return %s, not %s", want, ast.OutputType())
+	}
+	return env.Program(ast, cel.CostLimit(ruleCostLimit))
+}
+
+// ruleCheck runs one custom rule.
+type ruleCheck struct {
+	rule       Rule
+	target     schema.GroupVersionResource
+	related    map[string]schema.GroupVersionResource
+	severity   Severity
+	expression cel.Program
+	message    cel.Program
+}
+
+func (c *ruleCheck) Name() string { return c.rule.ID }
+
+func (c *ruleCheck) Description() string {
+	if c.rule.Description != "" {
+		return c.rule.Description
+	}
+	return fmt.Sprintf("Custom rule on %s", c.target.String())
+}
+
+func (c *ruleCheck) RequiredClients() []Client { return []Client{KubeClient, DynamicClient} }
+
+func (c *ruleCheck) Run(ctx context.Context, env *Env) (*Result, error) {
+	logging.Info("Evaluating custom rule %s on %s.", c.rule.ID, c.target.String())
+	logging.Starting("Operator-initiated custom rule check")
+
+	api, err := discoverResource(env, c.target)
+	if err != nil {
+		return nil, err
+	}
+	related, err := newRelatedObjects(ctx, env, c.related)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Resource: c.target.Resource}
+	opts := metav1.ListOptions{LabelSelector: c.rule.Selector}
+
+	if !api.Namespaced {
+		listCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		list, err := env.Dynamic.Resource(c.target).List(listCtx, opts)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("unable to list %s: %w", c.target.Resource, err)
+		}
+		items := list.Items
+		if api.Kind == "Namespace" {
+			items = selectedNamespaces(items, env.Namespaces)
+		}
+		findings, errs := c.evaluateAll(items, related)
+		result.Findings = append(result.Findings, findings...)
+		result.Namespaces = append(result.Namespaces, NamespaceResult{Name: ClusterScope, Checked: len(items), Error: errs})
+		return result, nil
+	}
+
+	for _, ns := range env.Namespaces {
+		logging.Running("Scan job on namespace: %s", ns)
+
+		nsCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		list, err := env.Dynamic.Resource(c.target).Namespace(ns).List(nsCtx, opts)
+		cancel()
+		if err != nil {
+			result.Namespaces = append(result.Namespaces, NamespaceResult{
+				Name:  ns,
+				Error: fmt.Sprintf("unable to list %s in %s: %v", c.target.Resource, ns, err),
+			})
+			continue
+		}
+
+		findings, errs := c.evaluateAll(list.Items, related)
+		result.Findings = append(result.Findings, findings...)
+		result.Namespaces = append(result.Namespaces, NamespaceResult{Name: ns, Checked: len(list.Items), Error: errs})
+	}
+
+	return result, nil
+}
+
+// selectedNamespaces keeps the Namespace objects the run's namespace selection covers.
+func selectedNamespaces(items []unstructured.Unstructured, selected []string) []unstructured.Unstructured {
+	keep := make(map[string]bool, len(selected))
+	for _, ns := range selected {
+		keep[ns] = true
+	}
+	var out []unstructured.Unstructured
+	for _, obj := range items {
+		if keep[obj.GetName()] {
+			out = append(out, obj)
+		}
+	}
+	return out
+}
+
+// evaluateAll runs the rule against a list of objects. Objects whose related
+// resources could not be listed are skipped; each distinct error is returned once.
+func (c *ruleCheck) evaluateAll(items []unstructured.Unstructured, related *relatedObjects) ([]Finding, string) {
+	var findings []Finding
+	var errs []string
+	seen := make(map[string]bool)
+	for _, obj := range items {
+		f, err := c.evaluate(obj, related)
+		if err != nil && !seen[err.Error()] {
+			seen[err.Error()] = true
+			errs = append(errs, err.Error())
+		}
+		findings = append(findings, f...)
+	}
+	return findings, strings.Join(errs, "; ")
+}
+
+// evaluate runs the rule against one object. An expression that fails to evaluate
+// (typically a missing field not guarded by has()) is reported as a warning.
+func (c *ruleCheck) evaluate(obj unstructured.Unstructured, related *relatedObjects) ([]Finding, error) {
+	b := &findingBuilder{check: c.rule.ID, ref: customResourceRef(obj)}
+
+	lists, err := related.forObject(obj)
+	if err != nil {
+		return nil, err
+	}
+	vars := map[string]interface{}{"object": obj.Object, "related": lists}
+
+	out, _, err := c.expression.Eval(vars)
+	if err != nil {
+		b.add(SeverityWarning, "RuleEvaluationError", fmt.Sprintf("Rule %s could not be evaluated", c.rule.ID),
+			"Guard optional fields with has() in the rule expression.", err.Error())
+		return b.findings, nil
+	}
+	holds, isBool := out.Value().(bool)
+	if !isBool {
+		b.add(SeverityWarning, "RuleEvaluationError", fmt.Sprintf("Rule %s returned %s instead of a bool", c.rule.ID, out.Type().TypeName()),
+			"Fix the rule expression to evaluate to true or false.")
+		return b.findings, nil
+	}
+	if holds {
+		return nil, nil
+	}
+
+	message := c.rule.Message
+	if c.message != nil {
+		if m, _, err := c.message.Eval(vars); err == nil {
+			if s, ok := m.Value().(string); ok {
+				message = s
+			}
+		}
+	}
+	if message == "" {
+		message = fmt.Sprintf("Violates rule %s", c.rule.ID)
+	}
+
+	b.add(c.severity, c.rule.Reason, message, c.rule.Action, "rule "+c.rule.ID+": "+c.rule.Expression)
+	return b.findings, nil
+}
+
+// relatedObjects lists the related resources of a rule, once per namespace.
+type relatedObjects struct {
+	ctx       context.Context
+	env       *Env
+	resources map[string]schema.GroupVersionResource
+	// namespaced reports per related name whether it is listed per namespace.
+	namespaced map[string]bool
+	// cache maps namespace (ClusterScope for cluster-scoped lists) to name to objects.
+	cache map[string]map[string][]interface{}
+}
+
+func newRelatedObjects(ctx context.Context, env *Env, resources map[string]schema.GroupVersionResource) (*relatedObjects, error) {
+	r := &relatedObjects{
+		ctx:        ctx,
+		env:        env,
+		resources:  resources,
+		namespaced: make(map[string]bool, len(resources)),
+		cache:      make(map[string]map[string][]interface{}),
+	}
+	for name, gvr := range resources {
+		api, err := discoverResource(env, gvr)
+		if err != nil {
+			return nil, fmt.Errorf("related %s: %w", name, err)
+		}
+		r.namespaced[name] = api.Namespaced
+	}
+	return r, nil
+}
+
+// forObject returns the related lists visible to one target object.
+func (r *relatedObjects) forObject(obj unstructured.Unstructured) (map[string][]interface{}, error) {
+	ns := obj.GetNamespace()
+	if ns == "" && obj.GetKind() == "Namespace" {
+		ns = obj.GetName()
+	}
+
+	out := make(map[string][]interface{}, len(r.resources))
+	for name := range r.resources {
+		scope := ClusterScope
+		if r.namespaced[name] {
+			scope = ns
+		}
+		items, err := r.list(name, scope)
+		if err != nil {
+			return nil, err
+		}
+		out[name] = items
+	}
+	return out, nil
+}
+
+// list returns the objects of a related resource in a namespace, or cluster-wide
+// for ClusterScope (all namespaces for namespaced resources of cluster-scoped targets).
+func (r *relatedObjects) list(name, ns string) ([]interface{}, error) {
+	if items, ok := r.cache[ns][name]; ok {
+		return items, nil
+	}
+
+	ctx, cancel := context.WithTimeout(r.ctx, 15*time.Second)
+	defer cancel()
+	list, err := r.env.Dynamic.Resource(r.resources[name]).Namespace(ns).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list related %s: %w", name, err)
+	}
+
+	items := make([]interface{}, 0, len(list.Items))
+	for _, item := range list.Items {
+		items = append(items, item.Object)
+	}
+	if r.cache[ns] == nil {
+		r.cache[ns] = make(map[string][]interface{})
+	}
+	r.cache[ns][name] = items
+	return items, nil
+}
@@ -0,0 +1,173 @@
+package checks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// writeRules writes a rules file into a temporary directory and returns its path.
+func writeRules(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		wantErr string // substring, "" for success
+		want    []string
+	}{
+		{
+			name: "valid rules",
+			rules: `
+rules:
+  - id: pinned-chart
+    resource: helm.toolkit.fluxcd.io/v2/helmreleases
+    expression: has(object.spec.chart.spec.version)
+  - id: prod-has-pdb
+    resource: v1/namespaces
+    selector: tier=prod
+    related:
+      pdbs: policy/v1/poddisruptionbudgets
+    expression: size(related.pdbs) > 0
+    severity: critical
+    messageExpression: "'namespace ' + object.metadata.name + ' has no PDB'"
+`,
+			want: []string{"pinned-chart", "prod-has-pdb"},
+		},
+		{name: "unknown field", rules: "rules:\n  - id: a\n    resource: v1/pods\n    expression: 'true'\n    sevrity: info\n", wantErr: "unknown field"},
+		{name: "invalid id", rules: "rules:\n  - id: Bad_ID\n    resource: v1/pods\n    expression: 'true'\n", wantErr: "rule \"Bad_ID\": id must be"},
+		{name: "missing id", rules: "rules:\n  - resource: v1/pods\n    expression: 'true'\n", wantErr: "rule #1"},
+		{name: "duplicate id", rules: "rules:\n  - id: a\n    resource: v1/pods\n    expression: 'true'\n  - id: a\n    resource: v1/pods\n    expression: 'true'\n", wantErr: "defined twice"},
+		{name: "built-in name", rules: "rules:\n  - id: pods\n    resource: v1/pods\n    expression: 'true'\n", wantErr: "built-in check"},
+		{name: "bad resource", rules: "rules:\n  - id: a\n    resource: pods\n    expression: 'true'\n", wantErr: "rule \"a\""},
+		{name: "bad selector", rules: "rules:\n  - id: a\n    resource: v1/pods\n    selector: 'a in ('\n    expression: 'true'\n", wantErr: "invalid selector"},
+		{name: "bad severity", rules: "rules:\n  - id: a\n    resource: v1/pods\n    severity: urgent\n    expression: 'true'\n", wantErr: "unknown severity"},
+		{name: "missing expression", rules: "rules:\n  - id: a\n    resource: v1/pods\n", wantErr: "expression is required"},
+		{name: "syntax error", rules: "rules:\n  - id: a\n    resource: v1/pods\n    expression: 'object.spec.('\n", wantErr: "expression:"},
+		{name: "non-bool expression", rules: "rules:\n  - id: a\n    resource: v1/pods\n    expression: '1 + 1'\n", wantErr: "must return bool"},
+		{name: "non-string message", rules: "rules:\n  - id: a\n    resource: v1/pods\n    expression: 'true'\n    messageExpression: '42'\n", wantErr: "messageExpression"},
+		{name: "bad related", rules: "rules:\n  - id: a\n    resource: v1/pods\n    related:\n      x: nope\n    expression: 'true'\n", wantErr: "related x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checks, err := LoadRules(writeRules(t, tt.rules))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadRules() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadRules() error = %v", err)
+			}
+			var got []string
+			for _, c := range checks {
+				got = append(got, c.Name())
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("LoadRules() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleEvaluate(t *testing.T) {
+	pod := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"namespace": "shop", "name": "api", "labels": map[string]interface{}{"team": "payments"}},
+	}}
+
+	tests := []struct {
+		name       string
+		rule       string
+		pdbs       []interface{}
+		wantReason string // "" for no finding
+		wantMsg    string
+	}{
+		{name: "holds", rule: "expression: has(object.metadata.labels.team)"},
+		{name: "violated with default message", rule: "expression: has(object.metadata.labels.owner)", wantReason: "RuleViolated", wantMsg: "Violates rule r"},
+		{name: "custom reason and message", rule: "expression: \"false\"\n    reason: NoOwner\n    message: pod has no owner", wantReason: "NoOwner", wantMsg: "pod has no owner"},
+		{name: "message expression", rule: "expression: \"false\"\n    messageExpression: \"'pod ' + object.metadata.name\"", wantReason: "RuleViolated", wantMsg: "pod api"},
+		{name: "missing field", rule: "expression: object.spec.priority > 0", wantReason: "RuleEvaluationError"},
+		{name: "dynamic non-bool result", rule: "expression: object.metadata.name", wantReason: "RuleEvaluationError"},
+		{name: "related list empty", rule: "related:\n      pdbs: policy/v1/poddisruptionbudgets\n    expression: size(related.pdbs) > 0", wantReason: "RuleViolated"},
+		{name: "related list present", rule: "related:\n      pdbs: policy/v1/poddisruptionbudgets\n    expression: size(related.pdbs) > 0", pdbs: []interface{}{map[string]interface{}{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checks, err := LoadRules(writeRules(t, "rules:\n  - id: r\n    resource: v1/pods\n    "+tt.rule+"\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := checks[0].(*ruleCheck)
+
+			// related lists come from the cache, so no cluster is needed
+			related := &relatedObjects{
+				resources:  c.related,
+				namespaced: map[string]bool{"pdbs": true},
+				cache:      map[string]map[string][]interface{}{"shop": {"pdbs": tt.pdbs}},
+			}
+			if related.cache["shop"]["pdbs"] == nil {
+				related.cache["shop"]["pdbs"] = []interface{}{}
+			}
+
+			findings, err := c.evaluate(pod, related)
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch {
+			case tt.wantReason == "" && len(findings) > 0:
+				t.Errorf("evaluate() = %v, want no findings", findings)
+			case tt.wantReason != "" && (len(findings) != 1 || findings[0].Reason != tt.wantReason):
+				t.Errorf("evaluate() = %v, want one %s finding", findings, tt.wantReason)
+			case tt.wantMsg != "" && findings[0].Message != tt.wantMsg:
+				t.Errorf("message = %q, want %q", findings[0].Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestSelectedNamespaces(t *testing.T) {
+	ns := func(name string) unstructured.Unstructured {
+		obj := unstructured.Unstructured{}
+		obj.SetName(name)
+		return obj
+	}
+	got := selectedNamespaces([]unstructured.Unstructured{ns("a"), ns("kube-system"), ns("b")}, []string{"b", "a"})
+	if len(got) != 2 || got[0].GetName() != "a" || got[1].GetName() != "b" {
+		t.Errorf("selectedNamespaces() = %v, want a and b", got)
+	}
+}
+
+func TestParseResource(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    schema.GroupVersionResource
+		wantErr bool
+	}{
+		{in: "v1/pods", want: schema.GroupVersionResource{Version: "v1", Resource: "pods"}},
+		{in: "apps/v1/deployments", want: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}},
+		{in: "pods", wantErr: true},
+		{in: "a/b/c/d", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseResource(tt.in)
+		if (err != nil) != tt.wantErr || (!tt.wantErr && got != tt.want) {
+			t.Errorf("ParseResource(%q) = %v, %v", tt.in, got, err)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gitlab.com/kobot/kobot/pkg/logging"
+	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func init() {
+	Register(serviceCheck{})
+}
+
+// loadBalancerGrace is how long a LoadBalancer Service may wait for its external address before it is critical.
+const loadBalancerGrace = 15 * time.Minute
+
+// serviceCheck verifies that Services actually route somewhere: their selector
+// matches pods, those pods are ready endpoints, the target ports exist on the
+// containers and LoadBalancers received an external address.
+type serviceCheck struct{}
+
+func (serviceCheck) Name() string { return "services" }
+
+func (serviceCheck) Description() string {
+	return "Service selectors, ready endpoints, target ports and LoadBalancer addresses"
+}
+
+func (serviceCheck) RequiredClients() []Client { return []Client{KubeClient} }
+
+func (serviceCheck) Run(ctx context.Context, env *Env) (*Result, error) {
+	logging.Info("Scanning Services across %d namespace(s).", len(env.Namespaces))
+	logging.Starting("Operator-initiated Service endpoint check")
+
+	result := &Result{Resource: "services"}
+	now := time.Now()
+	core := env.Clientset.CoreV1()
+
+	for _, ns := range env.Namespaces {
+		logging.Running("Scan job on namespace: %s", ns)
+
+		nsCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		services, err := core.Services(ns).List(nsCtx, metav1.ListOptions{})
+		if err != nil {
+			cancel()
+			result.Namespaces = append(result.Namespaces, NamespaceResult{Name: ns, Error: fmt.Sprintf("unable to list Services in %s: %v", ns, err)})
+			continue
+		}
+		if len(services.Items) == 0 {
+			cancel()
+			result.Namespaces = append(result.Namespaces, NamespaceResult{Name: ns})
+			continue
+		}
+
+		pods, err := core.Pods(ns).List(nsCtx, metav1.ListOptions{})
+		if err != nil {
+			cancel()
+			result.Namespaces = append(result.Namespaces, NamespaceResult{Name: ns, Error: fmt.Sprintf("unable to list pods in %s: %v", ns, err)})
+			continue
+		}
+		endpoints, err := readyEndpoints(nsCtx, env, ns)
+		cancel()
+		if err != nil {
+			result.Namespaces = append(result.Namespaces, NamespaceResult{Name: ns, Error: err.Error()})
+			continue
+		}
+
+		result.Namespaces = append(result.Namespaces, NamespaceResult{Name: ns, Checked: len(services.Items)})
+		for _, svc := range services.Items {
+			result.Findings = append(result.Findings, inspectService(svc, pods.Items, endpoints, now)...)
+		}
+	}
+
+	return result, nil
+}
+
+// readyEndpoints counts the ready endpoint addresses per Service name. It reads
+// EndpointSlices and falls back to the core/v1 Endpoints API on clusters without them.
+func readyEndpoints(ctx context.Context, env *Env, ns string) (map[string]int, error) {
+	out := make(map[string]int)
+
+	slices, err := env.Clientset.DiscoveryV1().EndpointSlices(ns).List(ctx, metav1.ListOptions{})
+	if err == nil {
+		for _, slice := range slices.Items {
+			svc := slice.Labels[discoveryv1.LabelServiceName]
+			if svc == "" {
+				continue
+			}
+			for _, ep := range slice.Endpoints {
+				if ep.Conditions.Ready == nil || *ep.Conditions.Ready {
+					out[svc] += len(ep.Addresses)
+				}
+			}
+		}
+		return out, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("unable to list EndpointSlices in %s: %w", ns, err)
+	}
+
+	endpoints, err := env.Clientset.CoreV1().Endpoints(ns).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list Endpoints in %s: %w", ns, err)
+	}
+	for _, ep := range endpoints.Items {
+		for _, subset := range ep.Subsets {
+			out[ep.Name] += len(subset.Addresses)
+		}
+	}
+	return out, nil
+}
+
+// inspectService evaluates one Service against the pods of its namespace and its ready endpoints.
+func inspectService(svc v1.Service, pods []v1.Pod, endpoints map[string]int, now time.Time) []Finding {
+	b := &findingBuilder{check: "services", ref: ResourceRef{Version: "v1", Kind: "Service", Namespace: svc.Namespace, Name: svc.Name}}
+
+	if svc.Spec.Type == v1.ServiceTypeExternalName {
+		return nil
+	}
+
+	if svc.Spec.Type == v1.ServiceTypeLoadBalancer && len(svc.Status.LoadBalancer.Ingress) == 0 {
+		age := now.Sub(svc.CreationTimestamp.Time)
+		severity := SeverityWarning
+		if age > loadBalancerGrace {
+			severity = SeverityCritical
+		}
+		b.add(severity, "LoadBalancerPending", fmt.Sprintf("LoadBalancer has no external address after %s", age.Round(time.Minute)),
+			fmt.Sprintf("Check the cloud controller manager or load balancer controller and 'kubectl describe service %s -n %s' for provisioning errors.", svc.Name, svc.Namespace))
+	}
+
+	// Services without a selector have manually managed endpoints
+	if len(svc.Spec.Selector) == 0 {
+		if endpoints[svc.Name] == 0 {
+			b.add(SeverityWarning, "NoReadyEndpoints", "Service has no selector and no ready endpoints",
+				"Services without a selector need manually managed EndpointSlices; create them or add a selector.")
+		}
+		return b.findings
+	}
+
+	selector := labels.SelectorFromSet(svc.Spec.Selector)
+	var selected []v1.Pod
+	for _, pod := range pods {
+		if pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed && selector.Matches(labels.Set(pod.Labels)) {
+			selected = append(selected, pod)
+		}
+	}
+
+	if len(selected) == 0 {
+		b.add(SeverityCritical, "SelectorMatchesNoPods", "Service selector matches no pods",
+			"Compare the selector with the pod template labels of the intended workload; a label typo is the usual cause.",
+			"selector "+selector.String(), closestPodHint(svc.Spec.Selector, pods))
+		return b.findings
+	}
+
+	if endpoints[svc.Name] == 0 && !svc.Spec.PublishNotReadyAddresses {
+		b.add(SeverityCritical, "NoReadyEndpoints", fmt.Sprintf("Service selects %d pod(s) but none is a ready endpoint", len(selected)),
+			"The selected pods fail their readiness probes; inspect them with 'kubectl get pods -l "+selector.String()+" -n "+svc.Namespace+"'.")
+	}
+
+	inspectServicePorts(b, svc, selected)
+	return b.findings
+}
+
+// inspectServicePorts checks every Service port's targetPort against the container ports of the selected pods.
+// A named targetPort has to exist; an undeclared numeric one may still work, so it is only a warning.
+func inspectServicePorts(b *findingBuilder, svc v1.Service, selected []v1.Pod) {
+	named := make(map[string]bool)
+	numbered := make(map[int32]bool)
+	for _, pod := range selected {
+		for _, c := range pod.Spec.Containers {
+			for _, p := range c.Ports {
+				if p.Name != "" {
+					named[p.Name] = true
+				}
+				numbered[p.ContainerPort] = true
+			}
+		}
+	}
+
+	for _, port := range svc.Spec.Ports {
+		target := port.TargetPort
+		label := port.Name
+		if label == "" {
+			label = fmt.Sprintf("%d", port.Port)
+		}
+
+		switch {
+		case target.Type == intstr.String && target.StrVal != "" && !named[target.StrVal]:
+			b.add(SeverityCritical, "TargetPortNotFound",
+				fmt.Sprintf("Port %s targets named port %q, which no selected container declares", label, target.StrVal),
+				"Rename the targetPort or the containerPort so they match; traffic to this port is dropped.",
+				"container ports "+portList(named, numbered))
+		case target.Type == intstr.Int && len(numbered) > 0:
+			number := target.IntVal
+			if number == 0 {
+				number = port.Port
+			}
+			if !numbered[number] {
+				b.add(SeverityWarning, "TargetPortNotDeclared",
+					fmt.Sprintf("Port %s targets port %d, which no selected container declares", label, number),
+					"Confirm the application listens on this port or fix the Service's targetPort.",
+					"container ports "+portList(named, numbered))
+			}
+		}
+	}
+}
+
+// portList renders the declared container ports for evidence.
+func portList(named map[string]bool, numbered map[int32]bool) string {
+	var out []string
+	for n := range numbered {
+		out = append(out, fmt.Sprintf("%d", n))
+	}
+	for n := range named {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	if len(out) == 0 {
+		return "(none declared)"
+	}
+	return strings.Join(out, ", ")
+}
+
+// closestPodHint names the pod whose labels come closest to a selector and the
+// labels that differ, which usually pinpoints the typo.
+func closestPodHint(selector map[string]string, pods []v1.Pod) string {
+	best, bestMatches := -1, 0
+	for i, pod := range pods {
+		matches := 0
+		for k, v := range selector {
+			if pod.Labels[k] == v {
+				matches++
+			}
+		}
+		if matches > bestMatches {
+			best, bestMatches = i, matches
+		}
+	}
+	if best < 0 {
+		return ""
+	}
+
+	pod := pods[best]
+	var diffs []string
+	for k, v := range selector {
+		if got, ok := pod.Labels[k]; !ok {
+			diffs = append(diffs, fmt.Sprintf("%s=%s missing", k, v))
+		} else if got != v {
+			diffs = append(diffs, fmt.Sprintf("%s=%s (pod has %q)", k, v, got))
+		}
+	}
+	sort.Strings(diffs)
+	return fmt.Sprintf("closest pod %s differs in %s", pod.Name, strings.Join(diffs, ", "))
+}
@@ -0,0 +1,116 @@
+package checks
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestInspectService(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	pod := func(name string, labels map[string]string, phase v1.PodPhase, ports ...v1.ContainerPort) v1.Pod {
+		return v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: name, Labels: labels},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "app", Ports: ports}}},
+			Status:     v1.PodStatus{Phase: phase},
+		}
+	}
+	http := v1.ContainerPort{Name: "http", ContainerPort: 8080}
+	pods := []v1.Pod{
+		pod("web-1", map[string]string{"app": "web", "tier": "front"}, v1.PodRunning, http),
+		pod("old-job", map[string]string{"app": "migrate"}, v1.PodSucceeded),
+	}
+	port := func(target intstr.IntOrString) []v1.ServicePort {
+		return []v1.ServicePort{{Name: "http", Port: 80, TargetPort: target}}
+	}
+
+	tests := []struct {
+		name      string
+		spec      v1.ServiceSpec
+		age       time.Duration
+		endpoints int
+		lbIngress bool
+		want      []string
+	}{
+		{name: "healthy", spec: v1.ServiceSpec{Selector: map[string]string{"app": "web"}, Ports: port(intstr.FromString("http"))}, endpoints: 1},
+		{name: "ExternalName is not checked", spec: v1.ServiceSpec{Type: v1.ServiceTypeExternalName}},
+		{name: "selector typo", spec: v1.ServiceSpec{Selector: map[string]string{"app": "wbe"}}, want: []string{"critical/SelectorMatchesNoPods"}},
+		{name: "completed pods are not backends", spec: v1.ServiceSpec{Selector: map[string]string{"app": "migrate"}}, want: []string{"critical/SelectorMatchesNoPods"}},
+		{name: "no ready endpoints", spec: v1.ServiceSpec{Selector: map[string]string{"app": "web"}}, want: []string{"critical/NoReadyEndpoints"}},
+		{name: "not-ready addresses are published", spec: v1.ServiceSpec{Selector: map[string]string{"app": "web"}, PublishNotReadyAddresses: true}},
+		{name: "no selector and no endpoints", spec: v1.ServiceSpec{}, want: []string{"warning/NoReadyEndpoints"}},
+		{name: "no selector with manual endpoints", spec: v1.ServiceSpec{}, endpoints: 2},
+		{name: "named target port missing", spec: v1.ServiceSpec{Selector: map[string]string{"app": "web"}, Ports: port(intstr.FromString("metrics"))}, endpoints: 1, want: []string{"critical/TargetPortNotFound"}},
+		{name: "numeric target port declared", spec: v1.ServiceSpec{Selector: map[string]string{"app": "web"}, Ports: port(intstr.FromInt32(8080))}, endpoints: 1},
+		{name: "numeric target port undeclared", spec: v1.ServiceSpec{Selector: map[string]string{"app": "web"}, Ports: port(intstr.FromInt32(9090))}, endpoints: 1, want: []string{"warning/TargetPortNotDeclared"}},
+		{name: "target port defaults to the port", spec: v1.ServiceSpec{Selector: map[string]string{"app": "web"}, Ports: port(intstr.IntOrString{})}, endpoints: 1, want: []string{"warning/TargetPortNotDeclared"}},
+		{name: "new LoadBalancer", spec: v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer, Selector: map[string]string{"app": "web"}}, age: time.Minute, endpoints: 1, want: []string{"warning/LoadBalancerPending"}},
+		{name: "LoadBalancer pending past grace", spec: v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer, Selector: map[string]string{"app": "web"}}, age: time.Hour, endpoints: 1, want: []string{"critical/LoadBalancerPending"}},
+		{name: "LoadBalancer provisioned", spec: v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer, Selector: map[string]string{"app": "web"}}, age: time.Hour, endpoints: 1, lbIngress: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := v1.Service{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web", CreationTimestamp: metav1.NewTime(now.Add(-tt.age))},
+				Spec:       tt.spec,
+			}
+			if tt.lbIngress {
+				svc.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{IP: "203.0.113.10"}}
+			}
+			got := findingReasons(inspectService(svc, pods, map[string]int{"web": tt.endpoints}, now))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("inspectService() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClosestPodHint(t *testing.T) {
+	pods := []v1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "db-0", Labels: map[string]string{"app": "db"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Labels: map[string]string{"app": "web", "tier": "frontend"}}},
+	}
+	got := closestPodHint(map[string]string{"app": "web", "tier": "front", "team": "shop"}, pods)
+	want := `closest pod web-1 differs in team=shop missing, tier=front (pod has "frontend")`
+	if got != want {
+		t.Errorf("closestPodHint() = %q, want %q", got, want)
+	}
+	if got := closestPodHint(map[string]string{"app": "cache"}, pods); got != "" {
+		t.Errorf("closestPodHint() without any matching label = %q, want none", got)
+	}
+}
+
+func TestReadyEndpoints(t *testing.T) {
+	yes, no := true, false
+	slice := func(name, service string, endpoints ...discoveryv1.Endpoint) *discoveryv1.EndpointSlice {
+		return &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: name, Labels: map[string]string{discoveryv1.LabelServiceName: service}},
+			Endpoints:  endpoints,
+		}
+	}
+	cs := fake.NewSimpleClientset(
+		slice("web-abc", "web",
+			discoveryv1.Endpoint{Addresses: []string{"10.0.0.1"}, Conditions: discoveryv1.EndpointConditions{Ready: &yes}},
+			discoveryv1.Endpoint{Addresses: []string{"10.0.0.2"}, Conditions: discoveryv1.EndpointConditions{Ready: &no}},
+			discoveryv1.Endpoint{Addresses: []string{"10.0.0.3"}},
+		),
+		slice("web-def", "web", discoveryv1.Endpoint{Addresses: []string{"10.0.0.4"}}),
+		slice("api-abc", "api", discoveryv1.Endpoint{Addresses: []string{"10.0.1.1"}, Conditions: discoveryv1.EndpointConditions{Ready: &no}}),
+	)
+
+	got, err := readyEndpoints(context.Background(), &Env{Clientset: cs}, "shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int{"web": 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("readyEndpoints() = %v, want %v", got, want)
+	}
+}
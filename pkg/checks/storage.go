@@ -0,0 +1,260 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gitlab.com/kobot/kobot/pkg/logging"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func init() {
+	Register(storageCheck{})
+}
+
+// pvcPendingGrace is how long a claim may stay Pending while it is being provisioned.
+const pvcPendingGrace = 5 * time.Minute
+
+// storageCheck reports PersistentVolumeClaims that are not bound or cannot be resized,
+// and the cluster-scoped PersistentVolumes, StorageClasses and VolumeAttachments behind them.
+type storageCheck struct{}
+
+func (storageCheck) Name() string { return "storage" }
+
+func (storageCheck) Description() string {
+	return "PersistentVolumeClaim binding and resizing, PersistentVolume phases, StorageClasses and VolumeAttachments"
+}
+
+func (storageCheck) RequiredClients() []Client { return []Client{KubeClient} }
+
+func (storageCheck) Run(ctx context.Context, env *Env) (*Result, error) {
+	logging.Info("Scanning storage across %d namespace(s).", len(env.Namespaces))
+	logging.Starting("Operator-initiated storage check")
+
+	result := &Result{Resource: "storage objects"}
+	now := time.Now()
+
+	clusterCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// --- cluster-scoped objects: StorageClasses, PersistentVolumes and VolumeAttachments
+	scope := NamespaceResult{Name: ClusterScope}
+	var errs []string
+
+	var classes map[string]storagev1.StorageClass
+	if list, err := env.Clientset.StorageV1().StorageClasses().List(clusterCtx, metav1.ListOptions{}); err != nil {
+		errs = append(errs, fmt.Sprintf("unable to list StorageClasses: %v", err))
+	} else {
+		classes = make(map[string]storagev1.StorageClass, len(list.Items))
+		for _, sc := range list.Items {
+			classes[sc.Name] = sc
+		}
+	}
+
+	if list, err := env.Clientset.CoreV1().PersistentVolumes().List(clusterCtx, metav1.ListOptions{}); err != nil {
+		errs = append(errs, fmt.Sprintf("unable to list PersistentVolumes: %v", err))
+	} else {
+		scope.Checked += len(list.Items)
+		for _, pv := range list.Items {
+			result.Findings = append(result.Findings, inspectPersistentVolume(pv)...)
+		}
+	}
+
+	if list, err := env.Clientset.StorageV1().VolumeAttachments().List(clusterCtx, metav1.ListOptions{}); err != nil {
+		errs = append(errs, fmt.Sprintf("unable to list VolumeAttachments: %v", err))
+	} else {
+		scope.Checked += len(list.Items)
+		for _, va := range list.Items {
+			result.Findings = append(result.Findings, inspectVolumeAttachment(va)...)
+		}
+	}
+	scope.Error = strings.Join(errs, "; ")
+
+	// --- namespaced PersistentVolumeClaims
+	for _, ns := range env.Namespaces {
+		logging.Running("Scan job on namespace: %s", ns)
+
+		nsCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		claims, err := env.Clientset.CoreV1().PersistentVolumeClaims(ns).List(nsCtx, metav1.ListOptions{})
+		cancel()
+		if err != nil {
+			result.Namespaces = append(result.Namespaces, NamespaceResult{
+				Name:  ns,
+				Error: fmt.Sprintf("unable to list PersistentVolumeClaims in %s: %v", ns, err),
+			})
+			continue
+		}
+
+		result.Namespaces = append(result.Namespaces, NamespaceResult{Name: ns, Checked: len(claims.Items)})
+		for _, pvc := range claims.Items {
+			result.Findings = append(result.Findings, inspectClaim(pvc, classes, now)...)
+		}
+	}
+
+	result.Namespaces = append(result.Namespaces, scope)
+	return result, nil
+}
+
+// defaultStorageClass returns the name of the StorageClass marked as default, if any.
+func defaultStorageClass(classes map[string]storagev1.StorageClass) string {
+	for name, sc := range classes {
+		if sc.Annotations["storageclass.kubernetes.io/is-default-class"] == "true" {
+			return name
+		}
+	}
+	return ""
+}
+
+// inspectClaim evaluates the phase, StorageClass and resize status of a PersistentVolumeClaim.
+// classes is nil when the StorageClasses could not be listed.
+func inspectClaim(pvc v1.PersistentVolumeClaim, classes map[string]storagev1.StorageClass, now time.Time) []Finding {
+	b := &findingBuilder{check: "storage", ref: ResourceRef{Version: "v1", Kind: "PersistentVolumeClaim", Namespace: pvc.Namespace, Name: pvc.Name}}
+	describe := fmt.Sprintf("kubectl describe pvc %s -n %s", pvc.Name, pvc.Namespace)
+
+	className := ""
+	if pvc.Spec.StorageClassName != nil {
+		className = *pvc.Spec.StorageClassName
+	}
+	if classes != nil && className != "" {
+		if _, ok := classes[className]; !ok {
+			b.add(SeverityCritical, "StorageClassNotFound", fmt.Sprintf("StorageClass %q does not exist", className),
+				"Create the StorageClass or point the claim at an existing one with 'kubectl get storageclass'.")
+			// the missing class already explains why the claim is Pending
+			return b.findings
+		}
+	}
+
+	switch pvc.Status.Phase {
+	case v1.ClaimLost:
+		b.add(SeverityCritical, "ClaimLost", fmt.Sprintf("Claim lost its PersistentVolume %s", pvc.Spec.VolumeName),
+			"The bound volume no longer exists; restore it from backup or recreate the claim, then restart the workload.")
+	case v1.ClaimPending:
+		inspectPendingClaim(b, pvc, className, classes, now, describe)
+	case v1.ClaimBound:
+		inspectClaimResize(b, pvc, describe)
+	}
+
+	return b.findings
+}
+
+// inspectPendingClaim reports claims that stay unbound past the provisioning grace period.
+func inspectPendingClaim(b *findingBuilder, pvc v1.PersistentVolumeClaim, className string, classes map[string]storagev1.StorageClass, now time.Time, describe string) {
+	age := now.Sub(pvc.CreationTimestamp.Time)
+	if age < pvcPendingGrace {
+		return
+	}
+
+	if classes != nil {
+		if pvc.Spec.StorageClassName == nil && defaultStorageClass(classes) == "" && pvc.Spec.VolumeName == "" {
+			b.add(SeverityCritical, "NoDefaultStorageClass", "Claim is Pending and names no StorageClass, but the cluster has no default one",
+				"Set spec.storageClassName on the claim or mark a StorageClass as default.")
+			return
+		}
+		// these claims bind only once a pod using them is scheduled
+		if sc, ok := classes[className]; ok && sc.VolumeBindingMode != nil && *sc.VolumeBindingMode == storagev1.VolumeBindingWaitForFirstConsumer {
+			return
+		}
+	}
+
+	b.add(SeverityCritical, "ClaimPending", fmt.Sprintf("Claim has been Pending for %s", age.Round(time.Minute)),
+		fmt.Sprintf("Check the provisioner events with '%s' and that the StorageClass provisioner is running.", describe),
+		"storageClassName "+quotedOrDefault(className))
+}
+
+// inspectClaimResize compares the requested size of a bound claim with its capacity and reports stuck or failed resizes.
+func inspectClaimResize(b *findingBuilder, pvc v1.PersistentVolumeClaim, describe string) {
+	for _, cond := range pvc.Status.Conditions {
+		if cond.Status != v1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case v1.PersistentVolumeClaimControllerResizeError, v1.PersistentVolumeClaimNodeResizeError:
+			b.add(SeverityCritical, "ResizeFailed", fmt.Sprintf("Volume expansion failed (%s)", cond.Type),
+				"Check that the StorageClass has allowVolumeExpansion and the CSI driver supports expansion.",
+				conditionEvidence(cond.Reason, cond.Message))
+			return
+		case v1.PersistentVolumeClaimFileSystemResizePending:
+			b.add(SeverityWarning, "FileSystemResizePending", "Volume was expanded but the file system resize is waiting for the node",
+				"The file system is grown when a pod mounts the volume; restart the pod using it if it does not happen on its own.",
+				conditionEvidence(cond.Reason, cond.Message))
+			return
+		}
+	}
+
+	for name, status := range pvc.Status.AllocatedResourceStatuses {
+		if status == v1.PersistentVolumeClaimControllerResizeInfeasible || status == v1.PersistentVolumeClaimNodeResizeInfeasible {
+			b.add(SeverityCritical, "ResizeFailed", fmt.Sprintf("Volume expansion of %s is infeasible (%s)", name, status),
+				fmt.Sprintf("Lower the request back to a supported size; see '%s'.", describe))
+			return
+		}
+	}
+
+	requested, ok := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+	capacity, hasCapacity := pvc.Status.Capacity[v1.ResourceStorage]
+	if ok && hasCapacity && requested.Cmp(capacity) > 0 {
+		b.add(SeverityWarning, "ResizePending", fmt.Sprintf("Requested %s but capacity is still %s", requested.String(), capacity.String()),
+			fmt.Sprintf("Check the resize progress with '%s'.", describe))
+	}
+}
+
+// inspectPersistentVolume reports Failed volumes and Released volumes waiting to be reclaimed.
+func inspectPersistentVolume(pv v1.PersistentVolume) []Finding {
+	b := &findingBuilder{check: "storage", ref: ResourceRef{Version: "v1", Kind: "PersistentVolume", Name: pv.Name}}
+
+	claim := ""
+	if ref := pv.Spec.ClaimRef; ref != nil {
+		claim = fmt.Sprintf("last claim %s/%s", ref.Namespace, ref.Name)
+	}
+
+	switch pv.Status.Phase {
+	case v1.VolumeFailed:
+		b.add(SeverityCritical, "VolumeFailed", "PersistentVolume failed its automatic reclamation",
+			fmt.Sprintf("Check 'kubectl describe pv %s' and clean up the backing storage manually.", pv.Name),
+			pv.Status.Message, claim)
+	case v1.VolumeReleased:
+		// Retain keeps the data on purpose; with Delete the volume should already be gone
+		severity, action := SeverityInfo, "The volume keeps its data for manual recovery; delete it (and the backing storage) once no longer needed."
+		if pv.Spec.PersistentVolumeReclaimPolicy == v1.PersistentVolumeReclaimDelete {
+			severity, action = SeverityWarning, "The volume should have been deleted with its claim; check the provisioner logs for deletion errors."
+		}
+		b.add(severity, "VolumeReleased", fmt.Sprintf("PersistentVolume is Released (reclaim policy %s)", pv.Spec.PersistentVolumeReclaimPolicy),
+			action, claim)
+	}
+
+	return b.findings
+}
+
+// inspectVolumeAttachment reports attach and detach errors of the CSI attacher.
+func inspectVolumeAttachment(va storagev1.VolumeAttachment) []Finding {
+	b := &findingBuilder{check: "storage", ref: ResourceRef{Group: "storage.k8s.io", Version: "v1", Kind: "VolumeAttachment", Name: va.Name}}
+
+	volume := "inline volume"
+	if va.Spec.Source.PersistentVolumeName != nil {
+		volume = "PersistentVolume " + *va.Spec.Source.PersistentVolumeName
+	}
+	target := fmt.Sprintf("%s on node %s (attacher %s)", volume, va.Spec.NodeName, va.Spec.Attacher)
+
+	if e := va.Status.AttachError; e != nil {
+		b.add(SeverityCritical, "AttachError", fmt.Sprintf("Volume cannot be attached: %s", target),
+			"Pods using the volume stay in ContainerCreating; check the CSI driver pods on the node and the cloud provider's volume limits.",
+			e.Message, "at "+e.Time.Format(time.RFC3339))
+	}
+	if e := va.Status.DetachError; e != nil {
+		b.add(SeverityWarning, "DetachError", fmt.Sprintf("Volume cannot be detached: %s", target),
+			"The volume stays attached to the old node and cannot move with its pod; check the CSI driver logs.",
+			e.Message, "at "+e.Time.Format(time.RFC3339))
+	}
+	return b.findings
+}
+
+// quotedOrDefault renders a StorageClass name, or "(default)" when the claim names none.
+func quotedOrDefault(name string) string {
+	if name == "" {
+		return "(default)"
+	}
+	return fmt.Sprintf("%q", name)
+}
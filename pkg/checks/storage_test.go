@@ -0,0 +1,130 @@
+package checks
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestInspectClaim(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	waitForConsumer := storagev1.VolumeBindingWaitForFirstConsumer
+	classes := map[string]storagev1.StorageClass{
+		"standard": {ObjectMeta: metav1.ObjectMeta{Name: "standard", Annotations: map[string]string{"storageclass.kubernetes.io/is-default-class": "true"}}},
+		"local":    {ObjectMeta: metav1.ObjectMeta{Name: "local"}, VolumeBindingMode: &waitForConsumer},
+	}
+	noDefault := map[string]storagev1.StorageClass{"local": classes["local"]}
+	class := func(name string) *string { return &name }
+	storage := func(size string) v1.ResourceList {
+		return v1.ResourceList{v1.ResourceStorage: resource.MustParse(size)}
+	}
+
+	tests := []struct {
+		name    string
+		age     time.Duration
+		class   *string
+		classes map[string]storagev1.StorageClass
+		status  v1.PersistentVolumeClaimStatus
+		request string
+		want    []string
+	}{
+		{name: "bound", class: class("standard"), classes: classes, status: v1.PersistentVolumeClaimStatus{Phase: v1.ClaimBound, Capacity: storage("10Gi")}, request: "10Gi"},
+		{name: "pending within grace", age: time.Minute, class: class("standard"), classes: classes, status: v1.PersistentVolumeClaimStatus{Phase: v1.ClaimPending}},
+		{name: "pending past grace", age: time.Hour, class: class("standard"), classes: classes, status: v1.PersistentVolumeClaimStatus{Phase: v1.ClaimPending}, want: []string{"critical/ClaimPending"}},
+		{name: "pending for its first consumer", age: time.Hour, class: class("local"), classes: classes, status: v1.PersistentVolumeClaimStatus{Phase: v1.ClaimPending}},
+		{name: "missing StorageClass", class: class("fast"), classes: classes, status: v1.PersistentVolumeClaimStatus{Phase: v1.ClaimPending}, want: []string{"critical/StorageClassNotFound"}},
+		{name: "no default StorageClass", age: time.Hour, classes: noDefault, status: v1.PersistentVolumeClaimStatus{Phase: v1.ClaimPending}, want: []string{"critical/NoDefaultStorageClass"}},
+		{name: "StorageClasses unreadable", age: time.Hour, class: class("fast"), status: v1.PersistentVolumeClaimStatus{Phase: v1.ClaimPending}, want: []string{"critical/ClaimPending"}},
+		{name: "lost", class: class("standard"), classes: classes, status: v1.PersistentVolumeClaimStatus{Phase: v1.ClaimLost}, want: []string{"critical/ClaimLost"}},
+		{name: "resize pending", class: class("standard"), classes: classes, status: v1.PersistentVolumeClaimStatus{Phase: v1.ClaimBound, Capacity: storage("10Gi")}, request: "20Gi", want: []string{"warning/ResizePending"}},
+		{
+			name: "resize failed", class: class("standard"), classes: classes, request: "20Gi",
+			status: v1.PersistentVolumeClaimStatus{Phase: v1.ClaimBound, Capacity: storage("10Gi"), Conditions: []v1.PersistentVolumeClaimCondition{
+				{Type: v1.PersistentVolumeClaimControllerResizeError, Status: v1.ConditionTrue, Message: "not supported"},
+			}},
+			want: []string{"critical/ResizeFailed"},
+		},
+		{
+			name: "file system resize pending", class: class("standard"), classes: classes, request: "20Gi",
+			status: v1.PersistentVolumeClaimStatus{Phase: v1.ClaimBound, Capacity: storage("20Gi"), Conditions: []v1.PersistentVolumeClaimCondition{
+				{Type: v1.PersistentVolumeClaimFileSystemResizePending, Status: v1.ConditionTrue},
+			}},
+			want: []string{"warning/FileSystemResizePending"},
+		},
+		{
+			name: "resize infeasible", class: class("standard"), classes: classes, request: "20Gi",
+			status: v1.PersistentVolumeClaimStatus{Phase: v1.ClaimBound, Capacity: storage("10Gi"), AllocatedResourceStatuses: map[v1.ResourceName]v1.ClaimResourceStatus{
+				v1.ResourceStorage: v1.PersistentVolumeClaimControllerResizeInfeasible,
+			}},
+			want: []string{"critical/ResizeFailed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pvc := v1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "db", Name: "data", CreationTimestamp: metav1.NewTime(now.Add(-tt.age))},
+				Spec:       v1.PersistentVolumeClaimSpec{StorageClassName: tt.class},
+				Status:     tt.status,
+			}
+			if tt.request != "" {
+				pvc.Spec.Resources.Requests = storage(tt.request)
+			}
+			if got := findingReasons(inspectClaim(pvc, tt.classes, now)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("inspectClaim() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInspectPersistentVolume(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy v1.PersistentVolumeReclaimPolicy
+		phase  v1.PersistentVolumePhase
+		want   []string
+	}{
+		{name: "bound", policy: v1.PersistentVolumeReclaimDelete, phase: v1.VolumeBound},
+		{name: "failed", policy: v1.PersistentVolumeReclaimDelete, phase: v1.VolumeFailed, want: []string{"critical/VolumeFailed"}},
+		{name: "released and retained", policy: v1.PersistentVolumeReclaimRetain, phase: v1.VolumeReleased, want: []string{"info/VolumeReleased"}},
+		{name: "released but not deleted", policy: v1.PersistentVolumeReclaimDelete, phase: v1.VolumeReleased, want: []string{"warning/VolumeReleased"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pv := v1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{Name: "pv-1"},
+				Spec:       v1.PersistentVolumeSpec{PersistentVolumeReclaimPolicy: tt.policy},
+				Status:     v1.PersistentVolumeStatus{Phase: tt.phase},
+			}
+			if got := findingReasons(inspectPersistentVolume(pv)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("inspectPersistentVolume() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInspectVolumeAttachment(t *testing.T) {
+	pv := "pv-1"
+	va := storagev1.VolumeAttachment{
+		ObjectMeta: metav1.ObjectMeta{Name: "csi-123"},
+		Spec:       storagev1.VolumeAttachmentSpec{Attacher: "ebs.csi.aws.com", NodeName: "n1", Source: storagev1.VolumeAttachmentSource{PersistentVolumeName: &pv}},
+	}
+	if got := inspectVolumeAttachment(va); got != nil {
+		t.Errorf("healthy attachment = %v, want no findings", got)
+	}
+
+	va.Status.AttachError = &storagev1.VolumeError{Message: "volume limit reached"}
+	va.Status.DetachError = &storagev1.VolumeError{Message: "still mounted"}
+	got := inspectVolumeAttachment(va)
+	if reasons := findingReasons(got); !reflect.DeepEqual(reasons, []string{"critical/AttachError", "warning/DetachError"}) {
+		t.Fatalf("inspectVolumeAttachment() = %v", reasons)
+	}
+	if want := "Volume cannot be attached: PersistentVolume pv-1 on node n1 (attacher ebs.csi.aws.com)"; got[0].Message != want {
+		t.Errorf("message = %q, want %q", got[0].Message, want)
+	}
+}
@@ -0,0 +1,327 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// WaiverFileName is the waiver file kobot reads from the working directory when present.
+const WaiverFileName = ".kobotignore"
+
+// WaiverFile is the format of a waiver file.
+type WaiverFile struct {
+	Waivers []Waiver `json:"waivers"`
+}
+
+// Waiver accepts known findings so they stop failing the run. Every field that is
+// set must match; name, kind and selector are matched against the object of a
+// finding or, failing that, against its owner, so a waiver for a Deployment also
+// covers the findings of its pods.
+type Waiver struct {
+	// Check is the ID of the check whose findings are waived (e.g. "helmreleases").
+	Check     string `json:"check,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Kind      string `json:"kind,omitempty"`
+	Name      string `json:"name,omitempty"`
+	// NameRegex is a regular expression matched against the name.
+	NameRegex string `json:"nameRegex,omitempty"`
+	// Selector is a label selector matched against the object's labels.
+	Selector string `json:"selector,omitempty"`
+	// Reason explains why the findings are accepted; it is mandatory.
+	Reason string `json:"reason"`
+	// Expires is the last day (YYYY-MM-DD) or the RFC3339 time the waiver applies.
+	Expires string `json:"expires,omitempty"`
+
+	nameRegex *regexp.Regexp
+	selector  labels.Selector
+	expiresAt time.Time // zero when the waiver never expires
+	source    string
+}
+
+// Waivers is a loaded waiver file.
+type Waivers struct {
+	path string
+	list []*Waiver
+}
+
+// LoadWaivers reads and validates a waiver file. A missing file yields no waivers
+// unless required is set (an explicitly given path).
+func LoadWaivers(path string, required bool) (*Waivers, error) {
+	out := &Waivers{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return out, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read waiver file: %w", err)
+	}
+	var file WaiverFile
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid waiver file %s: %w", path, err)
+	}
+
+	for i := range file.Waivers {
+		w := &file.Waivers[i]
+		w.source = fmt.Sprintf("%s#%d", path, i+1)
+		if err := w.compile(); err != nil {
+			return nil, fmt.Errorf("%s: waiver #%d: %w", path, i+1, err)
+		}
+		out.list = append(out.list, w)
+	}
+	return out, nil
+}
+
+// compile validates a waiver and parses its regex, selector and expiry.
+func (w *Waiver) compile() error {
+	if strings.TrimSpace(w.Reason) == "" {
+		return fmt.Errorf("reason is required")
+	}
+	if w.Check == "" && w.Namespace == "" && w.Kind == "" && w.Name == "" && w.NameRegex == "" && w.Selector == "" {
+		return fmt.Errorf("at least one of check, namespace, kind, name, nameRegex or selector is required")
+	}
+
+	var err error
+	if w.NameRegex != "" {
+		if w.nameRegex, err = regexp.Compile(w.NameRegex); err != nil {
+			return fmt.Errorf("invalid nameRegex: %w", err)
+		}
+	}
+	if w.Selector != "" {
+		if w.selector, err = labels.Parse(w.Selector); err != nil {
+			return fmt.Errorf("invalid selector: %w", err)
+		}
+	}
+	if w.Expires != "" {
+		if day, err := time.Parse(time.DateOnly, w.Expires); err == nil {
+			// a date waives the findings through the end of that day
+			w.expiresAt = day.AddDate(0, 0, 1)
+		} else if w.expiresAt, err = time.Parse(time.RFC3339, w.Expires); err != nil {
+			return fmt.Errorf("invalid expires %q (expected YYYY-MM-DD or RFC3339)", w.Expires)
+		}
+	}
+	return nil
+}
+
+// expired reports whether the waiver no longer applies at now.
+func (w *Waiver) expired(now time.Time) bool {
+	return !w.expiresAt.IsZero() && !now.Before(w.expiresAt)
+}
+
+// String describes what the waiver matches, for evidence lines.
+func (w *Waiver) String() string {
+	var parts []string
+	for _, p := range []struct{ key, value string }{
+		{"check", w.Check}, {"namespace", w.Namespace}, {"kind", w.Kind},
+		{"name", w.Name}, {"nameRegex", w.NameRegex}, {"selector", w.Selector},
+	} {
+		if p.value != "" {
+			parts = append(parts, p.key+"="+p.value)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// RequiredClients lists the clients needed to apply the waivers: label selectors
+// are matched against the live objects.
+func (ws *Waivers) RequiredClients() []Client {
+	for _, w := range ws.list {
+		if w.selector != nil {
+			return []Client{KubeClient, DynamicClient}
+		}
+	}
+	return nil
+}
+
+// Apply moves the findings matched by an active waiver into the Waived list of
+// their result. Expired waivers no longer match; they are reported as warnings of
+// an extra "waivers" result, which is appended to the returned results when at
+// least one waiver has expired.
+func (ws *Waivers) Apply(ctx context.Context, env *Env, results []*Result) []*Result {
+	if len(ws.list) == 0 {
+		return results
+	}
+
+	now := time.Now()
+	var active []*Waiver
+	expired := &Result{
+		Check:       "waivers",
+		Description: "Expiry of the waivers in " + ws.path,
+		Resource:    "waivers",
+		Namespaces:  []NamespaceResult{{Name: ClusterScope, Checked: len(ws.list)}},
+	}
+	for _, w := range ws.list {
+		if !w.expired(now) {
+			active = append(active, w)
+			continue
+		}
+		b := &findingBuilder{check: "waivers", ref: ResourceRef{Kind: "Waiver", Name: w.source}}
+		b.add(SeverityWarning, "WaiverExpired", fmt.Sprintf("Waiver expired on %s, its findings are reported again", w.Expires),
+			fmt.Sprintf("Fix the waived findings or renew the waiver in %s.", ws.path),
+			w.String(), "reason: "+w.Reason)
+		expired.Findings = append(expired.Findings, b.findings...)
+	}
+
+	objects := newLabelResolver(env)
+	for _, r := range results {
+		var kept []Finding
+		for _, f := range r.Findings {
+			w, notes := matchWaiver(ctx, active, f, objects)
+			if w != nil {
+				f.Waiver = w
+				r.Waived = append(r.Waived, f)
+				continue
+			}
+			// tell the operator why a waiver that might have matched did not apply
+			f.Evidence = append(f.Evidence, notes...)
+			kept = append(kept, f)
+		}
+		r.Findings = kept
+	}
+
+	// a clean waiver file adds nothing to the report
+	if len(expired.Findings) == 0 {
+		return results
+	}
+	return append(results, expired)
+}
+
+// matchWaiver returns the first waiver matching the finding, or nil. When no
+// waiver matches, notes explain the selectors that could not be evaluated
+// because the labels of an object could not be read.
+func matchWaiver(ctx context.Context, waivers []*Waiver, f Finding, objects *labelResolver) (*Waiver, []string) {
+	var notes []string
+	for _, w := range waivers {
+		if w.Check != "" && w.Check != f.CheckID {
+			continue
+		}
+		if w.Namespace != "" && w.Namespace != f.Resource.Namespace {
+			continue
+		}
+
+		refs := []ResourceRef{f.Resource}
+		if f.Owner != nil {
+			refs = append(refs, *f.Owner)
+		}
+		for _, ref := range refs {
+			matched, err := w.matchesObject(ctx, ref, objects)
+			if matched {
+				return w, nil
+			}
+			if err != nil {
+				notes = append(notes, fmt.Sprintf("waiver %s not applied: %v", w.source, err))
+			}
+		}
+	}
+	return nil, notes
+}
+
+// matchesObject applies the kind, name and label rules of a waiver to one object.
+// The error is set when the object's labels were needed but could not be read.
+func (w *Waiver) matchesObject(ctx context.Context, ref ResourceRef, objects *labelResolver) (bool, error) {
+	switch {
+	case w.Kind != "" && !strings.EqualFold(w.Kind, ref.Kind):
+		return false, nil
+	case w.Name != "" && w.Name != ref.Name:
+		return false, nil
+	case w.nameRegex != nil && !w.nameRegex.MatchString(ref.Name):
+		return false, nil
+	case w.selector != nil:
+		set, err := objects.labels(ctx, ref)
+		if err != nil {
+			return false, err
+		}
+		return w.selector.Matches(set), nil
+	}
+	return true, nil
+}
+
+// labelResolver reads the labels of the objects findings are about. Resource names
+// come from discovery; objects and discovery documents are fetched once, and so
+// are failures, which are reported on every finding they affect.
+type labelResolver struct {
+	env       *Env
+	resources map[string]servedResources // group/version -> discovery result
+	cache     map[string]objectLabels    // object key -> labels or read error
+}
+
+type servedResources struct {
+	list []metav1.APIResource
+	err  error
+}
+
+type objectLabels struct {
+	set labels.Set
+	err error
+}
+
+func newLabelResolver(env *Env) *labelResolver {
+	return &labelResolver{env: env, resources: make(map[string]servedResources), cache: make(map[string]objectLabels)}
+}
+
+// labels returns the labels of the referenced object, or why they cannot be read.
+func (l *labelResolver) labels(ctx context.Context, ref ResourceRef) (labels.Set, error) {
+	key := ref.key()
+	if cached, ok := l.cache[key]; ok {
+		return cached.set, cached.err
+	}
+	set, err := l.read(ctx, ref)
+	if err != nil {
+		err = fmt.Errorf("unable to read the labels of %s: %w", qualifiedRef(ref), err)
+	}
+	l.cache[key] = objectLabels{set: set, err: err}
+	return set, err
+}
+
+// read fetches the labels of an object from the API.
+func (l *labelResolver) read(ctx context.Context, ref ResourceRef) (labels.Set, error) {
+	if l.env.Clientset == nil || l.env.Dynamic == nil {
+		return nil, fmt.Errorf("no cluster client available")
+	}
+	if ref.Version == "" {
+		return nil, fmt.Errorf("the finding does not name an API version")
+	}
+
+	gv := schema.GroupVersion{Group: ref.Group, Version: ref.Version}
+	served, ok := l.resources[gv.String()]
+	if !ok {
+		list, err := l.env.Clientset.Discovery().ServerResourcesForGroupVersion(gv.String())
+		if err != nil {
+			served.err = fmt.Errorf("unable to discover %s: %w", gv, err)
+		} else {
+			served.list = list.APIResources
+		}
+		l.resources[gv.String()] = served
+	}
+	if served.err != nil {
+		return nil, served.err
+	}
+
+	for _, r := range served.list {
+		if r.Kind != ref.Kind || strings.Contains(r.Name, "/") {
+			continue
+		}
+		getCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		obj, err := l.env.Dynamic.Resource(gv.WithResource(r.Name)).Namespace(ref.Namespace).Get(getCtx, ref.Name, metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		set := labels.Set(obj.GetLabels())
+		if set == nil {
+			set = labels.Set{}
+		}
+		return set, nil
+	}
+	return nil, fmt.Errorf("kind %s is not served by %s", ref.Kind, gv)
+}
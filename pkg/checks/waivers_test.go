@@ -0,0 +1,263 @@
+package checks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWaiverCompile(t *testing.T) {
+	tests := []struct {
+		name      string
+		waiver    Waiver
+		wantErr   string
+		expiresAt time.Time
+	}{
+		{name: "check only", waiver: Waiver{Check: "pods", Reason: "known"}},
+		{name: "reason required", waiver: Waiver{Check: "pods", Reason: "  "}, wantErr: "reason is required"},
+		{name: "matcher required", waiver: Waiver{Reason: "known"}, wantErr: "at least one of"},
+		{name: "invalid regex", waiver: Waiver{NameRegex: "web-(", Reason: "known"}, wantErr: "invalid nameRegex"},
+		{name: "invalid selector", waiver: Waiver{Selector: "app in (", Reason: "known"}, wantErr: "invalid selector"},
+		{
+			name:      "date expires at the end of that day",
+			waiver:    Waiver{Check: "pods", Reason: "known", Expires: "2026-12-31"},
+			expiresAt: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "RFC3339 expires at that instant",
+			waiver:    Waiver{Check: "pods", Reason: "known", Expires: "2026-12-31T12:00:00+02:00"},
+			expiresAt: time.Date(2026, 12, 31, 10, 0, 0, 0, time.UTC),
+		},
+		{name: "invalid expires", waiver: Waiver{Check: "pods", Reason: "known", Expires: "31.12.2026"}, wantErr: "expected YYYY-MM-DD or RFC3339"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := tt.waiver
+			err := w.compile()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("compile() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("compile() error = %v", err)
+			}
+			if !w.expiresAt.Equal(tt.expiresAt) {
+				t.Errorf("expiresAt = %s, want %s", w.expiresAt, tt.expiresAt)
+			}
+		})
+	}
+}
+
+func TestWaiverExpired(t *testing.T) {
+	w := Waiver{Check: "pods", Reason: "known", Expires: "2026-12-31"}
+	if err := w.compile(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		now  time.Time
+		want bool
+	}{
+		{time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC), false},
+		{time.Date(2026, 12, 31, 23, 59, 59, 0, time.UTC), false},
+		{time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), true},
+	}
+	for _, tt := range tests {
+		if got := w.expired(tt.now); got != tt.want {
+			t.Errorf("expired(%s) = %v, want %v", tt.now, got, tt.want)
+		}
+	}
+
+	never := Waiver{Check: "pods", Reason: "known"}
+	if err := never.compile(); err != nil {
+		t.Fatal(err)
+	}
+	if never.expired(time.Date(3000, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Error("a waiver without expires expired")
+	}
+}
+
+// testWaiverEnv returns an Env whose clients serve the Deployment web in shop,
+// labeled team=payments, and its unlabeled pod web-5d9c7-abcde.
+func testWaiverEnv() *Env {
+	cs := fake.NewSimpleClientset()
+	cs.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{{Name: "pods", Kind: "Pod", Namespaced: true}},
+		},
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "deployments", Kind: "Deployment", Namespaced: true},
+				{Name: "deployments/scale", Kind: "Scale", Namespaced: true},
+			},
+		},
+	}
+	pod := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"namespace": "shop", "name": "web-5d9c7-abcde"},
+	}}
+	deploy := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"namespace": "shop", "name": "web", "labels": map[string]interface{}{"team": "payments"}},
+	}}
+	return &Env{Clientset: cs, Dynamic: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), pod, deploy)}
+}
+
+func TestMatchWaiver(t *testing.T) {
+	deploy := ResourceRef{Group: "apps", Version: "v1", Kind: "Deployment", Namespace: "shop", Name: "web"}
+	pod := Finding{
+		CheckID:  "pods",
+		Resource: ResourceRef{Version: "v1", Kind: "Pod", Namespace: "shop", Name: "web-5d9c7-abcde"},
+		Owner:    &deploy,
+	}
+	unowned := pod
+	unowned.Owner = nil
+	gone := pod
+	gone.Owner = &ResourceRef{Group: "apps", Version: "v1", Kind: "Deployment", Namespace: "shop", Name: "gone"}
+	custom := pod
+	custom.Owner = &ResourceRef{Group: "example.com", Version: "v1", Kind: "Widget", Namespace: "shop", Name: "web"}
+
+	tests := []struct {
+		name    string
+		waiver  Waiver
+		finding Finding
+		want    bool
+		note    string // substring of the note explaining a failed lookup
+	}{
+		{name: "check", waiver: Waiver{Check: "pods"}, finding: pod, want: true},
+		{name: "other check", waiver: Waiver{Check: "nodes"}, finding: pod},
+		{name: "namespace", waiver: Waiver{Namespace: "shop"}, finding: pod, want: true},
+		{name: "other namespace", waiver: Waiver{Namespace: "billing"}, finding: pod},
+		{name: "pod name", waiver: Waiver{Name: "web-5d9c7-abcde"}, finding: pod, want: true},
+		{name: "owner name", waiver: Waiver{Name: "web"}, finding: pod, want: true},
+		{name: "owner name without owner", waiver: Waiver{Name: "web"}, finding: unowned},
+		{name: "name regex", waiver: Waiver{NameRegex: "^web-"}, finding: unowned, want: true},
+		{name: "kind is case-insensitive", waiver: Waiver{Kind: "deployment", Name: "web"}, finding: pod, want: true},
+		{name: "kind and name must match one object", waiver: Waiver{Kind: "Pod", Name: "web"}, finding: pod},
+		{name: "owner labels", waiver: Waiver{Selector: "team=payments"}, finding: pod, want: true},
+		{name: "labels do not match", waiver: Waiver{Selector: "team=search"}, finding: pod},
+		{name: "owner not found", waiver: Waiver{Selector: "team=payments"}, finding: gone, note: "unable to read the labels of Deployment/shop/gone: deployments.apps \"gone\" not found"},
+		{name: "group not served", waiver: Waiver{Selector: "team=payments"}, finding: custom, note: "unable to read the labels of Widget/shop/web: unable to discover example.com/v1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := tt.waiver
+			w.Reason = "known"
+			w.source = "test#1"
+			if err := w.compile(); err != nil {
+				t.Fatal(err)
+			}
+
+			got, notes := matchWaiver(context.Background(), []*Waiver{&w}, tt.finding, newLabelResolver(testWaiverEnv()))
+			if (got != nil) != tt.want {
+				t.Errorf("matchWaiver() = %v, want match %v", got, tt.want)
+			}
+			note := strings.Join(notes, "\n")
+			if tt.note == "" && note != "" {
+				t.Errorf("notes = %q, want none", note)
+			}
+			if tt.note != "" && !strings.Contains(note, "waiver test#1 not applied: "+tt.note) {
+				t.Errorf("notes = %q, want it to contain %q", note, tt.note)
+			}
+		})
+	}
+}
+
+func TestLabelResolverCachesFailures(t *testing.T) {
+	objects := newLabelResolver(testWaiverEnv())
+	ref := ResourceRef{Group: "apps", Version: "v1", Kind: "Deployment", Namespace: "shop", Name: "gone"}
+	_, first := objects.labels(context.Background(), ref)
+	_, second := objects.labels(context.Background(), ref)
+	if first == nil || second == nil || first.Error() != second.Error() {
+		t.Errorf("labels() errors = %v, %v, want the same error twice", first, second)
+	}
+}
+
+func TestWaiversApply(t *testing.T) {
+	path := filepath.Join(t.TempDir(), WaiverFileName)
+	content := `
+waivers:
+  - check: pods
+    name: web
+    reason: rollout in progress
+  - namespace: shop
+    reason: stale
+    expires: "2020-01-31"
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ws, err := LoadWaivers(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clients := ws.RequiredClients(); clients != nil {
+		t.Errorf("RequiredClients() = %v, want none without selectors", clients)
+	}
+
+	result := &Result{Check: "pods", Findings: []Finding{
+		{CheckID: "pods", Reason: "CrashLoopBackOff", Resource: ResourceRef{Version: "v1", Kind: "Pod", Namespace: "shop", Name: "web"}},
+		{CheckID: "pods", Reason: "ImagePullBackOff", Resource: ResourceRef{Version: "v1", Kind: "Pod", Namespace: "shop", Name: "api"}},
+	}}
+	results := ws.Apply(context.Background(), &Env{}, []*Result{result})
+
+	if len(result.Waived) != 1 || result.Waived[0].Resource.Name != "web" || result.Waived[0].Waiver == nil {
+		t.Errorf("Waived = %v, want the web finding", result.Waived)
+	}
+	if len(result.Findings) != 1 || result.Findings[0].Resource.Name != "api" {
+		t.Errorf("Findings = %v, want the api finding only", result.Findings)
+	}
+	if len(results) != 2 || results[1].Check != "waivers" {
+		t.Fatalf("Apply() returned %d results, want the waivers result appended", len(results))
+	}
+	expired := results[1].Findings
+	if len(expired) != 1 || expired[0].Reason != "WaiverExpired" || expired[0].Resource.Name != path+"#2" {
+		t.Errorf("waivers findings = %v, want one WaiverExpired for %s#2", expired, path)
+	}
+
+	// without an expired waiver there is nothing to report
+	active := &Waivers{path: path, list: ws.list[:1]}
+	result = &Result{Check: "pods", Findings: []Finding{
+		{CheckID: "pods", Reason: "CrashLoopBackOff", Resource: ResourceRef{Version: "v1", Kind: "Pod", Namespace: "shop", Name: "web"}},
+	}}
+	if results := active.Apply(context.Background(), &Env{}, []*Result{result}); len(results) != 1 || len(result.Waived) != 1 {
+		t.Errorf("Apply() with active waivers only = %d results, %d waived, want the pods result alone with its finding waived", len(results), len(result.Waived))
+	}
+}
+
+func TestLoadWaivers(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing.yaml")
+
+	ws, err := LoadWaivers(missing, false)
+	if err != nil || len(ws.list) != 0 {
+		t.Errorf("LoadWaivers(missing, optional) = %v, %v, want no waivers", ws, err)
+	}
+	if _, err := LoadWaivers(missing, true); err == nil || !strings.Contains(err.Error(), "unable to read waiver file") {
+		t.Errorf("LoadWaivers(missing, required) error = %v", err)
+	}
+
+	invalid := filepath.Join(dir, "invalid.yaml")
+	if err := os.WriteFile(invalid, []byte("waivers:\n  - check: pods\n  - check: nodes\n    reason: known\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadWaivers(invalid, true); err == nil || !strings.Contains(err.Error(), invalid+": waiver #1: reason is required") {
+		t.Errorf("LoadWaivers(invalid) error = %v", err)
+	}
+}
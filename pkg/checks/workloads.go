@@ -0,0 +1,224 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gitlab.com/kobot/kobot/pkg/logging"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func init() {
+	Register(workloadCheck{})
+}
+
+// workloadCheck evaluates the rollout health of Deployments, StatefulSets and DaemonSets.
+// Findings point at the owning workload rather than at its individual pods.
+type workloadCheck struct{}
+
+func (workloadCheck) Name() string { return "workloads" }
+
+func (workloadCheck) Description() string {
+	return "Deployment, StatefulSet and DaemonSet rollout and replica health"
+}
+
+func (workloadCheck) RequiredClients() []Client { return []Client{KubeClient} }
+
+func (workloadCheck) Run(ctx context.Context, env *Env) (*Result, error) {
+	logging.Info("Scanning workload rollout health across %d namespace(s).", len(env.Namespaces))
+	logging.Starting("Operator-initiated workload rollout check")
+
+	result := &Result{Resource: "workloads"}
+	apps := env.Clientset.AppsV1()
+
+	for _, ns := range env.Namespaces {
+		logging.Running("Scan job on namespace: %s", ns)
+
+		nsCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		nsResult := NamespaceResult{Name: ns}
+		var errs []string
+
+		if deployments, err := apps.Deployments(ns).List(nsCtx, metav1.ListOptions{}); err != nil {
+			errs = append(errs, fmt.Sprintf("unable to list Deployments: %v", err))
+		} else {
+			nsResult.Checked += len(deployments.Items)
+			for _, d := range deployments.Items {
+				result.Findings = append(result.Findings, inspectDeployment(d)...)
+			}
+		}
+
+		if statefulSets, err := apps.StatefulSets(ns).List(nsCtx, metav1.ListOptions{}); err != nil {
+			errs = append(errs, fmt.Sprintf("unable to list StatefulSets: %v", err))
+		} else {
+			nsResult.Checked += len(statefulSets.Items)
+			for _, s := range statefulSets.Items {
+				result.Findings = append(result.Findings, inspectStatefulSet(s)...)
+			}
+		}
+
+		if daemonSets, err := apps.DaemonSets(ns).List(nsCtx, metav1.ListOptions{}); err != nil {
+			errs = append(errs, fmt.Sprintf("unable to list DaemonSets: %v", err))
+		} else {
+			nsResult.Checked += len(daemonSets.Items)
+			for _, d := range daemonSets.Items {
+				result.Findings = append(result.Findings, inspectDaemonSet(d)...)
+			}
+		}
+		cancel()
+
+		nsResult.Error = strings.Join(errs, "; ")
+		result.Namespaces = append(result.Namespaces, nsResult)
+	}
+
+	return result, nil
+}
+
+// workloadRef returns the ResourceRef for an apps/v1 workload.
+func workloadRef(kind, namespace, name string) ResourceRef {
+	return ResourceRef{Group: "apps", Version: "v1", Kind: kind, Namespace: namespace, Name: name}
+}
+
+// replicaSeverity is critical when nothing is available and a warning when the workload is degraded.
+func replicaSeverity(available int32) Severity {
+	if available == 0 {
+		return SeverityCritical
+	}
+	return SeverityWarning
+}
+
+// inspectDeployment evaluates generation, replica counts and rollout conditions of a Deployment.
+func inspectDeployment(d appsv1.Deployment) []Finding {
+	b := &findingBuilder{check: "workloads", ref: workloadRef("Deployment", d.Namespace, d.Name)}
+	rolloutCmd := fmt.Sprintf("kubectl rollout status deployment/%s -n %s", d.Name, d.Namespace)
+
+	desired := int32(1)
+	if d.Spec.Replicas != nil {
+		desired = *d.Spec.Replicas
+	}
+
+	if d.Spec.Paused {
+		b.add(SeverityInfo, "RolloutPaused", "Rollout is paused",
+			fmt.Sprintf("Resume with 'kubectl rollout resume deployment/%s -n %s' once the pause is no longer needed.", d.Name, d.Namespace))
+	}
+
+	if d.Status.ObservedGeneration < d.Generation {
+		b.add(SeverityWarning, "GenerationNotObserved",
+			fmt.Sprintf("Controller has not observed the latest spec (generation %d, observed %d)", d.Generation, d.Status.ObservedGeneration),
+			"Check that the kube-controller-manager is healthy.")
+	}
+
+	deadlineExceeded := false
+	for _, cond := range d.Status.Conditions {
+		switch {
+		case cond.Type == appsv1.DeploymentProgressing && cond.Reason == "ProgressDeadlineExceeded":
+			deadlineExceeded = true
+			b.add(SeverityCritical, "ProgressDeadlineExceeded", "Rollout stalled: progress deadline exceeded",
+				fmt.Sprintf("Inspect the new ReplicaSet's pods with '%s' and roll back with 'kubectl rollout undo' if the release is bad.", rolloutCmd),
+				cond.Message)
+		case cond.Type == appsv1.DeploymentReplicaFailure && cond.Status == v1.ConditionTrue:
+			b.add(SeverityCritical, "ReplicaFailure", fmt.Sprintf("Replicas cannot be created (%s)", cond.Reason),
+				"Check ResourceQuotas, LimitRanges and admission webhooks in the namespace.",
+				cond.Message)
+		}
+	}
+
+	if desired == 0 {
+		return b.findings
+	}
+
+	if d.Status.AvailableReplicas < desired {
+		b.add(replicaSeverity(d.Status.AvailableReplicas), "ReplicasUnavailable",
+			fmt.Sprintf("%d/%d replicas available (%d ready)", d.Status.AvailableReplicas, desired, d.Status.ReadyReplicas),
+			fmt.Sprintf("Run '%s' and inspect the unavailable pods.", rolloutCmd))
+	}
+
+	if !deadlineExceeded && !d.Spec.Paused && d.Status.UpdatedReplicas < desired {
+		b.add(SeverityWarning, "RolloutInProgress",
+			fmt.Sprintf("Rollout in progress: %d/%d replicas updated", d.Status.UpdatedReplicas, desired),
+			fmt.Sprintf("Wait for the rollout with '%s' and rerun kobot.", rolloutCmd))
+	}
+
+	return b.findings
+}
+
+// inspectStatefulSet evaluates generation, replica counts and revision rollout of a StatefulSet.
+func inspectStatefulSet(s appsv1.StatefulSet) []Finding {
+	b := &findingBuilder{check: "workloads", ref: workloadRef("StatefulSet", s.Namespace, s.Name)}
+	rolloutCmd := fmt.Sprintf("kubectl rollout status statefulset/%s -n %s", s.Name, s.Namespace)
+
+	desired := int32(1)
+	if s.Spec.Replicas != nil {
+		desired = *s.Spec.Replicas
+	}
+
+	if s.Status.ObservedGeneration < s.Generation {
+		b.add(SeverityWarning, "GenerationNotObserved",
+			fmt.Sprintf("Controller has not observed the latest spec (generation %d, observed %d)", s.Generation, s.Status.ObservedGeneration),
+			"Check that the kube-controller-manager is healthy.")
+	}
+
+	if desired == 0 {
+		return b.findings
+	}
+
+	if s.Status.ReadyReplicas < desired || s.Status.AvailableReplicas < desired {
+		b.add(replicaSeverity(s.Status.AvailableReplicas), "ReplicasUnavailable",
+			fmt.Sprintf("%d/%d replicas ready (%d available)", s.Status.ReadyReplicas, desired, s.Status.AvailableReplicas),
+			fmt.Sprintf("StatefulSets roll pods one at a time; inspect the lowest-ordinal unready pod and its PersistentVolumeClaims. Run '%s'.", rolloutCmd))
+	}
+
+	// OnDelete StatefulSets never converge on their own, so only RollingUpdate is a stuck rollout
+	rolling := s.Spec.UpdateStrategy.Type == "" || s.Spec.UpdateStrategy.Type == appsv1.RollingUpdateStatefulSetStrategyType
+	if rolling && s.Status.UpdateRevision != "" && s.Status.CurrentRevision != s.Status.UpdateRevision {
+		b.add(SeverityWarning, "RolloutInProgress",
+			fmt.Sprintf("Rollout in progress: %d/%d replicas on revision %s", s.Status.UpdatedReplicas, desired, s.Status.UpdateRevision),
+			fmt.Sprintf("Wait for the rollout with '%s' and rerun kobot.", rolloutCmd),
+			fmt.Sprintf("currentRevision=%s updateRevision=%s", s.Status.CurrentRevision, s.Status.UpdateRevision))
+	}
+
+	return b.findings
+}
+
+// inspectDaemonSet evaluates node coverage, misscheduling and rollout progress of a DaemonSet.
+func inspectDaemonSet(d appsv1.DaemonSet) []Finding {
+	b := &findingBuilder{check: "workloads", ref: workloadRef("DaemonSet", d.Namespace, d.Name)}
+	rolloutCmd := fmt.Sprintf("kubectl rollout status daemonset/%s -n %s", d.Name, d.Namespace)
+	st := d.Status
+
+	if st.ObservedGeneration < d.Generation {
+		b.add(SeverityWarning, "GenerationNotObserved",
+			fmt.Sprintf("Controller has not observed the latest spec (generation %d, observed %d)", d.Generation, st.ObservedGeneration),
+			"Check that the kube-controller-manager is healthy.")
+	}
+
+	if st.CurrentNumberScheduled < st.DesiredNumberScheduled {
+		b.add(SeverityWarning, "NodesMissing",
+			fmt.Sprintf("Scheduled on %d/%d eligible nodes", st.CurrentNumberScheduled, st.DesiredNumberScheduled),
+			"Check node taints, tolerations and nodeSelector on the DaemonSet.")
+	}
+
+	if st.NumberUnavailable > 0 {
+		b.add(replicaSeverity(st.NumberAvailable), "PodsUnavailable",
+			fmt.Sprintf("%d of %d daemon pods unavailable (%d ready)", st.NumberUnavailable, st.DesiredNumberScheduled, st.NumberReady),
+			fmt.Sprintf("Run '%s' and inspect the unavailable pods with 'kubectl get pods -o wide -n %s'.", rolloutCmd, d.Namespace))
+	}
+
+	if st.NumberMisscheduled > 0 {
+		b.add(SeverityWarning, "Misscheduled",
+			fmt.Sprintf("%d daemon pod(s) running on nodes where they should not run", st.NumberMisscheduled),
+			"Check recent node label or taint changes against the DaemonSet's nodeSelector and affinity.")
+	}
+
+	if st.DesiredNumberScheduled > 0 && st.UpdatedNumberScheduled < st.DesiredNumberScheduled &&
+		d.Spec.UpdateStrategy.Type != appsv1.OnDeleteDaemonSetStrategyType {
+		b.add(SeverityWarning, "RolloutInProgress",
+			fmt.Sprintf("Rollout in progress: %d/%d nodes updated", st.UpdatedNumberScheduled, st.DesiredNumberScheduled),
+			fmt.Sprintf("Wait for the rollout with '%s' and rerun kobot.", rolloutCmd))
+	}
+
+	return b.findings
+}
@@ -0,0 +1,183 @@
+package checks
+
+import (
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// findingReasons renders findings as "severity/Reason" in order, so tests can
+// compare what an inspect function reported without matching messages.
+func findingReasons(findings []Finding) []string {
+	var out []string
+	for _, f := range findings {
+		out = append(out, string(f.Severity)+"/"+f.Reason)
+	}
+	return out
+}
+
+func TestInspectDeployment(t *testing.T) {
+	replicas := func(n int32) *int32 { return &n }
+	tests := []struct {
+		name   string
+		spec   appsv1.DeploymentSpec
+		status appsv1.DeploymentStatus
+		want   []string
+	}{
+		{
+			name:   "healthy",
+			spec:   appsv1.DeploymentSpec{Replicas: replicas(3)},
+			status: appsv1.DeploymentStatus{ObservedGeneration: 2, AvailableReplicas: 3, ReadyReplicas: 3, UpdatedReplicas: 3},
+		},
+		{
+			name:   "replicas default to one",
+			status: appsv1.DeploymentStatus{ObservedGeneration: 2},
+			want:   []string{"critical/ReplicasUnavailable", "warning/RolloutInProgress"},
+		},
+		{
+			name:   "degraded",
+			spec:   appsv1.DeploymentSpec{Replicas: replicas(3)},
+			status: appsv1.DeploymentStatus{ObservedGeneration: 2, AvailableReplicas: 2, ReadyReplicas: 2, UpdatedReplicas: 3},
+			want:   []string{"warning/ReplicasUnavailable"},
+		},
+		{
+			name: "progress deadline exceeded is not also in progress",
+			spec: appsv1.DeploymentSpec{Replicas: replicas(2)},
+			status: appsv1.DeploymentStatus{ObservedGeneration: 2, AvailableReplicas: 2, UpdatedReplicas: 1, Conditions: []appsv1.DeploymentCondition{
+				{Type: appsv1.DeploymentProgressing, Status: v1.ConditionFalse, Reason: "ProgressDeadlineExceeded"},
+			}},
+			want: []string{"critical/ProgressDeadlineExceeded"},
+		},
+		{
+			name: "replica failure",
+			spec: appsv1.DeploymentSpec{Replicas: replicas(1)},
+			status: appsv1.DeploymentStatus{ObservedGeneration: 2, UpdatedReplicas: 1, Conditions: []appsv1.DeploymentCondition{
+				{Type: appsv1.DeploymentReplicaFailure, Status: v1.ConditionTrue, Reason: "FailedCreate"},
+			}},
+			want: []string{"critical/ReplicaFailure", "critical/ReplicasUnavailable"},
+		},
+		{
+			name:   "paused rollout",
+			spec:   appsv1.DeploymentSpec{Replicas: replicas(1), Paused: true},
+			status: appsv1.DeploymentStatus{ObservedGeneration: 2, AvailableReplicas: 1},
+			want:   []string{"info/RolloutPaused"},
+		},
+		{
+			name:   "generation not observed",
+			spec:   appsv1.DeploymentSpec{Replicas: replicas(1)},
+			status: appsv1.DeploymentStatus{ObservedGeneration: 1, AvailableReplicas: 1, UpdatedReplicas: 1},
+			want:   []string{"warning/GenerationNotObserved"},
+		},
+		{
+			name:   "scaled to zero",
+			spec:   appsv1.DeploymentSpec{Replicas: replicas(0)},
+			status: appsv1.DeploymentStatus{ObservedGeneration: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web", Generation: 2},
+				Spec:       tt.spec,
+				Status:     tt.status,
+			}
+			if got := findingReasons(inspectDeployment(d)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("inspectDeployment() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInspectStatefulSet(t *testing.T) {
+	three := int32(3)
+	tests := []struct {
+		name     string
+		strategy appsv1.StatefulSetUpdateStrategyType
+		status   appsv1.StatefulSetStatus
+		want     []string
+	}{
+		{
+			name:   "healthy",
+			status: appsv1.StatefulSetStatus{ReadyReplicas: 3, AvailableReplicas: 3, CurrentRevision: "r1", UpdateRevision: "r1"},
+		},
+		{
+			name:   "nothing ready",
+			status: appsv1.StatefulSetStatus{CurrentRevision: "r1", UpdateRevision: "r1"},
+			want:   []string{"critical/ReplicasUnavailable"},
+		},
+		{
+			name:   "rolling update in progress",
+			status: appsv1.StatefulSetStatus{ReadyReplicas: 3, AvailableReplicas: 3, UpdatedReplicas: 1, CurrentRevision: "r1", UpdateRevision: "r2"},
+			want:   []string{"warning/RolloutInProgress"},
+		},
+		{
+			name:     "OnDelete revisions never converge on their own",
+			strategy: appsv1.OnDeleteStatefulSetStrategyType,
+			status:   appsv1.StatefulSetStatus{ReadyReplicas: 3, AvailableReplicas: 3, CurrentRevision: "r1", UpdateRevision: "r2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "db", Name: "postgres"},
+				Spec:       appsv1.StatefulSetSpec{Replicas: &three, UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: tt.strategy}},
+				Status:     tt.status,
+			}
+			if got := findingReasons(inspectStatefulSet(s)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("inspectStatefulSet() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInspectDaemonSet(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy appsv1.DaemonSetUpdateStrategyType
+		status   appsv1.DaemonSetStatus
+		want     []string
+	}{
+		{
+			name:   "healthy",
+			status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, CurrentNumberScheduled: 3, NumberReady: 3, NumberAvailable: 3, UpdatedNumberScheduled: 3},
+		},
+		{
+			name:   "missing nodes and unavailable pods",
+			status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, CurrentNumberScheduled: 2, NumberReady: 1, NumberAvailable: 1, NumberUnavailable: 2, UpdatedNumberScheduled: 3},
+			want:   []string{"warning/NodesMissing", "warning/PodsUnavailable"},
+		},
+		{
+			name:   "misscheduled",
+			status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 1, CurrentNumberScheduled: 1, NumberAvailable: 1, UpdatedNumberScheduled: 1, NumberMisscheduled: 1},
+			want:   []string{"warning/Misscheduled"},
+		},
+		{
+			name:   "rolling update in progress",
+			status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, CurrentNumberScheduled: 3, NumberAvailable: 3, UpdatedNumberScheduled: 1},
+			want:   []string{"warning/RolloutInProgress"},
+		},
+		{
+			name:     "OnDelete is not a stuck rollout",
+			strategy: appsv1.OnDeleteDaemonSetStrategyType,
+			status:   appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, CurrentNumberScheduled: 3, NumberAvailable: 3, UpdatedNumberScheduled: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := appsv1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "cni"},
+				Spec:       appsv1.DaemonSetSpec{UpdateStrategy: appsv1.DaemonSetUpdateStrategy{Type: tt.strategy}},
+				Status:     tt.status,
+			}
+			if got := findingReasons(inspectDaemonSet(d)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("inspectDaemonSet() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,34 +2,80 @@ package cluster
 
 import (
 	"fmt"
-	"os" // allows us to get the kubeconfig from the env var
-	"path/filepath" // allows us to read the kubeconfig from the os path
-	"k8s.io/client-go/tools/clientcmd" // allows to find and connect to the users kubeconfig
+
+	"k8s.io/client-go/dynamic"    // dynamic client for custom resources like HelmReleases
 	"k8s.io/client-go/kubernetes" // creates the actual clientset to get resources within the cluster
-	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd" // allows to find and connect to the users kubeconfig
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
-func GetClientset() (*kubernetes.Clientset, error) {
-	kubeconfig := os.Getenv("KUBECONFIG")
-	if kubeconfig == "" {
-		home := os.Getenv("HOME")
-		if home == "" {
-			return nil, fmt.Errorf("neither the KUBECONFIG or HOME environment variable is either not set or empty")
-		}
-		kubeconfig = filepath.Join(home, ".kube", "config")
-	}
+// Options selects which cluster and identity kobot talks to. The fields mirror
+// the kubectl flags of the same name and are applied with the same precedence.
+type Options struct {
+	// Kubeconfig is an explicit kubeconfig path (--kubeconfig). When empty the
+	// KUBECONFIG environment variable and ~/.kube/config are used, like kubectl.
+	Kubeconfig string
+	// Context overrides the current-context of the kubeconfig (--context).
+	Context string
+	// Cluster overrides the cluster of the selected context (--cluster).
+	Cluster string
+	// User overrides the user of the selected context (--user).
+	User string
+	// Impersonate is the user to impersonate (--as).
+	Impersonate string
+	// ImpersonateGroups are the groups to impersonate (--as-group).
+	ImpersonateGroups []string
+}
+
+// Connection is the resolved client configuration shared by every client kobot builds.
+type Connection struct {
+	Config *rest.Config
+	// Context is the kubeconfig context in use, empty when running in-cluster.
+	Context string
+}
+
+// LoadConnection resolves the rest.Config for the given options using
+// clientcmd's loading rules, exactly like kubectl does. When no kubeconfig
+// exists and kobot runs inside a pod, the in-cluster service account is used.
+func LoadConnection(opts Options) (*Connection, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = opts.Kubeconfig
 
-	if info, err := os.Stat(kubeconfig); err != nil {
-		return nil, fmt.Errorf("error accessing kubeconfig at %s: %w", kubeconfig, err)
-	} else if info.IsDir() {
-		return nil, fmt.Errorf("expected kubeconfig file but found directory at %s", kubeconfig)
+	overrides := &clientcmd.ConfigOverrides{
+		CurrentContext: opts.Context,
+		Context: clientcmdapi.Context{
+			Cluster:  opts.Cluster,
+			AuthInfo: opts.User,
+		},
+		AuthInfo: clientcmdapi.AuthInfo{
+			Impersonate:       opts.Impersonate,
+			ImpersonateGroups: opts.ImpersonateGroups,
+		},
 	}
 
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	loader := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides)
+
+	config, err := loader.ClientConfig()
 	if err != nil {
-		return nil, fmt.Errorf("failed to build config for the required clientset: %w", err)
+		return nil, fmt.Errorf("failed to build rest.Config from kubeconfig: %w", err)
+	}
+
+	conn := &Connection{Config: config}
+
+	// the context name is informational only; in-cluster configs have none
+	if raw, err := loader.RawConfig(); err == nil {
+		conn.Context = raw.CurrentContext
+		if opts.Context != "" {
+			conn.Context = opts.Context
+		}
 	}
 
+	return conn, nil
+}
+
+// GetClientset builds the typed clientset for core resources from a shared rest.Config.
+func GetClientset(config *rest.Config) (*kubernetes.Clientset, error) {
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Kubernetes clientset: %w", err)
@@ -40,33 +86,11 @@ func GetClientset() (*kubernetes.Clientset, error) {
 
 // GetDynamicClientset builds and returns a dynamic.Interface client
 // for interacting with custom resources like HelmReleases, Kustomizations, etc.
-func GetDynamicClientset() (dynamic.Interface, error) {
-	kubeconfig := os.Getenv("KUBECONFIG")
-	if kubeconfig == "" {
-		home := os.Getenv("HOME")
-		if home == "" {
-			return nil, fmt.Errorf("neither the KUBECONFIG nor HOME environment variable is set or non-empty")
-		}
-		kubeconfig = filepath.Join(home, ".kube", "config")
-	}
-
-	info, err := os.Stat(kubeconfig)
-	if err != nil {
-		return nil, fmt.Errorf("error accessing kubeconfig at %s: %w", kubeconfig, err)
-	}
-	if info.IsDir() {
-		return nil, fmt.Errorf("expected kubeconfig file but found directory at %s", kubeconfig)
-	}
-
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
-	if err != nil {
-		return nil, fmt.Errorf("failed to build rest.Config for dynamic client: %w", err)
-	}
-
+func GetDynamicClientset(config *rest.Config) (dynamic.Interface, error) {
 	dynamicClient, err := dynamic.NewForConfig(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create dynamic clientset: %w", err)
 	}
 
 	return dynamicClient, nil
-}
\ No newline at end of file
+}
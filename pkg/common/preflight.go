@@ -1,29 +1,76 @@
 package common
 
 import (
+	"sync"
+
 	"gitlab.com/kobot/kobot/pkg/cluster"
 	"gitlab.com/kobot/kobot/pkg/logging"
-	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
 )
 
+// the rest.Config is resolved once per process and shared by every client
+var (
+	connOptions cluster.Options
+	connOnce    sync.Once
+	conn        *cluster.Connection
+	connErr     error
+)
+
+// UseConnectionOptions sets the kubeconfig/context options used to connect.
+// It must be called before the first Ensure* function.
+func UseConnectionOptions(opts cluster.Options) {
+	connOptions = opts
+}
+
+// Connection returns the shared cluster connection, resolving it on first use.
+func Connection() (*cluster.Connection, error) {
+	connOnce.Do(func() {
+		conn, connErr = cluster.LoadConnection(connOptions)
+	})
+	return conn, connErr
+}
+
 // returns the clientset
 func EnsureClusterConnection() *kubernetes.Clientset {
-	clientset, err := cluster.GetClientset()
+	c, err := Connection()
 	if err != nil {
 		logging.Error("Failed to connect to cluster. If kubectl can't connect, Kobot can't connect either.")
+		logging.Error("%v", err)
+		return nil
+	}
+
+	clientset, err := cluster.GetClientset(c.Config)
+	if err != nil {
+		logging.Error("Failed to connect to cluster. If kubectl can't connect, Kobot can't connect either.")
+		logging.Error("%v", err)
+		return nil
+	}
+
+	// building a clientset never talks to the API server, so make sure it is actually reachable
+	if _, err := clientset.Discovery().ServerVersion(); err != nil {
+		logging.Error("Failed to connect to cluster. If kubectl can't connect, Kobot can't connect either.")
+		logging.Error("%v", err)
 		return nil
 	}
 	// commenting this out because we dont want to tell the user that it connected; rather just tell them when it didnt connect right?
-	// logging.Success("Connected to cluster successfully") 
+	// logging.Success("Connected to cluster successfully")
 	return clientset
 }
 
 // returns the dynamicClient
 func EnsureDynamicClusterConnection() dynamic.Interface {
-	dynamicClient, err := cluster.GetDynamicClientset()
+	c, err := Connection()
+	if err != nil {
+		logging.Error("Failed to connect to cluster dynamically. If kubectl can't connect, Kobot can't connect either.")
+		logging.Error("%v", err)
+		return nil
+	}
+
+	dynamicClient, err := cluster.GetDynamicClientset(c.Config)
 	if err != nil {
 		logging.Error("Failed to connect to cluster dynamically. If kubectl can't connect, Kobot can't connect either.")
+		logging.Error("%v", err)
 		return nil
 	}
 
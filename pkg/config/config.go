@@ -0,0 +1,179 @@
+// Package config loads kobot's configuration files. A user file (~/.kobot.yaml)
+// holds personal defaults and a project file, usually checked in next to the
+// cluster's manifests, holds the team's settings for one cluster. Settings are
+// expressed in terms of the command line flags they default, so every setting
+// can still be overridden by a KOBOT_* environment variable or the flag itself.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// FileName is the name of both the user and the project configuration file.
+const FileName = ".kobot.yaml"
+
+// Config is the content of a configuration file. Unset fields leave the flag default alone.
+type Config struct {
+	// Namespaces to scan; empty scans every namespace.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// ExcludeNamespaces are names or patterns of namespaces never to scan.
+	ExcludeNamespaces []string `json:"excludeNamespaces,omitempty"`
+	// NamespaceSelector is a label selector the scanned namespaces must match.
+	NamespaceSelector string `json:"namespaceSelector,omitempty"`
+	// Selector limits the pods and HelmReleases that are checked.
+	Selector string `json:"selector,omitempty"`
+	// FieldSelector limits the pods that are checked.
+	FieldSelector string `json:"fieldSelector,omitempty"`
+	// Checks selects the built-in checks 'kobot check cluster' runs.
+	Checks *CheckSelection `json:"checks,omitempty"`
+	// FluxGrace is how long (in seconds) to wait for Flux objects to become Ready.
+	FluxGrace *int `json:"fluxGrace,omitempty"`
+	// Concurrency is how many checks run at the same time.
+	Concurrency *int `json:"concurrency,omitempty"`
+	// Output is the report format: console, json or yaml.
+	Output string `json:"output,omitempty"`
+	// FailOn is the severity at which the run exits with code 2: info, warning, critical or none.
+	FailOn string `json:"failOn,omitempty"`
+	HTML   *bool  `json:"html,omitempty"`
+	Events *bool  `json:"events,omitempty"`
+	// Rules are custom rules files run by 'kobot check cluster'.
+	Rules []string `json:"rules,omitempty"`
+	// Resources are custom resources (group/version/resource) checked with the
+	// generic condition rules by 'kobot check cluster'.
+	Resources []string `json:"resources,omitempty"`
+	// IgnoreFile is the waiver file of accepted findings.
+	IgnoreFile string `json:"ignoreFile,omitempty"`
+	// Params holds the settings of individual checks.
+	Params Params `json:"params,omitempty"`
+}
+
+// CheckSelection enables or disables built-in checks by name.
+type CheckSelection struct {
+	// Enabled, when set, is the complete list of checks to run.
+	Enabled  []string `json:"enabled,omitempty"`
+	Disabled []string `json:"disabled,omitempty"`
+}
+
+// Params are the per-check settings.
+type Params struct {
+	Pods         *PodParams         `json:"pods,omitempty"`
+	Certificates *CertificateParams `json:"certificates,omitempty"`
+}
+
+// PodParams configures the pod checks.
+type PodParams struct {
+	Deep     *bool  `json:"deep,omitempty"`
+	Logs     *bool  `json:"logs,omitempty"`
+	LogLines *int64 `json:"logLines,omitempty"`
+	LogBytes *int   `json:"logBytes,omitempty"`
+}
+
+// CertificateParams configures the certificates check.
+type CertificateParams struct {
+	// ExpiryDays is how many days ahead certificates are reported as expiring.
+	ExpiryDays *int `json:"expiryDays,omitempty"`
+}
+
+// Load reads the user file and then the project file on top of it, so settings of
+// the project file win. Missing files are skipped unless required is set for the
+// project file (an explicit --config path).
+func Load(userPath, projectPath string, required bool) (*Config, error) {
+	cfg := &Config{}
+	if err := cfg.merge(userPath, false); err != nil {
+		return nil, err
+	}
+	if projectPath != "" && projectPath != userPath {
+		if err := cfg.merge(projectPath, required); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// merge decodes a file into cfg. Keys present in the file replace the current values.
+func (c *Config) merge(path string, required bool) error {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to read config file: %w", err)
+	}
+	if err := yaml.UnmarshalStrict(data, c); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// FlagValues returns the configured settings keyed by the flag they default,
+// in the string form the flag itself accepts.
+func (c *Config) FlagValues() map[string]string {
+	out := make(map[string]string)
+	setList := func(flag string, values []string) {
+		if len(values) > 0 {
+			out[flag] = strings.Join(values, ",")
+		}
+	}
+	setString := func(flag, value string) {
+		if value != "" {
+			out[flag] = value
+		}
+	}
+	setBool := func(flag string, value *bool) {
+		if value != nil {
+			out[flag] = strconv.FormatBool(*value)
+		}
+	}
+	setInt := func(flag string, value *int) {
+		if value != nil {
+			out[flag] = strconv.Itoa(*value)
+		}
+	}
+
+	setList("namespace", c.Namespaces)
+	setList("exclude-namespace", c.ExcludeNamespaces)
+	setString("namespace-selector", c.NamespaceSelector)
+	setString("selector", c.Selector)
+	setString("field-selector", c.FieldSelector)
+	if c.Checks != nil {
+		setList("checks", c.Checks.Enabled)
+		setList("skip-checks", c.Checks.Disabled)
+	}
+	setInt("flux-grace", c.FluxGrace)
+	setInt("concurrency", c.Concurrency)
+	setString("output", c.Output)
+	setString("fail-on", c.FailOn)
+	setBool("html", c.HTML)
+	setBool("events", c.Events)
+	setList("rules", c.Rules)
+	setList("resource", c.Resources)
+	setString("ignore-file", c.IgnoreFile)
+
+	if p := c.Params.Pods; p != nil {
+		setBool("deep", p.Deep)
+		setBool("logs", p.Logs)
+		if p.LogLines != nil {
+			out["log-lines"] = strconv.FormatInt(*p.LogLines, 10)
+		}
+		setInt("log-bytes", p.LogBytes)
+	}
+	if p := c.Params.Certificates; p != nil {
+		setInt("cert-expiry", p.ExpiryDays)
+	}
+	return out
+}
+
+// EnvName is the environment variable that overrides a flag, e.g. KOBOT_FLUX_GRACE for --flux-grace.
+func EnvName(flag string) string {
+	return "KOBOT_" + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	user := write("user.yaml", "output: json\nnamespaces: [a]\nparams:\n  pods:\n    deep: true\n")
+	project := write("project.yaml", "output: yaml\nparams:\n  certificates:\n    expiryDays: 14\n")
+	invalid := write("invalid.yaml", "outptu: yaml\n")
+	missing := filepath.Join(dir, "missing.yaml")
+
+	tests := []struct {
+		name     string
+		user     string
+		project  string
+		required bool
+		want     map[string]string
+		wantErr  string
+	}{
+		{
+			name:    "project file wins over user file",
+			user:    user,
+			project: project,
+			want:    map[string]string{"output": "yaml", "namespace": "a", "deep": "true", "cert-expiry": "14"},
+		},
+		{name: "missing files are skipped", user: missing, project: missing, want: map[string]string{}},
+		{name: "missing required file", user: user, project: missing, required: true, wantErr: "unable to read config file"},
+		{name: "unknown key", user: user, project: invalid, wantErr: "invalid config file " + invalid},
+		{name: "same file is read once", user: user, project: user, want: map[string]string{"output": "json", "namespace": "a", "deep": "true"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(tt.user, tt.project, tt.required)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if got := cfg.FlagValues(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FlagValues() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	tests := map[string]string{
+		"output":             "KOBOT_OUTPUT",
+		"flux-grace":         "KOBOT_FLUX_GRACE",
+		"namespace-selector": "KOBOT_NAMESPACE_SELECTOR",
+	}
+	for flag, want := range tests {
+		if got := EnvName(flag); got != want {
+			t.Errorf("EnvName(%q) = %q, want %q", flag, got, want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package logging
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/fatih/color"
 )
@@ -17,46 +18,65 @@ var (
 	// kobot		 = color.New(color.FgHiBlack)
 )
 
+// out receives the decorative progress output, errOut receives warnings and errors.
+// Both default to stdout so the console report reads top to bottom.
+var (
+	out    io.Writer = color.Output
+	errOut io.Writer = color.Output
+)
+
+// SetQuiet suppresses all decorative output. Warnings and errors are sent to
+// stderr instead so stdout stays clean for machine-readable reports.
+func SetQuiet(quiet bool) {
+	if quiet {
+		out = io.Discard
+		errOut = color.Error
+		return
+	}
+	out = color.Output
+	errOut = color.Output
+}
+
 func Kobot(format string, a ...interface{}) string {
-    tag := color.New(color.FgHiBlack).Sprint("SCAN INFO:")
-    return fmt.Sprintf("       | %s %s", tag, fmt.Sprintf(format, a...))
+	tag := color.New(color.FgHiBlack).Sprint("SCAN INFO:")
+	return fmt.Sprintf("       | %s %s", tag, fmt.Sprintf(format, a...))
 }
 
 func Action(msg string, args ...interface{}) {
-	infoColor.Printf("RECOMMENDATION    ")
-	fmt.Printf(msg+"\n", args...)
+	infoColor.Fprintf(out, "RECOMMENDATION    ")
+	fmt.Fprintf(out, msg+"\n", args...)
 }
 
 func Running(msg string, args ...interface{}) {
-	bold.Printf("RUNNING    ")
-	fmt.Printf(msg+"\n", args...)
+	bold.Fprintf(out, "RUNNING    ")
+	fmt.Fprintf(out, msg+"\n", args...)
 }
 
 func Info(msg string, args ...interface{}) {
-	infoColor.Printf("INFO        ")
-	fmt.Printf(msg+"\n", args...)
+	infoColor.Fprintf(out, "INFO        ")
+	fmt.Fprintf(out, msg+"\n", args...)
 }
 
 func Starting(msg string, args ...interface{}) {
-	successColor.Printf("STARTING    ")
-	fmt.Printf(msg+"\n", args...)
+	successColor.Fprintf(out, "STARTING    ")
+	fmt.Fprintf(out, msg+"\n", args...)
 }
 
 func Success(msg string, args ...interface{}) {
-	successColor.Printf("OK          ")
-	fmt.Printf(msg+"\n", args...)
+	successColor.Fprintf(out, "OK          ")
+	fmt.Fprintf(out, msg+"\n", args...)
 }
 
 func Warn(msg string, args ...interface{}) {
-	warnColor.Printf("WARN        ")
-	fmt.Printf(msg+"\n", args...)
+	warnColor.Fprintf(errOut, "WARN        ")
+	fmt.Fprintf(errOut, msg+"\n", args...)
 }
 
 func Error(msg string, args ...interface{}) {
-	errorColor.Printf("ERROR       ")
-	fmt.Printf(msg+"\n", args...)
+	errorColor.Fprintf(errOut, "ERROR       ")
+	fmt.Fprintf(errOut, msg+"\n", args...)
 }
 
 func Title(msg string, args ...interface{}) {
-	bold.Printf("\n%s\n", fmt.Sprintf(msg, args...))
+	bold.Fprintf(out, "\n%s\n", fmt.Sprintf(msg, args...))
 }